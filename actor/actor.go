@@ -0,0 +1,260 @@
+// Package actor gives a piece of state to a single goroutine and lets other
+// goroutines act on it only through messages, instead of sharing it under a
+// lock.
+//
+// Commands are functions over the state, run one at a time in arrival
+// order. Send enqueues a command without waiting for it; Ask waits for a
+// typed reply. The mailbox is bounded, so a busy actor pushes back on its
+// callers. A command that panics does not take the program down: the actor
+// recovers, reports the panic to the caller, rebuilds its state with the
+// init function and carries on, up to Options.MaxRestarts times.
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrStopped is returned for commands sent to a stopped actor.
+var ErrStopped = errors.New("actor: stopped")
+
+// PanicError is returned by Ask when the command panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("actor: command panicked: %v", e.Value)
+}
+
+// Options configures an Actor. Zero values are replaced by defaults.
+type Options struct {
+	// Mailbox is the number of commands that can wait. Default: 64.
+	Mailbox int
+	// MaxRestarts is how many panics the actor survives before stopping
+	// for good. Default: 10. Negative means no limit.
+	MaxRestarts int
+	// OnPanic is called in the actor goroutine for every recovered panic.
+	OnPanic func(err *PanicError)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Mailbox <= 0 {
+		o.Mailbox = 64
+	}
+	if o.MaxRestarts == 0 {
+		o.MaxRestarts = 10
+	}
+	return o
+}
+
+type envelope[S any] struct {
+	ctx  context.Context // The Ask's context; nil for Send
+	run  func(*S)
+	fail func(error) // Reports a panic to an Ask; nil for Send
+}
+
+// Actor owns a state of type S. Create it with New.
+type Actor[S any] struct {
+	init func() S
+	opts Options
+
+	mailbox  chan envelope[S]
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// sendMu is held for reading by every enqueue and for writing by the
+	// actor when it closes the mailbox, so no command is accepted after
+	// the final drain.
+	sendMu  sync.RWMutex
+	stopped bool
+
+	mu       sync.Mutex
+	restarts int
+}
+
+// New starts an actor whose state is built by init, which is called again
+// after every restart.
+func New[S any](init func() S, opts Options) *Actor[S] {
+	opts = opts.withDefaults()
+	a := &Actor[S]{
+		init:    init,
+		opts:    opts,
+		mailbox: make(chan envelope[S], opts.Mailbox),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go a.loop()
+	return a
+}
+
+// Send enqueues fn without waiting for it to run. It blocks while the
+// mailbox is full, until ctx is done or the actor stops. ctx only bounds
+// that wait: once Send returned nil, fn runs even if ctx ends.
+func (a *Actor[S]) Send(ctx context.Context, fn func(*S)) error {
+	return a.enqueue(ctx, envelope[S]{run: fn})
+}
+
+// Ask runs fn on the actor's state and returns its result. If ctx ends
+// first, Ask returns ctx.Err() and fn is skipped if it has not started yet.
+func Ask[S, R any](ctx context.Context, a *Actor[S], fn func(*S) R) (R, error) {
+	type result struct {
+		value R
+		err   error
+	}
+	reply := make(chan result, 1) // The actor never blocks on a gone caller
+
+	env := envelope[S]{
+		ctx:  ctx,
+		run:  func(s *S) { reply <- result{value: fn(s)} },
+		fail: func(err error) { reply <- result{err: err} },
+	}
+	var zero R
+	if err := a.enqueue(ctx, env); err != nil {
+		return zero, err
+	}
+
+	select {
+	case res := <-reply:
+		return res.value, res.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case <-a.done:
+		// The command may have run just before the actor stopped
+		select {
+		case res := <-reply:
+			return res.value, res.err
+		default:
+			return zero, ErrStopped
+		}
+	}
+}
+
+func (a *Actor[S]) enqueue(ctx context.Context, env envelope[S]) error {
+	a.sendMu.RLock()
+	defer a.sendMu.RUnlock()
+	if a.stopped {
+		return ErrStopped
+	}
+
+	// Checked first, as select picks randomly among ready cases
+	select {
+	case <-a.quit:
+		return ErrStopped
+	case <-a.done:
+		return ErrStopped
+	default:
+	}
+
+	select {
+	case a.mailbox <- env:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-a.quit:
+		return ErrStopped
+	case <-a.done:
+		return ErrStopped
+	}
+}
+
+// Stop stops accepting commands, runs the ones already in the mailbox and
+// waits for the actor goroutine to exit.
+func (a *Actor[S]) Stop() {
+	a.stopOnce.Do(func() { close(a.quit) })
+	<-a.done
+}
+
+// Done is closed once the actor has stopped, by Stop or after too many
+// panics.
+func (a *Actor[S]) Done() <-chan struct{} {
+	return a.done
+}
+
+// Restarts returns how many panics the actor has recovered from.
+func (a *Actor[S]) Restarts() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.restarts
+}
+
+func (a *Actor[S]) loop() {
+	defer close(a.done)
+	defer a.closeMailbox() // After too many panics; a no-op after Stop
+
+	state := a.init()
+	for {
+		select {
+		case env := <-a.mailbox:
+			if !a.handle(&state, env) {
+				return
+			}
+		case <-a.quit:
+			a.closeMailbox()
+			for {
+				select {
+				case env := <-a.mailbox:
+					if !a.handle(&state, env) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// closeMailbox makes every later enqueue fail. It waits for the enqueues in
+// progress, which return once they see quit or have put their command in
+// the mailbox.
+func (a *Actor[S]) closeMailbox() {
+	a.sendMu.Lock()
+	defer a.sendMu.Unlock()
+	a.stopped = true
+}
+
+// handle runs one command and reports whether the actor carries on.
+func (a *Actor[S]) handle(state *S, env envelope[S]) bool {
+	if env.ctx != nil && env.ctx.Err() != nil {
+		return true // The Ask gave up; nobody is waiting for the result
+	}
+
+	perr := run(state, env)
+	if perr == nil {
+		return true
+	}
+
+	if env.fail != nil {
+		env.fail(perr)
+	}
+	if a.opts.OnPanic != nil {
+		a.opts.OnPanic(perr)
+	}
+
+	a.mu.Lock()
+	a.restarts++
+	restarts := a.restarts
+	a.mu.Unlock()
+	if a.opts.MaxRestarts >= 0 && restarts > a.opts.MaxRestarts {
+		return false
+	}
+	// The command may have left the state half-updated
+	*state = a.init()
+	return true
+}
+
+func run[S any](state *S, env envelope[S]) (perr *PanicError) {
+	defer func() {
+		if v := recover(); v != nil {
+			perr = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	env.run(state)
+	return nil
+}
@@ -0,0 +1,261 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-projects/leakcheck"
+)
+
+// The workloads of the counter, safecounter and rwmutex demos, with the
+// state owned by an actor instead of a lock.
+
+func TestCounterWorkload(t *testing.T) {
+	leakcheck.Check(t)
+	counter := New(func() int { return 0 }, Options{})
+	defer counter.Stop()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				if err := counter.Send(ctx, func(n *int) { *n++ }); err != nil {
+					t.Errorf("Send: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Ask queues behind every Send above, so it sees all of them
+	got, err := Ask(ctx, counter, func(n *int) int { return *n })
+	if err != nil || got != 10_000 {
+		t.Errorf("counter = %d, %v; want 10000", got, err)
+	}
+}
+
+func TestMapWorkload(t *testing.T) {
+	leakcheck.Check(t)
+	m := New(func() map[string]string { return make(map[string]string) }, Options{Mailbox: 4})
+	defer m.Stop()
+
+	ctx := context.Background()
+	set := func(key, value string) error {
+		return m.Send(ctx, func(data *map[string]string) { (*data)[key] = value })
+	}
+	get := func(key string) (string, error) {
+		return Ask(ctx, m, func(data *map[string]string) string { return (*data)[key] })
+	}
+
+	for _, k := range []string{"keyA", "keyB", "keyC"} {
+		_ = set(k, "value"+k[3:])
+	}
+
+	var wg sync.WaitGroup
+	for r := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 5 {
+				key := []string{"keyA", "keyB", "keyC"}[(r+i)%3]
+				if v, err := get(key); err != nil || v == "" {
+					t.Errorf("get(%s) = %q, %v", key, v, err)
+				}
+			}
+		}()
+	}
+	for w := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 2 {
+				_ = set(fmt.Sprintf("key%d", w*2+i), fmt.Sprintf("value%d-%d", w, i))
+			}
+		}()
+	}
+	wg.Wait()
+
+	n, _ := Ask(ctx, m, func(data *map[string]string) int { return len(*data) })
+	if n != 7 {
+		t.Errorf("len = %d, want 7", n)
+	}
+}
+
+func TestAskTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		// busy occupies the actor for the whole test, so the mailbox fills
+		mailbox int
+		queued  int
+	}{
+		{"waiting for reply", 1, 0},
+		{"mailbox full", 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+			a := New(func() int { return 0 }, Options{Mailbox: tt.mailbox})
+			defer a.Stop()
+
+			release := make(chan struct{})
+			defer close(release)
+			_ = a.Send(context.Background(), func(*int) { <-release })
+			for range tt.queued {
+				_ = a.Send(context.Background(), func(n *int) { *n++ })
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			ran := false
+			_, err := Ask(ctx, a, func(*int) bool { ran = true; return true })
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Ask: err = %v, want deadline exceeded", err)
+			}
+
+			// Once the actor is free, the expired command must not run
+			release <- struct{}{}
+			if _, err := Ask(context.Background(), a, func(*int) bool { return true }); err != nil {
+				t.Fatal(err)
+			}
+			if ran {
+				t.Error("command ran after its caller gave up")
+			}
+		})
+	}
+}
+
+func TestPanicRestart(t *testing.T) {
+	leakcheck.Check(t)
+
+	var reported []*PanicError
+	a := New(func() int { return 100 }, Options{OnPanic: func(err *PanicError) { reported = append(reported, err) }})
+	defer a.Stop()
+	ctx := context.Background()
+
+	_ = a.Send(ctx, func(n *int) { *n = 5 })
+	_, err := Ask(ctx, a, func(n *int) int {
+		*n = -1 // Half-done update, discarded by the restart
+		panic("boom")
+	})
+
+	var perr *PanicError
+	if !errors.As(err, &perr) || perr.Value != "boom" || len(perr.Stack) == 0 {
+		t.Fatalf("Ask: err = %v, want PanicError(boom) with a stack", err)
+	}
+	if got, _ := Ask(ctx, a, func(n *int) int { return *n }); got != 100 {
+		t.Errorf("state after restart = %d, want the initial 100", got)
+	}
+	if a.Restarts() != 1 || len(reported) != 1 {
+		t.Errorf("restarts = %d, reported = %d; want 1, 1", a.Restarts(), len(reported))
+	}
+}
+
+func TestTooManyPanics(t *testing.T) {
+	leakcheck.Check(t)
+	a := New(func() int { return 0 }, Options{MaxRestarts: 2})
+	ctx := context.Background()
+
+	for range 3 {
+		_ = a.Send(ctx, func(*int) { panic("boom") })
+	}
+	<-a.Done()
+
+	if err := a.Send(ctx, func(*int) {}); !errors.Is(err, ErrStopped) {
+		t.Errorf("Send after giving up: err = %v, want ErrStopped", err)
+	}
+	if _, err := Ask(ctx, a, func(n *int) int { return *n }); !errors.Is(err, ErrStopped) {
+		t.Errorf("Ask after giving up: err = %v, want ErrStopped", err)
+	}
+	if a.Restarts() != 3 {
+		t.Errorf("restarts = %d, want 3", a.Restarts())
+	}
+}
+
+func TestSendOutlivesContext(t *testing.T) {
+	leakcheck.Check(t)
+
+	a := New(func() int { return 0 }, Options{Mailbox: 100})
+	defer a.Stop()
+	release := make(chan struct{})
+	_ = a.Send(context.Background(), func(*int) { <-release })
+
+	// The usual pattern: the context is canceled as soon as Send returns,
+	// long before the busy actor gets to the command
+	for range 10 {
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := a.Send(ctx, func(n *int) { *n++ }); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		cancel()
+	}
+	close(release)
+
+	n, err := Ask(context.Background(), a, func(n *int) int { return *n })
+	if err != nil || n != 10 {
+		t.Errorf("counter = %d, %v; want all 10 accepted increments", n, err)
+	}
+}
+
+func TestStopDrainsMailbox(t *testing.T) {
+	leakcheck.Check(t)
+
+	var total int
+	a := New(func() int { return 0 }, Options{Mailbox: 100})
+	release := make(chan struct{})
+	_ = a.Send(context.Background(), func(*int) { <-release })
+	for range 50 {
+		_ = a.Send(context.Background(), func(n *int) { *n++; total = *n })
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		a.Stop()
+		close(stopped)
+	}()
+	<-a.quit // Stop has been called
+	close(release)
+	<-stopped
+
+	if total != 50 {
+		t.Errorf("ran %d queued commands before stopping, want 50", total)
+	}
+	if err := a.Send(context.Background(), func(*int) {}); !errors.Is(err, ErrStopped) {
+		t.Errorf("Send after Stop: err = %v", err)
+	}
+}
+
+func TestStopDuringSend(t *testing.T) {
+	leakcheck.Check(t)
+
+	for range 1000 {
+		a := New(func() int { return 0 }, Options{Mailbox: 1})
+		ran := 0 // Only touched by the actor goroutine until Stop returns
+		var accepted atomic.Int32
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for a.Send(context.Background(), func(*int) { ran++ }) == nil {
+					accepted.Add(1)
+				}
+			}()
+		}
+		a.Stop()
+		wg.Wait()
+
+		if int32(ran) != accepted.Load() {
+			t.Fatalf("%d Sends returned nil but %d commands ran", accepted.Load(), ran)
+		}
+	}
+}
@@ -1,15 +1,44 @@
 module postgres
 
-go 1.24.3
+go 1.25
 
-toolchain go1.24.6
-
-require github.com/lib/pq v1.10.9
+require (
+	github.com/cooler-SAI/go-Tools v0.0.8
+	github.com/lib/pq v1.10.9
+	github.com/redis/go-redis/v9 v9.14.0
+	go-projects v0.0.0-00010101000000-000000000000
+	kafka v0.0.0-00010101000000-000000000000
+	redis v0.0.0-00010101000000-000000000000
+)
 
 require (
-	github.com/cooler-SAI/go-Tools v0.0.8 // indirect
+	github.com/IBM/sarama v1.46.3 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/eapache/go-resiliency v1.7.0 // indirect
+	github.com/eapache/go-xerial-snappy v0.0.0-20230731223053-c322873962e3 // indirect
+	github.com/eapache/queue v1.1.0 // indirect
+	github.com/golang/snappy v0.0.4 // indirect
+	github.com/hashicorp/go-uuid v1.0.3 // indirect
+	github.com/jcmturner/aescts/v2 v2.0.0 // indirect
+	github.com/jcmturner/dnsutils/v2 v2.0.0 // indirect
+	github.com/jcmturner/gofork v1.7.6 // indirect
+	github.com/jcmturner/gokrb5/v8 v8.4.4 // indirect
+	github.com/jcmturner/rpc/v2 v2.0.3 // indirect
+	github.com/klauspost/compress v1.18.1 // indirect
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/pierrec/lz4/v4 v4.1.22 // indirect
+	github.com/rcrowley/go-metrics v0.0.0-20250401214520-65e299d6c5c9 // indirect
 	github.com/rs/zerolog v1.34.0 // indirect
-	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/crypto v0.43.0 // indirect
+	golang.org/x/net v0.46.0 // indirect
+	golang.org/x/sys v0.37.0 // indirect
 )
+
+replace kafka => ../../kafka
+
+replace go-projects => ../..
+
+replace redis => ../../database/noSQL/redis
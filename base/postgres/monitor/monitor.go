@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+	"github.com/redis/go-redis/v9"
+
+	"go-projects/health"
+	"go-projects/lifecycle"
+	"go-projects/poolstats"
+	"postgres/pgconn"
+	"redis/redisconn"
+)
+
+// serve runs the health endpoints and the pool metrics on /debug/vars
+// until ctx is done.
+func serve(ctx context.Context, addr string, monitor *health.Monitor) error {
+	mux := http.NewServeMux()
+	monitor.Mount(mux)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	served := make(chan error, 1)
+	go func() { served <- srv.ListenAndServe() }()
+	zerolog.Log.Info().Str("addr", addr).Msg("Health endpoints: " + health.LivePath + ", " + health.ReadyPath)
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:8081", "address of the health endpoints")
+	interval := flag.Duration("interval", 5*time.Second, "time between health checks")
+	flag.Parse()
+
+	zerolog.Init()
+	app := lifecycle.New(lifecycle.Options{})
+
+	// PG_* and REDIS_* environment variables set the pool sizes
+	pgConfig, err := pgconn.ConfigFromEnv()
+	if err != nil {
+		zerolog.Log.Fatal().Err(err).Msg("Invalid Postgres settings")
+	}
+	redisConfig, err := redisconn.ConfigFromEnv()
+	if err != nil {
+		zerolog.Log.Fatal().Err(err).Msg("Invalid Redis settings")
+	}
+
+	// Neither call connects, so a server that is down doesn't stop the
+	// program. Both pools reconnect on their own once it is back.
+	db, err := pgconn.OpenPool(pgConfig)
+	if err != nil {
+		zerolog.Log.Fatal().Err(err).Msg("Invalid Postgres settings")
+	}
+	rdb := redis.NewClient(redisConfig.Options())
+	app.OnShutdown("postgres", func(context.Context) error { return db.Close() })
+	app.OnShutdown("redis", func(context.Context) error { return rdb.Close() })
+
+	monitor := health.New(health.Options{
+		Interval: *interval,
+		OnChange: func(e health.Event) {
+			if e.To == health.Up {
+				zerolog.Log.Info().Str("dependency", e.Name).Stringer("from", e.From).Msg("✅ Dependency up")
+				return
+			}
+			zerolog.Log.Warn().Err(e.Err).Str("dependency", e.Name).Stringer("from", e.From).Msg("❌ Dependency down")
+		},
+	})
+	monitor.Register("postgres", db.PingContext)
+	monitor.Register("redis", func(ctx context.Context) error { return rdb.Ping(ctx).Err() })
+
+	pools := poolstats.New(poolstats.Options{
+		Interval: *interval,
+		OnSample: func(s poolstats.Sample) {
+			zerolog.Log.Debug().Str("pool", s.Name).
+				Int("open", s.Stats.Open).Int("in_use", s.Stats.InUse).Int("idle", s.Stats.Idle).
+				Int64("waits", s.Interval.WaitCount).Dur("wait", s.Interval.WaitDuration).
+				Int64("timeouts", s.Interval.Timeouts).Msg("Pool stats")
+		},
+	})
+	pools.Register("postgres", poolstats.DB(db))
+	pools.Register("redis", redisconn.Stats(rdb))
+	pools.Publish("pools")
+
+	app.Go("health monitor", monitor.Run)
+	app.Go("pool stats", pools.Run)
+	app.Go("health server", func(ctx context.Context) error { return serve(ctx, *addr, monitor) })
+
+	fmt.Printf("Stop or start the containers (docker stop my-postgres) and watch http://%s%s\n", *addr, health.ReadyPath)
+	fmt.Printf("Pool metrics are under \"pools\" in http://%s/debug/vars\n", *addr)
+	if err := app.Wait(); err != nil {
+		zerolog.Log.Error().Err(err).Msg("Shutdown finished with errors")
+	}
+}
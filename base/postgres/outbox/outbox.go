@@ -0,0 +1,76 @@
+// Package outbox implements the transactional outbox pattern for PostgreSQL.
+//
+// Instead of publishing an event after a transaction commits (and losing it if
+// the process dies in between) or before it commits (and announcing a change
+// that may be rolled back), the event is inserted into the outbox table with
+// the same *sql.Tx as the business change. A Relay later reads undelivered
+// rows with FOR UPDATE SKIP LOCKED, publishes them to a Sink and marks them
+// delivered. Delivery is at-least-once: a crash between publishing and the
+// commit of the relay transaction publishes the row again, so consumers should
+// deduplicate by Event.ID.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Schema creates the outbox table and a partial index over pending rows. A
+// row is pending until it is delivered or parked by the relay after
+// RelayOptions.MaxAttempts failures.
+const Schema = `
+CREATE TABLE IF NOT EXISTS outbox (
+	id BIGSERIAL PRIMARY KEY,
+	topic VARCHAR(255) NOT NULL,
+	key VARCHAR(255) NOT NULL DEFAULT '',
+	payload JSONB NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	delivered_at TIMESTAMPTZ,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	parked_at TIMESTAMPTZ
+);
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS parked_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (id) WHERE delivered_at IS NULL AND parked_at IS NULL;`
+
+// Event is one row of the outbox table.
+type Event struct {
+	ID        int64
+	Topic     string
+	Key       string
+	Payload   []byte
+	CreatedAt time.Time
+	Attempts  int
+}
+
+// CreateTable applies Schema.
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, Schema); err != nil {
+		return fmt.Errorf("outbox: create table: %w", err)
+	}
+	return nil
+}
+
+// Enqueue stores payload as JSON in the outbox using tx, so the event is
+// committed or rolled back together with the rest of the transaction.
+//
+// A single relay publishes the events of one key in insertion order. Several
+// relays claim disjoint batches and publish them concurrently, so events of
+// one key can then overtake each other; consumers that need the order must
+// restore it from Event.ID. An empty key carries no ordering at all.
+func Enqueue(ctx context.Context, tx *sql.Tx, topic, key string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("outbox: encode %s event: %w", topic, err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO outbox (topic, key, payload) VALUES ($1, $2, $3)", topic, key, data)
+	if err != nil {
+		return fmt.Errorf("outbox: insert %s event: %w", topic, err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var errBroken = errors.New("broker down")
+
+func TestMemorySink(t *testing.T) {
+	s := &MemorySink{Fail: func(e Event) error {
+		if e.ID == 2 {
+			return errBroken
+		}
+		return nil
+	}}
+	ctx := context.Background()
+
+	for id := int64(1); id <= 3; id++ {
+		err := s.Publish(ctx, Event{ID: id})
+		if want := id == 2; (err != nil) != want {
+			t.Errorf("Publish(%d) = %v, want failure %t", id, err, want)
+		}
+	}
+	got := s.Events()
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 3 {
+		t.Errorf("Events = %+v, want 1 and 3", got)
+	}
+
+	got[0].ID = 42
+	if s.Events()[0].ID != 1 {
+		t.Error("Events shares its slice with the sink")
+	}
+}
+
+// result is an outcome reduced to what the test checks.
+type result struct {
+	id      int64
+	failed  bool
+	park    bool
+	skipped bool
+}
+
+func TestPublish(t *testing.T) {
+	poison := func(e Event) error {
+		if e.Payload != nil {
+			return errBroken
+		}
+		return nil
+	}
+	bad := []byte("bad")
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		events      []Event
+		want        []result
+	}{
+		{
+			name: "failure holds back only its key",
+			events: []Event{
+				{ID: 1, Key: "a", Payload: bad},
+				{ID: 2, Key: "b"},
+				{ID: 3, Key: "a"},
+				{ID: 4, Key: "b"},
+			},
+			want: []result{{id: 1, failed: true}, {id: 2}, {id: 3, skipped: true}, {id: 4}},
+		},
+		{
+			name: "events without a key are never held back",
+			events: []Event{
+				{ID: 1, Payload: bad},
+				{ID: 2},
+			},
+			want: []result{{id: 1, failed: true}, {id: 2}},
+		},
+		{
+			name:        "last attempt parks the event and frees its key",
+			maxAttempts: 3,
+			events: []Event{
+				{ID: 1, Key: "a", Payload: bad, Attempts: 2},
+				{ID: 2, Key: "a"},
+			},
+			want: []result{{id: 1, failed: true, park: true}, {id: 2}},
+		},
+		{
+			name:        "earlier attempts do not park",
+			maxAttempts: 3,
+			events: []Event{
+				{ID: 1, Key: "a", Payload: bad, Attempts: 1},
+				{ID: 2, Key: "a"},
+			},
+			want: []result{{id: 1, failed: true}, {id: 2, skipped: true}},
+		},
+		{
+			name:        "negative MaxAttempts never parks",
+			maxAttempts: -1,
+			events:      []Event{{ID: 1, Key: "a", Payload: bad, Attempts: 1000}},
+			want:        []result{{id: 1, failed: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &MemorySink{Fail: poison}
+			r := NewRelay(nil, sink, RelayOptions{MaxAttempts: tt.maxAttempts})
+
+			var got []result
+			for _, o := range r.publish(context.Background(), tt.events) {
+				got = append(got, result{id: o.event.ID, failed: o.err != nil, park: o.park, skipped: o.skipped})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("outcomes = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("outcome %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPublishStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sink := &MemorySink{Fail: func(Event) error {
+		cancel()
+		return nil
+	}}
+	r := NewRelay(nil, sink, RelayOptions{})
+
+	out := r.publish(ctx, []Event{{ID: 1}, {ID: 2}, {ID: 3}})
+	if len(out) != 1 || out[0].err != nil {
+		t.Errorf("outcomes = %+v, want only event 1, delivered", out)
+	}
+}
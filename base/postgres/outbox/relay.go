@@ -0,0 +1,213 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+)
+
+// RelayOptions configures a Relay. Zero values are replaced by defaults.
+type RelayOptions struct {
+	// BatchSize is the maximum number of rows claimed per poll. Default: 100.
+	BatchSize int
+	// Interval is the pause between polls when the outbox is empty. Default: 1s.
+	Interval time.Duration
+	// MaxAttempts is the number of failed publishes after which a row is
+	// parked: parked_at is set and the relay skips it from then on, so one
+	// poison event does not hold back its key forever. Default: 10. Negative
+	// retries forever.
+	MaxAttempts int
+}
+
+// Relay moves events from the outbox table to a Sink. Several relays can run
+// against the same table: FOR UPDATE SKIP LOCKED hands every row to exactly
+// one of them at a time, but events of one key then lose their order.
+type Relay struct {
+	db   *sql.DB
+	sink Sink
+	opts RelayOptions
+}
+
+// NewRelay creates a Relay publishing to sink.
+func NewRelay(db *sql.DB, sink Sink, opts RelayOptions) *Relay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = 10
+	}
+	return &Relay{db: db, sink: sink, opts: opts}
+}
+
+// Run polls the outbox until ctx is done. Full batches are followed by an
+// immediate poll, so a backlog drains without waiting for Interval.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		n, err := r.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			zerolog.Log.Error().Err(err).Msg("Outbox relay poll failed")
+		}
+		if err == nil && n == r.opts.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.opts.Interval):
+		}
+	}
+}
+
+// RunOnce claims up to BatchSize pending rows, publishes them in id order and
+// marks the published ones delivered. A failed publish updates the row's
+// attempts and last_error, and the rest of the batch goes on without the
+// later events of the same key, so they never overtake it; they are retried
+// on the next poll. A row that fails for the MaxAttempts-th time is parked
+// instead and no longer holds its key back. It returns the number of
+// delivered events.
+func (r *Relay) RunOnce(ctx context.Context) (delivered int, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("outbox: begin: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			zerolog.Log.Error().Err(err).Msg("Outbox relay rollback failed")
+		}
+	}()
+
+	events, err := claim(ctx, tx, r.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, o := range r.publish(ctx, events) {
+		switch {
+		case o.skipped:
+			continue
+		case o.err == nil:
+			_, err = tx.ExecContext(ctx,
+				"UPDATE outbox SET delivered_at = now(), attempts = attempts + 1, last_error = NULL WHERE id = $1",
+				o.event.ID)
+			if err != nil {
+				return delivered, fmt.Errorf("outbox: mark event %d delivered: %w", o.event.ID, err)
+			}
+			delivered++
+		case o.park:
+			zerolog.Log.Warn().Err(o.err).Int64("id", o.event.ID).Str("key", o.event.Key).
+				Msg("Outbox event parked after too many failed attempts")
+			_, err = tx.ExecContext(ctx,
+				"UPDATE outbox SET parked_at = now(), attempts = attempts + 1, last_error = $2 WHERE id = $1",
+				o.event.ID, o.err.Error())
+			if err != nil {
+				return delivered, fmt.Errorf("outbox: park event %d: %w", o.event.ID, err)
+			}
+		default:
+			_, err = tx.ExecContext(ctx,
+				"UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1",
+				o.event.ID, o.err.Error())
+			if err != nil {
+				return delivered, fmt.Errorf("outbox: record failure of event %d: %w", o.event.ID, err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		// The events were published but stay pending, so they will be sent again.
+		return 0, fmt.Errorf("outbox: commit: %w", err)
+	}
+	return delivered, nil
+}
+
+// outcome is what became of one claimed event.
+type outcome struct {
+	event   Event
+	err     error // The publish error, nil once delivered
+	park    bool  // err is the event's last allowed failure
+	skipped bool  // Not published: an earlier event of the same key failed
+}
+
+// publish sends events to the sink in order. After a failure, later events
+// of the same key are skipped; events without a key are never held back.
+// Events left when ctx ends are not in the result, so they cost no attempt.
+func (r *Relay) publish(ctx context.Context, events []Event) []outcome {
+	var (
+		out     []outcome
+		blocked = make(map[string]bool)
+	)
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if event.Key != "" && blocked[event.Key] {
+			out = append(out, outcome{event: event, skipped: true})
+			continue
+		}
+
+		err := r.sink.Publish(ctx, event)
+		o := outcome{event: event, err: err}
+		if err != nil {
+			o.park = r.opts.MaxAttempts > 0 && event.Attempts+1 >= r.opts.MaxAttempts
+			if !o.park && event.Key != "" {
+				blocked[event.Key] = true
+			}
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// Unpark makes a parked event pending again with a fresh attempt count, e.g.
+// after the cause of its failures is fixed.
+func Unpark(ctx context.Context, db *sql.DB, id int64) error {
+	res, err := db.ExecContext(ctx,
+		"UPDATE outbox SET parked_at = NULL, attempts = 0 WHERE id = $1 AND parked_at IS NOT NULL", id)
+	if err != nil {
+		return fmt.Errorf("outbox: unpark event %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("outbox: event %d is not parked", id)
+	}
+	return nil
+}
+
+// claim locks pending rows that no other relay is working on.
+func claim(ctx context.Context, tx *sql.Tx, limit int) ([]Event, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, topic, key, payload, created_at, attempts
+		FROM outbox
+		WHERE delivered_at IS NULL AND parked_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("outbox: claim: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			zerolog.Log.Error().Err(err).Msg("Error closing rows")
+		}
+	}(rows)
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.Topic, &e.Key, &e.Payload, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, fmt.Errorf("outbox: scan: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox: claim: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"kafka"
+)
+
+// Sink publishes outbox events to a message system. Publish must return only
+// after the event is durably accepted, because the relay marks the row
+// delivered as soon as Publish returns nil.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// KafkaSink publishes every event to the Kafka topic named by Event.Topic,
+// keyed by Event.Key so events of one key stay ordered within a partition.
+type KafkaSink struct {
+	producer kafka.Producer
+}
+
+// NewKafkaSink creates a Sink on top of a kafka.Producer.
+func NewKafkaSink(producer kafka.Producer) *KafkaSink {
+	return &KafkaSink{producer: producer}
+}
+
+// Publish implements Sink. The outbox row id travels in the "outbox-id"
+// header for deduplication on the consumer side.
+func (s *KafkaSink) Publish(ctx context.Context, event Event) error {
+	return s.producer.Send(ctx, kafka.Message{
+		Topic:   event.Topic,
+		Key:     []byte(event.Key),
+		Value:   event.Payload,
+		Headers: map[string]string{"outbox-id": strconv.FormatInt(event.ID, 10)},
+	})
+}
+
+// RedisStreamSink appends every event to the Redis stream prefix+Event.Topic.
+type RedisStreamSink struct {
+	rdb    redis.Cmdable
+	prefix string
+}
+
+// NewRedisStreamSink creates a Sink writing to Redis streams named prefix+topic.
+func NewRedisStreamSink(rdb redis.Cmdable, prefix string) *RedisStreamSink {
+	return &RedisStreamSink{rdb: rdb, prefix: prefix}
+}
+
+// Publish implements Sink.
+func (s *RedisStreamSink) Publish(ctx context.Context, event Event) error {
+	err := s.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.prefix + event.Topic,
+		Values: map[string]any{
+			"outbox_id": event.ID,
+			"key":       event.Key,
+			"payload":   string(event.Payload),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("outbox: xadd %s: %w", s.prefix+event.Topic, err)
+	}
+	return nil
+}
+
+// MemorySink keeps published events in memory, for tests and demos.
+type MemorySink struct {
+	// Fail, if set, is called before an event is stored; a non-nil error is
+	// returned from Publish instead of storing the event.
+	Fail func(event Event) error
+
+	mu     sync.Mutex
+	events []Event
+}
+
+// Publish implements Sink.
+func (s *MemorySink) Publish(_ context.Context, event Event) error {
+	if s.Fail != nil {
+		if err := s.Fail(event); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Events returns a copy of the published events in publish order.
+func (s *MemorySink) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
@@ -0,0 +1,154 @@
+// Package pgconn opens the PostgreSQL connection pool on first use.
+//
+// sql.Open does not connect, so a database that is down or still starting
+// only shows up on the first query. Lazy pings the server before handing
+// out the *sql.DB, retries with backoff while it can't reach it, and gives
+// up straight away on errors that retrying won't fix, like a wrong password
+// or a missing database.
+//
+// Config holds the pool settings next to the DSN. database/sql defaults to
+// an unlimited number of open connections, which under load turns into an
+// unlimited number of Postgres backends; Config caps it.
+package pgconn
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lib/pq"
+
+	"go-projects/envconf"
+	"go-projects/errs"
+	"go-projects/lazy"
+)
+
+// DSN connects to the db service of docker-compose.yml.
+const DSN = "user=postgres password=example host=localhost port=5432 dbname=postgres sslmode=disable"
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvDSN             = "PG_DSN"
+	EnvMaxOpenConns    = "PG_MAX_OPEN_CONNS"
+	EnvMaxIdleConns    = "PG_MAX_IDLE_CONNS"
+	EnvConnMaxLifetime = "PG_CONN_MAX_LIFETIME" // A time.Duration, like "30m"
+	EnvConnMaxIdleTime = "PG_CONN_MAX_IDLE_TIME"
+)
+
+// Config configures the connection pool. Zero values are replaced by
+// defaults.
+type Config struct {
+	// DSN is the connection string. Default: DSN.
+	DSN string
+	// MaxOpenConns caps the connections in use plus idle. Callers beyond
+	// it wait for a free connection. Default: 10. Negative means no limit.
+	MaxOpenConns int
+	// MaxIdleConns is how many connections are kept open when not in use.
+	// Default: MaxOpenConns (2 without a limit), so a busy pool doesn't
+	// close and reopen connections between bursts. Negative keeps none.
+	MaxIdleConns int
+	// ConnMaxLifetime closes connections older than this, so they move to
+	// new servers after a failover. Default: 30m.
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime closes connections idle for longer. Default: 5m.
+	ConnMaxIdleTime time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.DSN == "" {
+		c.DSN = DSN
+	}
+	if c.MaxOpenConns == 0 {
+		c.MaxOpenConns = 10
+	}
+	if c.MaxIdleConns == 0 {
+		c.MaxIdleConns = max(c.MaxOpenConns, 2)
+	}
+	if c.ConnMaxLifetime <= 0 {
+		c.ConnMaxLifetime = 30 * time.Minute
+	}
+	if c.ConnMaxIdleTime <= 0 {
+		c.ConnMaxIdleTime = 5 * time.Minute
+	}
+	return c
+}
+
+// ConfigFromEnv returns a Config set from the PG_* environment variables.
+// Unset variables keep their defaults.
+func ConfigFromEnv() (Config, error) {
+	var c Config
+	c.DSN = os.Getenv(EnvDSN)
+	var err error
+	if c.MaxOpenConns, err = envconf.Int(EnvMaxOpenConns); err != nil {
+		return c, fmt.Errorf("pgconn: %w", err)
+	}
+	if c.MaxIdleConns, err = envconf.Int(EnvMaxIdleConns); err != nil {
+		return c, fmt.Errorf("pgconn: %w", err)
+	}
+	if c.ConnMaxLifetime, err = envconf.Duration(EnvConnMaxLifetime); err != nil {
+		return c, fmt.Errorf("pgconn: %w", err)
+	}
+	if c.ConnMaxIdleTime, err = envconf.Duration(EnvConnMaxIdleTime); err != nil {
+		return c, fmt.Errorf("pgconn: %w", err)
+	}
+	return c, nil
+}
+
+// Apply sets the pool limits of c on db.
+func (c Config) Apply(db *sql.DB) {
+	c = c.withDefaults()
+	db.SetMaxOpenConns(max(c.MaxOpenConns, 0))
+	db.SetMaxIdleConns(max(c.MaxIdleConns, 0))
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
+
+// Lazy returns a Lazy that opens and pings the pool on the first Get.
+// After a lost connection, Reset it and close the old *sql.DB it returns.
+func Lazy(cfg Config, opts lazy.Options) *lazy.Lazy[*sql.DB] {
+	return lazy.New(func(ctx context.Context) (*sql.DB, error) {
+		return Open(ctx, cfg)
+	}, opts)
+}
+
+// Open opens a pool configured by cfg and checks that the server answers.
+func Open(ctx context.Context, cfg Config) (*sql.DB, error) {
+	db, err := OpenPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("pgconn: ping: %w", classify(err))
+	}
+	return db, nil
+}
+
+// OpenPool opens a pool configured by cfg without connecting, for callers
+// that want to start while the server is still down.
+func OpenPool(cfg Config) (*sql.DB, error) {
+	cfg = cfg.withDefaults()
+	db, err := sql.Open("postgres", cfg.DSN)
+	if err != nil {
+		return nil, errs.MarkPermanent(fmt.Errorf("pgconn: open: %w", err))
+	}
+	cfg.Apply(db)
+	return db, nil
+}
+
+// classify marks server errors that won't go away by retrying.
+func classify(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code.Class() {
+	case "28", // invalid_authorization_specification
+		"3D": // invalid_catalog_name
+		return errs.MarkPermanent(err)
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package pgconn
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"go-projects/errs"
+)
+
+func TestWithDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Config
+		want Config
+	}{
+		{
+			name: "zero",
+			want: Config{DSN: DSN, MaxOpenConns: 10, MaxIdleConns: 10, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: 5 * time.Minute},
+		},
+		{
+			name: "idle follows open",
+			in:   Config{MaxOpenConns: 4},
+			want: Config{DSN: DSN, MaxOpenConns: 4, MaxIdleConns: 4, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: 5 * time.Minute},
+		},
+		{
+			name: "no open limit keeps 2 idle",
+			in:   Config{MaxOpenConns: -1},
+			want: Config{DSN: DSN, MaxOpenConns: -1, MaxIdleConns: 2, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: 5 * time.Minute},
+		},
+		{
+			name: "explicit values stay",
+			in:   Config{DSN: "dbname=x", MaxOpenConns: 20, MaxIdleConns: -1, ConnMaxLifetime: time.Hour, ConnMaxIdleTime: time.Minute},
+			want: Config{DSN: "dbname=x", MaxOpenConns: 20, MaxIdleConns: -1, ConnMaxLifetime: time.Hour, ConnMaxIdleTime: time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.withDefaults(); got != tt.want {
+				t.Errorf("withDefaults = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{name: "unset", want: Config{}},
+		{
+			name: "all set",
+			env: map[string]string{
+				EnvDSN:             "dbname=x",
+				EnvMaxOpenConns:    "20",
+				EnvMaxIdleConns:    "5",
+				EnvConnMaxLifetime: "1h",
+				EnvConnMaxIdleTime: "1m",
+			},
+			want: Config{DSN: "dbname=x", MaxOpenConns: 20, MaxIdleConns: 5, ConnMaxLifetime: time.Hour, ConnMaxIdleTime: time.Minute},
+		},
+		{name: "bad number", env: map[string]string{EnvMaxOpenConns: "many"}, wantErr: true},
+		{name: "bad duration", env: map[string]string{EnvConnMaxIdleTime: "60"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{EnvDSN, EnvMaxOpenConns, EnvMaxIdleConns, EnvConnMaxLifetime, EnvConnMaxIdleTime} {
+				v, ok := tt.env[name]
+				t.Setenv(name, v) // Restores the variable after the test
+				if !ok {
+					_ = os.Unsetenv(name)
+				}
+			}
+
+			got, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv error = %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ConfigFromEnv = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		maxOpen int
+	}{
+		{"default", Config{}, 10},
+		{"limited", Config{MaxOpenConns: 3}, 3},
+		{"no limit", Config{MaxOpenConns: -1}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sql.Open("postgres", DSN) // Does not connect
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			tt.cfg.Apply(db)
+			if got := db.Stats().MaxOpenConnections; got != tt.maxOpen {
+				t.Errorf("MaxOpenConnections = %d, want %d", got, tt.maxOpen)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"wrong password", &pq.Error{Code: "28P01"}, true},
+		{"missing database", &pq.Error{Code: "3D000"}, true},
+		{"too many connections", &pq.Error{Code: "53300"}, false},
+		{"network", errors.New("dial tcp: connection refused"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classify(tt.err)
+			if got := errs.IsPermanent(err); got != tt.permanent {
+				t.Errorf("IsPermanent = %t, want %t", got, tt.permanent)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classify lost the original error %v", tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"go-projects/poolstats"
+	"postgres/pgconn"
+	"redis/redisconn"
+)
+
+// target is one pool under test: what a worker runs and how the pool
+// reports its statistics.
+type target struct {
+	op    func(ctx context.Context) error
+	stats poolstats.Source
+	close func() error
+}
+
+// openPostgres opens a pool of size connections. The query holds its
+// connection for a while, like a real one would.
+func openPostgres(ctx context.Context, size int, query string) (*target, error) {
+	cfg, err := pgconn.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg.MaxOpenConns, cfg.MaxIdleConns = size, size
+	db, err := pgconn.Open(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &target{
+		op: func(ctx context.Context) error {
+			_, err := db.ExecContext(ctx, query)
+			return err
+		},
+		stats: poolstats.DB(db),
+		close: db.Close,
+	}, nil
+}
+
+// openRedis opens a client with a pool of size connections.
+func openRedis(ctx context.Context, size int) (*target, error) {
+	cfg, err := redisconn.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg.PoolSize = size
+	cfg.PoolTimeout = time.Minute // Measure the wait instead of failing
+	client, err := redisconn.Open(ctx, cfg.Options())
+	if err != nil {
+		return nil, err
+	}
+	return &target{
+		op: func(ctx context.Context) error {
+			return client.Incr(ctx, "poolload:counter").Err()
+		},
+		stats: redisconn.Stats(client),
+		close: client.Close,
+	}, nil
+}
+
+// result is the outcome of one pool size.
+type result struct {
+	size      int
+	ops       int
+	errors    int
+	elapsed   time.Duration
+	latencies []time.Duration // Sorted
+	pool      poolstats.Stats // Change over the run
+}
+
+func (r result) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	return r.latencies[int(p*float64(len(r.latencies)-1))]
+}
+
+// errorPause is how long a worker waits after a failed operation.
+const errorPause = 50 * time.Millisecond
+
+// load runs workers against t for d and measures every operation,
+// including the wait for a connection.
+func load(t *target, workers int, d time.Duration, exporter *poolstats.Exporter) result {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	exported := make(chan struct{})
+	go func() {
+		defer close(exported)
+		_ = exporter.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-exported // No samples from this run after load returns
+	}()
+
+	before := t.stats()
+	start := time.Now()
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		errors    int
+		wg        sync.WaitGroup
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var own []time.Duration
+			failed := 0
+			for ctx.Err() == nil {
+				opStart := time.Now()
+				if err := t.op(ctx); err != nil {
+					if ctx.Err() == nil {
+						failed++
+					}
+					// Do not hammer a server that is down
+					select {
+					case <-ctx.Done():
+					case <-time.After(errorPause):
+					}
+					continue
+				}
+				own = append(own, time.Since(opStart))
+			}
+			mu.Lock()
+			latencies = append(latencies, own...)
+			errors += failed
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	slices.Sort(latencies)
+	return result{
+		ops:       len(latencies),
+		errors:    errors,
+		elapsed:   time.Since(start),
+		latencies: latencies,
+		pool:      t.stats().Sub(before),
+	}
+}
+
+func parseSizes(s string) ([]int, error) {
+	var sizes []int
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid pool size %q", f)
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes, nil
+}
+
+func writeTable(results []result, workers int) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "pool\tworkers\tops/s\tp50\tp95\tp99\tmax\twaits\tavg wait\ttimeouts\terrors\t")
+	for _, r := range results {
+		var avgWait time.Duration
+		if r.pool.WaitCount > 0 {
+			avgWait = r.pool.WaitDuration / time.Duration(r.pool.WaitCount)
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%.0f\t%v\t%v\t%v\t%v\t%d\t%v\t%d\t%d\t\n",
+			r.size, workers, float64(r.ops)/r.elapsed.Seconds(),
+			r.percentile(0.5).Round(time.Microsecond), r.percentile(0.95).Round(time.Microsecond),
+			r.percentile(0.99).Round(time.Microsecond), r.percentile(1).Round(time.Microsecond),
+			r.pool.WaitCount, avgWait.Round(time.Microsecond), r.pool.Timeouts, r.errors)
+	}
+	_ = tw.Flush()
+}
+
+func main() {
+	kind := flag.String("target", "postgres", "pool to load: postgres or redis")
+	sizesFlag := flag.String("sizes", "1,2,4,8,16,32", "comma-separated pool sizes to try")
+	workers := flag.Int("workers", 32, "concurrent workers")
+	duration := flag.Duration("duration", 5*time.Second, "load duration per pool size")
+	query := flag.String("query", "SELECT pg_sleep(0.005)", "statement each Postgres worker runs")
+	interval := flag.Duration("interval", time.Second, "pool metrics interval")
+	verbose := flag.Bool("v", false, "print pool metrics every interval")
+	flag.Parse()
+
+	sizes, err := parseSizes(*sizesFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("🔥 Loading %s with %d workers for %v per pool size\n", *kind, *workers, *duration)
+	var results []result
+	for _, size := range sizes {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		var t *target
+		switch *kind {
+		case "postgres":
+			t, err = openPostgres(ctx, size, *query)
+		case "redis":
+			t, err = openRedis(ctx, size)
+		default:
+			err = fmt.Errorf("unknown target %q", *kind)
+		}
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		exporter := poolstats.New(poolstats.Options{
+			Interval: *interval,
+			OnSample: func(s poolstats.Sample) {
+				if *verbose {
+					fmt.Printf("   📊 pool %d: open %d, in use %d, idle %d, waits %d (%v) this interval\n",
+						size, s.Stats.Open, s.Stats.InUse, s.Stats.Idle, s.Interval.WaitCount, s.Interval.WaitDuration.Round(time.Millisecond))
+				}
+			},
+		})
+		exporter.Register(*kind, t.stats)
+
+		r := load(t, *workers, *duration, exporter)
+		r.size = size
+		results = append(results, r)
+		fmt.Printf("✅ pool %d: %d ops\n", size, r.ops)
+
+		if err := t.close(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ close: %v\n", err)
+		}
+	}
+
+	fmt.Println()
+	writeTable(results, *workers)
+	fmt.Println("\nA pool smaller than the number of workers shows up as waits: latency")
+	fmt.Println("grows with the queue while the server sits idle. Past the point where")
+	fmt.Println("the server is the bottleneck, more connections only add load to it.")
+}
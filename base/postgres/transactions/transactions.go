@@ -1,35 +1,39 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
+
+	"go-projects/lifecycle"
 )
 
 func main() {
 
-	stopChan := make(chan os.Signal, 1)
-	signal.Notify(stopChan, os.Interrupt, syscall.SIGTERM)
+	app := lifecycle.New(lifecycle.Options{})
 
-	doneChan := make(chan struct{})
+	app.Go("main loop", func(ctx context.Context) error {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
 
-	go func() {
-		<-stopChan
-		fmt.Println("\nReceived shutdown signal...")
-		close(doneChan)
-		time.Sleep(300 * time.Millisecond)
-	}()
-
-	for {
-		select {
-		case <-doneChan:
-			fmt.Println("Shutting down gracefully...")
-			os.Exit(0)
-		default:
+		for {
 			fmt.Println("Program Running.....")
-			time.Sleep(1 * time.Second)
+			select {
+			case <-ctx.Done():
+				fmt.Println("\nReceived shutdown signal...")
+				return nil
+			case <-ticker.C:
+			}
 		}
+	})
+
+	app.OnShutdown("cleanup", func(ctx context.Context) error {
+		fmt.Println("Shutting down gracefully...")
+		time.Sleep(300 * time.Millisecond)
+		return nil
+	})
+
+	if err := app.Wait(); err != nil {
+		fmt.Printf("Shutdown error: %v\n", err)
 	}
 }
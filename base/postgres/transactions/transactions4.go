@@ -1,17 +1,30 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/cooler-SAI/go-Tools/zerolog"
-	_ "github.com/lib/pq"
+
+	"go-projects/lazy"
+	"postgres/outbox"
+	"postgres/pgconn"
 )
 
+// TransferEvent is published through the outbox after a transfer commits.
+type TransferEvent struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+}
+
 func initDatabase(db *sql.DB) {
-	// Drops and recreates the accounts table
+	// Drops and recreates the accounts and outbox tables
 	_, err := db.Exec(`
         DROP TABLE IF EXISTS accounts;
+        DROP TABLE IF EXISTS outbox;
         CREATE TABLE accounts (
             id SERIAL PRIMARY KEY,
             name VARCHAR(100) UNIQUE NOT NULL,
@@ -21,6 +34,10 @@ func initDatabase(db *sql.DB) {
 	if err != nil {
 		zerolog.Log.Fatal().Err(err).Msg("Failed to initialize database")
 	}
+
+	if err := outbox.CreateTable(context.Background(), db); err != nil {
+		zerolog.Log.Fatal().Err(err).Msg("Failed to initialize outbox")
+	}
 }
 
 func createAccount(db *sql.DB, name string, balance float64) {
@@ -96,6 +113,12 @@ func transferMoney(db *sql.DB, from, to string, amount float64) error {
 		return fmt.Errorf("failed to add funds: %v", err)
 	}
 
+	// Records the event in the same transaction, so it is published only if the transfer commits
+	err = outbox.Enqueue(context.Background(), tx, "transfers", from, TransferEvent{From: from, To: to, Amount: amount})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue transfer event: %v", err)
+	}
+
 	// Commits the transaction
 	err = tx.Commit()
 	if err != nil {
@@ -113,20 +136,32 @@ func main() {
 
 	fmt.Println("Starting transaction demonstration in Go with PostgreSQL...")
 
-	// Database connection string
-	connStr := "user=postgres password=example host=localhost port=5432 dbname=postgres sslmode=disable"
+	// PG_DSN and PG_MAX_OPEN_CONNS etc. override the pool settings
+	cfg, err := pgconn.ConfigFromEnv()
+	if err != nil {
+		zerolog.Log.Fatal().Err(err).Msg("Invalid database settings")
+	}
 
-	// Opens a database connection
-	db, err := sql.Open("postgres", connStr)
+	// Connects on first use and keeps retrying while the container starts
+	database := pgconn.Lazy(cfg, lazy.Options{
+		MaxAttempts: -1,
+		OnError: func(attempt int, err error) {
+			zerolog.Log.Warn().Err(err).Int("attempt", attempt).Msg("Database not ready, retrying")
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	db, err := database.Get(ctx)
+	cancel()
 	if err != nil {
-		zerolog.Log.Fatal().Err(err).Msg("Failed to open database connection")
+		zerolog.Log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
-	defer func(db *sql.DB) {
-		err := db.Close()
-		if err != nil {
-			zerolog.Log.Error().Err(err).Msg("Error closing DB connection")
+	defer func() {
+		if db, ok := database.Reset(); ok {
+			if err := db.Close(); err != nil {
+				zerolog.Log.Error().Err(err).Msg("Error closing DB connection")
+			}
 		}
-	}(db)
+	}()
 
 	// Initializes the database
 	initDatabase(db)
@@ -151,6 +186,18 @@ func main() {
 	// Prints final balances
 	printBalances(db)
 
+	// Publishes pending outbox events
+	sink := &outbox.MemorySink{}
+	relay := outbox.NewRelay(db, sink, outbox.RelayOptions{})
+	delivered, err := relay.RunOnce(context.Background())
+	if err != nil {
+		zerolog.Log.Fatal().Err(err).Msg("Failed to relay outbox events")
+	}
+	zerolog.Log.Info().Int("delivered", delivered).Msg("Outbox events relayed.")
+	for _, event := range sink.Events() {
+		fmt.Printf("  Event #%d [%s] key=%s payload=%s\n", event.ID, event.Topic, event.Key, event.Payload)
+	}
+
 	zerolog.Log.Info().Msg("Done.")
 	zerolog.Log.Info().Msg("Don't forget to stop Docker-container with: docker stop my-postgres")
 }
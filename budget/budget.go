@@ -0,0 +1,160 @@
+// Package budget divides the deadline of a request across the steps that
+// serve it, for example a database query, then a cache write, then a call to
+// an external API.
+//
+// Run gives every stage a share of the time that is left when the stage
+// starts, proportional to its weight, so time saved by a fast stage goes to
+// the following ones. A stage can reserve a minimum; if the remaining budget
+// cannot cover the minimums of the stages still to run, Run fails fast
+// instead of starting work that cannot finish. The returned Report tells
+// which stage used up the time.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInsufficient is returned when the remaining time cannot cover the
+// minimum reserves of the stages still to run.
+var ErrInsufficient = errors.New("budget: not enough time left")
+
+// Stage is one step of a chained operation.
+type Stage struct {
+	Name string
+	// Weight is the share of the remaining budget relative to the stages
+	// still to run. Default: 1.
+	Weight float64
+	// Min is the least time the stage needs. It is reserved while earlier
+	// stages run, and Run fails before the stage if it cannot be given.
+	Min time.Duration
+	// Run does the work; ctx expires when the stage's allotment is used up.
+	Run func(ctx context.Context) error
+}
+
+func (s Stage) weight() float64 {
+	if s.Weight <= 0 {
+		return 1
+	}
+	return s.Weight
+}
+
+// StageReport is what happened to one stage.
+type StageReport struct {
+	Name string
+	// Allotted is the time the stage was given; zero without a deadline.
+	Allotted time.Duration
+	Used     time.Duration
+	Err      error
+	// Skipped is set for the stage Run refused to start and all after it.
+	Skipped bool
+}
+
+// Overran reports whether the stage hit its own deadline.
+func (s StageReport) Overran() bool {
+	return s.Allotted > 0 && s.Used >= s.Allotted
+}
+
+// Report describes a Run.
+type Report struct {
+	Budget time.Duration // Time left when Run started; zero without a deadline
+	Used   time.Duration
+	Stages []StageReport
+}
+
+// Culprit returns the stage that used up the budget: the first stage that
+// overran its allotment or, if none did, the one that took the longest. ok is
+// false when no stage ran.
+func (r Report) Culprit() (culprit StageReport, ok bool) {
+	for _, s := range r.Stages {
+		if s.Overran() {
+			return s, true
+		}
+	}
+	for _, s := range r.Stages {
+		if !s.Skipped && (!ok || s.Used > culprit.Used) {
+			culprit, ok = s, true
+		}
+	}
+	return culprit, ok
+}
+
+// String formats the report as one line per stage.
+func (r Report) String() string {
+	out := fmt.Sprintf("budget %v, used %v", r.Budget.Round(time.Millisecond), r.Used.Round(time.Millisecond))
+	for _, s := range r.Stages {
+		switch {
+		case s.Skipped:
+			out += fmt.Sprintf("\n  %-12s skipped", s.Name)
+		case s.Err != nil:
+			out += fmt.Sprintf("\n  %-12s %v of %v: %v", s.Name, s.Used.Round(time.Millisecond), s.Allotted.Round(time.Millisecond), s.Err)
+		default:
+			out += fmt.Sprintf("\n  %-12s %v of %v", s.Name, s.Used.Round(time.Millisecond), s.Allotted.Round(time.Millisecond))
+		}
+	}
+	return out
+}
+
+// Run runs the stages in order, each with its share of the deadline of ctx,
+// and stops at the first error. Without a deadline on ctx the stages run with
+// ctx unchanged and Min is not checked.
+func Run(ctx context.Context, stages ...Stage) (Report, error) {
+	start := time.Now()
+	deadline, hasDeadline := ctx.Deadline()
+
+	report := Report{Stages: make([]StageReport, len(stages))}
+	if hasDeadline {
+		report.Budget = time.Until(deadline)
+	}
+	for i, s := range stages {
+		report.Stages[i] = StageReport{Name: s.Name, Skipped: true}
+	}
+
+	finish := func(err error) (Report, error) {
+		report.Used = time.Since(start)
+		return report, err
+	}
+
+	for i, s := range stages {
+		stageCtx, cancel := ctx, context.CancelFunc(func() {})
+		var allotted time.Duration
+		if hasDeadline {
+			var err error
+			allotted, err = allot(time.Until(deadline), stages[i:])
+			if err != nil {
+				return finish(fmt.Errorf("before stage %q: %w", s.Name, err))
+			}
+			stageCtx, cancel = context.WithTimeout(ctx, allotted)
+		}
+
+		began := time.Now()
+		err := s.Run(stageCtx)
+		cancel()
+
+		report.Stages[i] = StageReport{Name: s.Name, Allotted: allotted, Used: time.Since(began), Err: err}
+		if err != nil {
+			return finish(fmt.Errorf("stage %q: %w", s.Name, err))
+		}
+	}
+	return finish(nil)
+}
+
+// allot returns the time for stages[0] out of remaining, keeping the minimum
+// reserves of the stages after it.
+func allot(remaining time.Duration, stages []Stage) (time.Duration, error) {
+	var reserve time.Duration
+	var weights float64
+	for _, s := range stages {
+		reserve += s.Min
+		weights += s.weight()
+	}
+	if remaining <= 0 || remaining < reserve {
+		return 0, fmt.Errorf("%w: %v left, stages need %v", ErrInsufficient, remaining.Round(time.Millisecond), reserve)
+	}
+
+	// Every stage keeps its reserve; the time above all reserves is shared by weight.
+	share := time.Duration(float64(remaining-reserve) * stages[0].weight() / weights)
+	return stages[0].Min + share, nil
+}
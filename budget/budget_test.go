@@ -0,0 +1,167 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAllot(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining time.Duration
+		stages    []Stage
+		want      time.Duration
+		wantErr   bool
+	}{
+		{"equal weights", 300 * time.Millisecond, []Stage{{}, {}, {}}, 100 * time.Millisecond, false},
+		{"by weight", 400 * time.Millisecond, []Stage{{Weight: 3}, {Weight: 1}}, 300 * time.Millisecond, false},
+		{"min plus share", 300 * time.Millisecond, []Stage{{Min: 100 * time.Millisecond}, {}}, 200 * time.Millisecond, false},
+		{"later reserve kept", 300 * time.Millisecond, []Stage{{}, {Min: 200 * time.Millisecond}}, 50 * time.Millisecond, false},
+		{"reserve exactly covered", 200 * time.Millisecond, []Stage{{Min: 50 * time.Millisecond}, {Min: 150 * time.Millisecond}}, 50 * time.Millisecond, false},
+		{"reserve not covered", 100 * time.Millisecond, []Stage{{Min: 50 * time.Millisecond}, {Min: 100 * time.Millisecond}}, 0, true},
+		{"nothing left", 0, []Stage{{}}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := allot(tt.remaining, tt.stages)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInsufficient) {
+					t.Fatalf("allot: err = %v, want ErrInsufficient", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("allot: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("allot = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// sleep returns a stage function that works for d or until ctx is done.
+func sleep(d time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case <-time.After(d):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		budget      time.Duration
+		stages      []Stage
+		wantErr     error
+		wantCulprit string
+		wantSkipped int
+	}{
+		{
+			name:   "all stages fit",
+			budget: time.Second,
+			stages: []Stage{
+				{Name: "db", Run: sleep(10 * time.Millisecond)},
+				{Name: "cache", Run: sleep(30 * time.Millisecond)},
+			},
+			wantCulprit: "cache",
+		},
+		{
+			name:   "stage overruns its share",
+			budget: 200 * time.Millisecond,
+			stages: []Stage{
+				{Name: "db", Run: sleep(time.Second)},
+				{Name: "cache", Run: sleep(time.Millisecond)},
+			},
+			wantErr:     context.DeadlineExceeded,
+			wantCulprit: "db",
+			wantSkipped: 1,
+		},
+		{
+			// db ignores its context, so it eats into the reserve of external
+			name:   "fails fast when reserve is gone",
+			budget: 200 * time.Millisecond,
+			stages: []Stage{
+				{Name: "db", Run: func(ctx context.Context) error { time.Sleep(150 * time.Millisecond); return nil }},
+				{Name: "external", Min: 100 * time.Millisecond, Run: sleep(time.Millisecond)},
+			},
+			wantErr:     ErrInsufficient,
+			wantCulprit: "db",
+			wantSkipped: 1,
+		},
+		{
+			name:   "too small from the start",
+			budget: 10 * time.Millisecond,
+			stages: []Stage{
+				{Name: "db", Min: 50 * time.Millisecond, Run: sleep(time.Millisecond)},
+			},
+			wantErr:     ErrInsufficient,
+			wantSkipped: 1,
+		},
+		{
+			name:   "stage error stops the chain",
+			budget: time.Second,
+			stages: []Stage{
+				{Name: "db", Run: func(ctx context.Context) error { return errTest }},
+				{Name: "cache", Run: sleep(time.Millisecond)},
+			},
+			wantErr:     errTest,
+			wantCulprit: "db",
+			wantSkipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.budget)
+			defer cancel()
+
+			report, err := Run(ctx, tt.stages...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run: err = %v, want %v", err, tt.wantErr)
+			}
+
+			culprit, ok := report.Culprit()
+			if tt.wantCulprit == "" {
+				if ok {
+					t.Errorf("Culprit = %q, want none", culprit.Name)
+				}
+			} else if culprit.Name != tt.wantCulprit {
+				t.Errorf("Culprit = %q, want %q\n%s", culprit.Name, tt.wantCulprit, report)
+			}
+
+			skipped := 0
+			for _, s := range report.Stages {
+				if s.Skipped {
+					skipped++
+				}
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("skipped %d stages, want %d\n%s", skipped, tt.wantSkipped, report)
+			}
+		})
+	}
+}
+
+func TestRunWithoutDeadline(t *testing.T) {
+	var hasDeadline bool
+	_, err := Run(context.Background(), Stage{Name: "db", Min: time.Hour, Run: func(ctx context.Context) error {
+		_, hasDeadline = ctx.Deadline()
+		return nil
+	}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if hasDeadline {
+		t.Error("stage got a deadline although the parent has none")
+	}
+}
+
+var errTest = errors.New("test error")
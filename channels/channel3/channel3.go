@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+
+	"go-projects/lifecycle"
+	"go-projects/pipeline"
+)
+
+// Task is the Task of channel2, now flowing through a pipeline.
+type Task struct {
+	ID        int
+	Name      string
+	Completed bool
+}
+
+// taskSource replaces the hand-written producer goroutine of channel2.
+func taskSource(ctx context.Context, emit func(Task) error) error {
+	names := []string{"Download File", "Research data", "Resize images", "Send report",
+		"Backup database", "Clean cache", "Build index", "Notify users"}
+	for i, name := range names {
+		if err := emit(Task{ID: i + 1, Name: name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// work simulates a time-consuming task.
+func work(ctx context.Context, task Task) (Task, error) {
+	select {
+	case <-time.After(300 * time.Millisecond):
+		task.Completed = true
+		return task, nil
+	case <-ctx.Done():
+		return task, ctx.Err()
+	}
+}
+
+// run builds the pipeline and waits until every task went through it.
+func run(ctx context.Context) error {
+	p := pipeline.New(ctx, pipeline.Options{Buffer: 2})
+
+	// source -> filter -> fan out to 3 workers -> merge -> batch -> sink
+	tasks := pipeline.Source(p, taskSource)
+	tasks = pipeline.Filter(p, tasks, func(t Task) bool { return t.Name != "Clean cache" })
+
+	workers := pipeline.FanOut(p, tasks, 3)
+	done := make([]<-chan Task, len(workers))
+	for i, in := range workers {
+		done[i] = pipeline.Map(p, in, work)
+	}
+	batches := pipeline.Batch(p, pipeline.Merge(p, done...), 3, 500*time.Millisecond)
+
+	return pipeline.Sink(p, batches, func(ctx context.Context, batch []Task) error {
+		ids := make([]int, len(batch))
+		for i, t := range batch {
+			ids[i] = t.ID
+		}
+		zerolog.Log.Info().Ints("tasks", ids).Msg("Batch of completed tasks saved")
+		return nil
+	})
+}
+
+func main() {
+	zerolog.Init()
+	zerolog.Log.Info().Msg("Starting pipeline demonstration...")
+
+	// Ctrl+C cancels the pipeline through the root context
+	app := lifecycle.New(lifecycle.Options{})
+
+	app.Go("pipeline", func(ctx context.Context) error {
+		start := time.Now()
+		if err := run(ctx); err != nil {
+			return err
+		}
+		zerolog.Log.Info().Msgf("All tasks finished in %v with 3 workers", time.Since(start).Round(time.Millisecond))
+		app.Shutdown() // The demo ends with the pipeline
+		return nil
+	})
+
+	if err := app.Wait(); err != nil {
+		zerolog.Log.Error().Err(err).Msg("Pipeline stopped")
+	}
+}
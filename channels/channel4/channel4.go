@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-projects/pqueue"
+)
+
+// Task is the Task of channel2, queued by priority instead of FIFO.
+type Task struct {
+	ID        int
+	Name      string
+	Completed bool
+}
+
+const (
+	low    = 0
+	normal = 5
+	urgent = 10
+)
+
+func worker(ctx context.Context, id int, queue *pqueue.Queue[Task], wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		task, err := queue.Pop(ctx)
+		if errors.Is(err, pqueue.ErrClosed) {
+			fmt.Printf("👷 Worker %d: queue closed, stopping\n", id)
+			return
+		}
+		if err != nil {
+			fmt.Printf("👷 Worker %d: %v\n", id, err)
+			return
+		}
+
+		fmt.Printf("👷 Worker %d: got task: %s (ID: %d)\n", id, task.Name, task.ID)
+		time.Sleep(200 * time.Millisecond) // work similar to time-consuming task
+		task.Completed = true
+	}
+}
+
+func main() {
+	// Every 200ms of waiting counts as one priority level, so low-priority
+	// tasks still run while urgent ones keep arriving
+	queue := pqueue.New[Task](pqueue.Options{Aging: 200 * time.Millisecond})
+
+	_ = queue.Push(Task{ID: 1, Name: "Download File"}, normal)
+	_ = queue.Push(Task{ID: 2, Name: "Research data"}, low)
+	_ = queue.Push(Task{ID: 3, Name: "Fix production outage"}, urgent) // Jumps the queue
+	_ = queue.PushAfter(Task{ID: 4, Name: "Send daily report"}, urgent, 1*time.Second)
+	for i := 5; i <= 10; i++ {
+		_ = queue.PushAfter(Task{ID: i, Name: fmt.Sprintf("Urgent ticket #%d", i)}, urgent, time.Duration(i-4)*200*time.Millisecond)
+	}
+	fmt.Printf("📥 %d tasks queued\n", queue.Len())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go worker(ctx, 1, queue, &wg)
+
+	// Let the delayed tasks become ready and the queue drain, then close it
+	time.Sleep(3 * time.Second)
+	queue.Close()
+
+	wg.Wait()
+	fmt.Println("✅ All tasks processed")
+}
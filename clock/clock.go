@@ -0,0 +1,140 @@
+// Package clock abstracts time so code that waits can be tested without
+// waiting.
+//
+// Production code takes a Clock and gets Real{}; tests pass a Fake and move
+// time forward with Advance, so a 30 second timeout fires instantly and in
+// the same order on every run.
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is the subset of the time package that code under test uses.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Until(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+	// AfterFunc calls f once d has passed. The returned Timer has no channel.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a *time.Timer with its channel behind a method.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is a *time.Ticker with its channel behind a method.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// Real is the Clock of the time package.
+type Real struct{}
+
+var _ Clock = Real{}
+
+func (Real) Now() time.Time                         { return time.Now() }
+func (Real) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (Real) Until(t time.Time) time.Duration        { return time.Until(t) }
+func (Real) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (Real) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (Real) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (Real) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (Real) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time   { return t.t.C }
+func (t realTicker) Stop()                 { t.t.Stop() }
+func (t realTicker) Reset(d time.Duration) { t.t.Reset(d) }
+
+// WithTimeout is context.WithTimeout measured on c.
+func WithTimeout(parent context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	return WithDeadline(parent, c, c.Now().Add(d))
+}
+
+// WithDeadline is context.WithDeadline measured on c. With Real it is
+// context.WithDeadline; with a Fake the context expires when the fake time
+// reaches deadline, and its Err, and that of every context derived from it,
+// is context.DeadlineExceeded as usual.
+func WithDeadline(parent context.Context, c Clock, deadline time.Time) (context.Context, context.CancelFunc) {
+	if _, ok := c.(Real); ok {
+		return context.WithDeadline(parent, deadline)
+	}
+	if d, ok := parent.Deadline(); ok && !d.After(deadline) {
+		return context.WithCancel(parent) // The parent expires first anyway
+	}
+
+	ctx := &deadlineCtx{parent: parent, deadline: deadline, done: make(chan struct{})}
+	stop := context.AfterFunc(parent, func() { ctx.cancel(parent.Err()) })
+	if c.Until(deadline) <= 0 {
+		ctx.cancel(context.DeadlineExceeded)
+		stop()
+		return ctx, func() { ctx.cancel(context.Canceled) }
+	}
+	t := c.AfterFunc(c.Until(deadline), func() { ctx.cancel(context.DeadlineExceeded) })
+	return ctx, func() {
+		t.Stop()
+		stop()
+		ctx.cancel(context.Canceled)
+	}
+}
+
+// deadlineCtx is a context that expires on a fake deadline. It closes its own
+// done channel instead of wrapping a cancelCtx, so contexts derived from it
+// see its Err, not that of a cancelCtx underneath.
+type deadlineCtx struct {
+	parent   context.Context
+	deadline time.Time
+	done     chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func (c *deadlineCtx) Deadline() (time.Time, bool) { return c.deadline, true }
+func (c *deadlineCtx) Done() <-chan struct{}       { return c.done }
+func (c *deadlineCtx) Value(key any) any           { return c.parent.Value(key) }
+
+func (c *deadlineCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// cancel sets err and closes done, unless that already happened.
+func (c *deadlineCtx) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
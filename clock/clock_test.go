@@ -0,0 +1,263 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-projects/leakcheck"
+)
+
+func TestFakeTimers(t *testing.T) {
+	tests := []struct {
+		name    string
+		delays  []time.Duration
+		advance []time.Duration
+		want    []int // Indexes of timers fired, in order
+	}{
+		{"none due", []time.Duration{time.Second}, []time.Duration{999 * time.Millisecond}, nil},
+		{"exactly due", []time.Duration{time.Second}, []time.Duration{time.Second}, []int{0}},
+		{"deadline order", []time.Duration{3 * time.Second, time.Second, 2 * time.Second}, []time.Duration{time.Minute}, []int{1, 2, 0}},
+		{"same deadline keeps set order", []time.Duration{time.Second, time.Second}, []time.Duration{time.Second}, []int{0, 1}},
+		{"several advances", []time.Duration{time.Second, 3 * time.Second}, []time.Duration{2 * time.Second, 2 * time.Second}, []int{0, 1}},
+		{"zero fires at once", []time.Duration{0}, nil, []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewFake(time.Time{})
+			start := c.Now()
+
+			var fired []int
+			var firedAt []time.Duration
+			for i, d := range tt.delays {
+				c.AfterFunc(d, func() {
+					fired = append(fired, i)
+					firedAt = append(firedAt, c.Since(start))
+				})
+			}
+			for _, d := range tt.advance {
+				c.Advance(d)
+			}
+
+			if len(fired) != len(tt.want) {
+				t.Fatalf("fired %v, want %v", fired, tt.want)
+			}
+			for i := range fired {
+				if fired[i] != tt.want[i] {
+					t.Errorf("fired %v, want %v", fired, tt.want)
+				}
+				// Callbacks see the clock at their own deadline
+				if want := tt.delays[fired[i]]; firedAt[i] != want {
+					t.Errorf("timer %d fired at %v, want %v", fired[i], firedAt[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestFakeTimerStopReset(t *testing.T) {
+	c := NewFake(time.Time{})
+	timer := c.NewTimer(time.Second)
+
+	if !timer.Stop() {
+		t.Error("Stop of an active timer = false")
+	}
+	if timer.Stop() {
+		t.Error("second Stop = true")
+	}
+	c.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Reset of a stopped timer = true")
+	}
+	c.Advance(time.Second)
+	select {
+	case got := <-timer.C():
+		if want := c.Now(); !got.Equal(want) {
+			t.Errorf("fired with %v, want %v", got, want)
+		}
+	default:
+		t.Fatal("reset timer did not fire")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	c := NewFake(time.Time{})
+	ticker := c.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	c.Advance(time.Second)
+	<-ticker.C()
+
+	// Ticks nobody receives are dropped, as with time.Ticker
+	c.Advance(5 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker buffered more than one tick")
+	default:
+	}
+
+	ticker.Reset(time.Minute)
+	c.Advance(59 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker fired before the new period")
+	default:
+	}
+	c.Advance(time.Second)
+	<-ticker.C()
+}
+
+func TestFakeSleep(t *testing.T) {
+	leakcheck.Check(t)
+	c := NewFake(time.Time{})
+
+	done := make(chan time.Time)
+	go func() {
+		c.Sleep(30 * time.Second)
+		done <- c.Now()
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(29 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("Sleep returned early")
+	default:
+	}
+	c.Advance(time.Second)
+	if got := (<-done).Sub(NewFake(time.Time{}).Now()); got != 30*time.Second {
+		t.Errorf("woke up after %v, want 30s", got)
+	}
+	if n := c.Waiters(); n != 0 {
+		t.Errorf("Waiters = %d after the sleep, want 0", n)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		advance time.Duration
+		cancel  bool
+		wantErr error
+	}{
+		{"not yet", time.Minute, 59 * time.Second, false, nil},
+		{"expired", time.Minute, time.Minute, false, context.DeadlineExceeded},
+		{"already expired", 0, 0, false, context.DeadlineExceeded},
+		{"cancelled", time.Minute, 0, true, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewFake(time.Time{})
+			ctx, cancel := WithTimeout(context.Background(), c, tt.timeout)
+			defer cancel()
+
+			if d, ok := ctx.Deadline(); !ok || !d.Equal(c.Now().Add(tt.timeout)) {
+				t.Errorf("Deadline = %v, %v", d, ok)
+			}
+			c.Advance(tt.advance)
+			if tt.cancel {
+				cancel()
+			}
+			if err := ctx.Err(); !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Errorf("Err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReal(t *testing.T) {
+	var c Clock = Real{}
+	start := c.Now()
+	<-c.After(time.Millisecond)
+	if c.Since(start) < time.Millisecond {
+		t.Error("After returned early")
+	}
+
+	ctx, cancel := WithTimeout(context.Background(), c, time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("Err = %v", ctx.Err())
+	}
+}
+
+func TestFakeStopResetDrain(t *testing.T) {
+	c := NewFake(time.Time{})
+	timer := c.NewTimer(time.Second)
+	c.Advance(time.Second)
+
+	if !timer.Stop() {
+		t.Error("Stop of a fired but unreceived timer = false")
+	}
+	select {
+	case <-timer.C():
+		t.Error("tick received after Stop")
+	default:
+	}
+
+	timer.Reset(time.Second)
+	c.Advance(time.Second)
+	timer.Reset(time.Minute)
+	c.Advance(time.Second)
+	select {
+	case <-timer.C():
+		t.Error("stale tick received after Reset")
+	default:
+	}
+
+	ticker := c.NewTicker(time.Second)
+	defer ticker.Stop()
+	c.Advance(time.Second)
+	ticker.Reset(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Error("stale tick received after Ticker.Reset")
+	default:
+	}
+}
+
+func TestWithTimeoutDerived(t *testing.T) {
+	c := NewFake(time.Time{})
+	ctx, cancel := WithTimeout(context.Background(), c, time.Minute)
+	defer cancel()
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+	grandchild, cancelGrandchild := context.WithTimeout(child, time.Hour)
+	defer cancelGrandchild()
+
+	c.Advance(time.Minute)
+	<-grandchild.Done()
+	for name, ctx := range map[string]context.Context{"ctx": ctx, "child": child, "grandchild": grandchild} {
+		if err := ctx.Err(); err != context.DeadlineExceeded {
+			t.Errorf("%s.Err = %v, want DeadlineExceeded", name, err)
+		}
+	}
+}
+
+func TestWithTimeoutParentCancelled(t *testing.T) {
+	c := NewFake(time.Time{})
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel := WithTimeout(parent, c, time.Minute)
+	defer cancel()
+
+	cancelParent()
+	<-ctx.Done()
+	if err := ctx.Err(); err != context.Canceled {
+		t.Errorf("Err = %v, want Canceled", err)
+	}
+	c.Advance(time.Minute)
+	if err := ctx.Err(); err != context.Canceled {
+		t.Errorf("Err after the deadline = %v, want Canceled", err)
+	}
+}
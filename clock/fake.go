@@ -0,0 +1,251 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when told to. Timers, tickers and sleeps
+// fire during Advance or Set, in deadline order, so tests are deterministic.
+//
+// A test usually starts the code under test in a goroutine, calls
+// BlockUntil to wait until that code is waiting on the clock, then Advance.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer // Active timers, unordered
+	seq     uint64       // Orders timers due at the same time
+	changed chan struct{}
+}
+
+var _ Clock = (*Fake)(nil)
+
+// NewFake creates a fake clock set to start. A zero start is replaced by a
+// fixed date, so tests do not depend on the current time.
+func NewFake(start time.Time) *Fake {
+	if start.IsZero() {
+		start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &Fake{now: start, changed: make(chan struct{})}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration { return f.Now().Sub(t) }
+func (f *Fake) Until(t time.Time) time.Duration { return t.Sub(f.Now()) }
+
+// Sleep blocks until the clock has been advanced by d.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker panics if d <= 0, like time.NewTicker.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{clock: f, ch: make(chan time.Time, 1), period: d}
+	t.Reset(d)
+	return fakeTicker{t}
+}
+
+// AfterFunc runs f in the goroutine calling Advance or Set, before they
+// return.
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	t := &fakeTimer{clock: f, fn: fn}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer due on the way.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t, firing every timer due on the way. The clock
+// never moves backwards; an earlier t only fires timers already due.
+func (f *Fake) Set(t time.Time) {
+	for {
+		f.mu.Lock()
+		next := f.nextLocked(t)
+		if next == nil {
+			if t.After(f.now) {
+				f.now = t
+			}
+			f.mu.Unlock()
+			return
+		}
+		if next.when.After(f.now) {
+			f.now = next.when
+		}
+		now := f.now
+		if next.period > 0 {
+			next.when = next.when.Add(next.period)
+		} else {
+			f.removeLocked(next)
+		}
+		if next.fn == nil {
+			// Send under the lock, so a Stop or Reset that returns after this
+			// point drains the tick
+			next.send(now)
+			f.mu.Unlock()
+			continue
+		}
+		f.mu.Unlock()
+
+		next.fn()
+	}
+}
+
+// Waiters returns the number of active timers, tickers and sleeps.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// BlockUntil waits until at least n timers, tickers or sleeps are active,
+// so the code under test has reached its wait before the test advances.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		if len(f.timers) >= n {
+			f.mu.Unlock()
+			return
+		}
+		changed := f.changed
+		f.mu.Unlock()
+		<-changed
+	}
+}
+
+// nextLocked returns the active timer due first at or before t, if any.
+// Timers due at the same time fire in the order they were set.
+func (f *Fake) nextLocked(t time.Time) *fakeTimer {
+	var next *fakeTimer
+	for _, timer := range f.timers {
+		if timer.when.After(t) {
+			continue
+		}
+		if next == nil || timer.when.Before(next.when) || (timer.when.Equal(next.when) && timer.seq < next.seq) {
+			next = timer
+		}
+	}
+	return next
+}
+
+func (f *Fake) removeLocked(t *fakeTimer) bool {
+	for i, timer := range f.timers {
+		if timer == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) notifyLocked() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// fakeTimer is a timer, or with a period the state of a fakeTicker.
+type fakeTimer struct {
+	clock  *Fake
+	ch     chan time.Time
+	fn     func()
+	period time.Duration // Non-zero for tickers
+	when   time.Time
+	seq    uint64
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+// Stop deactivates the timer and, like a time.Timer since Go 1.23, drops a
+// tick that has not been received yet. Such a tick counts as not fired.
+func (t *fakeTimer) Stop() bool {
+	f := t.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	active := f.removeLocked(t)
+	return t.drainLocked() || active
+}
+
+// Reset drops a pending tick, like Stop, and rearms the timer.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	f := t.clock
+	f.mu.Lock()
+	active := f.removeLocked(t)
+	active = t.drainLocked() || active
+	if t.period > 0 {
+		t.period = d
+	}
+	f.seq++
+	t.when, t.seq = f.now.Add(d), f.seq
+
+	// A timer for a non-positive duration fires right away, as with the
+	// time package, without waiting for the next Advance
+	due := d <= 0 && t.period == 0
+	if !due {
+		f.timers = append(f.timers, t)
+	} else if t.fn == nil {
+		t.send(f.now)
+	}
+	f.notifyLocked()
+	f.mu.Unlock()
+
+	if due && t.fn != nil {
+		t.fn()
+	}
+	return active
+}
+
+// drainLocked drops an unreceived tick. It reports whether it dropped one of
+// a timer, which then counts as not fired yet.
+func (t *fakeTimer) drainLocked() bool {
+	if t.ch == nil {
+		return false
+	}
+	select {
+	case <-t.ch:
+		return t.period == 0
+	default:
+		return false
+	}
+}
+
+// send delivers a tick. Like time.Ticker, a tick is dropped if the previous
+// one has not been received.
+func (t *fakeTimer) send(now time.Time) {
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
+
+type fakeTicker struct{ t *fakeTimer }
+
+func (t fakeTicker) C() <-chan time.Time { return t.t.ch }
+func (t fakeTicker) Stop()               { t.t.Stop() }
+
+// Reset panics if d <= 0, like time.Ticker.Reset.
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.t.Reset(d)
+}
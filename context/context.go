@@ -7,11 +7,13 @@ import (
 
 	"github.com/cooler-SAI/go-Tools/random"
 	"github.com/cooler-SAI/go-Tools/zerolog"
-)
 
-func performLongTask(ctx context.Context, taskName string, duration time.Duration, wg *sync.WaitGroup) {
-	defer wg.Done()
+	"go-projects/clock"
+)
 
+// performLongTask waits duration on clk, or less if ctx ends first. It
+// returns nil when the task completed and ctx.Err() when it was canceled.
+func performLongTask(ctx context.Context, clk clock.Clock, taskName string, duration time.Duration) error {
 	zerolog.Init()
 	logger2 := zerolog.Log
 
@@ -21,12 +23,14 @@ func performLongTask(ctx context.Context, taskName string, duration time.Duratio
 		Logger()
 
 	select {
-	case <-time.After(duration):
+	case <-clk.After(duration):
 		taskLogger.Info().Msg("Task completed successfully")
+		return nil
 	case <-ctx.Done():
 		taskLogger.Warn().
 			Err(ctx.Err()).
 			Msg("Task canceled")
+		return ctx.Err()
 	}
 }
 
@@ -36,6 +40,10 @@ func main() {
 	logger := zerolog.Log
 
 	var wg sync.WaitGroup
+	run := func(ctx context.Context, taskName string, duration time.Duration) {
+		defer wg.Done()
+		_ = performLongTask(ctx, clock.Real{}, taskName, duration)
+	}
 
 	logger.Info().Msg("Starting scenarios")
 
@@ -45,7 +53,7 @@ func main() {
 	ctx1, cancel1 := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel1()
 	wg.Add(1)
-	go performLongTask(ctx1, "TaskA", 2*time.Second, &wg)
+	go run(ctx1, "TaskA", 2*time.Second)
 
 	// Scenario 2
 	scenarioLog = logger.With().Str("scenario", "2").Logger()
@@ -53,7 +61,7 @@ func main() {
 	ctx2, cancel2 := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel2()
 	wg.Add(1)
-	go performLongTask(ctx2, "TaskB", 3*time.Second, &wg)
+	go run(ctx2, "TaskB", 3*time.Second)
 
 	// Scenario 3 - Random duration
 	scenarioLog = logger.With().Str("scenario", "3").Logger()
@@ -62,7 +70,7 @@ func main() {
 	ctx3, cancel3 := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel3()
 	wg.Add(1)
-	go performLongTask(ctx3, "TaskC", randomDuration, &wg)
+	go run(ctx3, "TaskC", randomDuration)
 
 	// Scenario 4 - Random timeout
 	scenarioLog = logger.With().Str("scenario", "4").Logger()
@@ -71,7 +79,7 @@ func main() {
 	ctx4, cancel4 := context.WithTimeout(context.Background(), randomTimeout)
 	defer cancel4()
 	wg.Add(1)
-	go performLongTask(ctx4, "TaskD", 5*time.Second, &wg)
+	go run(ctx4, "TaskD", 5*time.Second)
 
 	wg.Wait()
 	logger.Info().Msg("All scenarios completed")
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"go-projects/leakcheck"
+)
+
+func TestWorker(t *testing.T) {
+	tests := []struct {
+		name       string
+		numWorkers int
+		buffer     int
+	}{
+		{"single worker", 1, 1},
+		{"buffered for all workers", 5, 5},
+		{"unbuffered with collector", 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+
+			results := make(chan workerResult, tt.buffer)
+			var wg sync.WaitGroup
+			for i := 1; i <= tt.numWorkers; i++ {
+				wg.Add(1)
+				go worker(i, results, &wg)
+			}
+			go func() {
+				wg.Wait()
+				close(results)
+			}()
+
+			seen := make(map[int]bool)
+			for res := range results {
+				if seen[res.id] {
+					t.Errorf("worker %d sent more than one result", res.id)
+				}
+				seen[res.id] = true
+				if (res.err == nil) == (res.value == "") {
+					t.Errorf("worker %d: want exactly one of value and err, got %q and %v", res.id, res.value, res.err)
+				}
+			}
+			if len(seen) != tt.numWorkers {
+				t.Errorf("got results from %d workers, want %d", len(seen), tt.numWorkers)
+			}
+		})
+	}
+}
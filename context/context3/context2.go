@@ -4,16 +4,20 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"go-projects/clock"
 )
 
-func doSomething(ctx context.Context) {
+// doSomething works once a second on clk until ctx is done.
+func doSomething(ctx context.Context, clk clock.Clock) {
 	for {
 		select {
-		case <-time.After(1 * time.Second):
+		case <-clk.After(1 * time.Second):
 			fmt.Println("Doing something...")
 
 		case <-ctx.Done():
 			fmt.Println("Context is done")
+			return
 		}
 	}
 }
@@ -23,7 +27,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	go doSomething(ctx)
+	go doSomething(ctx, clock.Real{})
 
 	time.Sleep(5 * time.Second)
 
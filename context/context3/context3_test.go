@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-projects/clock"
+	"go-projects/leakcheck"
+)
+
+func TestDoSomethingStopsWhenContextIsDone(t *testing.T) {
+	tests := []struct {
+		name  string
+		ticks int // Full seconds before the context expires half a second later
+	}{
+		{"expires before first tick", 0},
+		{"expires after first tick", 1},
+		{"expires after many ticks", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+			fake := clock.NewFake(time.Time{})
+			timeout := time.Duration(tt.ticks)*time.Second + 500*time.Millisecond
+			ctx, cancel := clock.WithTimeout(context.Background(), fake, timeout)
+			defer cancel()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				doSomething(ctx, fake)
+			}()
+
+			// Each round doSomething waits on a new tick and on the deadline
+			for range tt.ticks {
+				fake.BlockUntil(2)
+				fake.Advance(time.Second)
+			}
+			fake.BlockUntil(2)
+			fake.Advance(500 * time.Millisecond)
+			<-done
+		})
+	}
+}
+
+func TestDoSomethingAlreadyExpired(t *testing.T) {
+	leakcheck.Check(t)
+	fake := clock.NewFake(time.Time{})
+	ctx, cancel := clock.WithTimeout(context.Background(), fake, 0)
+	defer cancel()
+	doSomething(ctx, fake)
+}
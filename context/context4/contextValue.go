@@ -3,19 +3,25 @@ package main
 import (
 	"context"
 	"fmt"
+
+	"go-projects/ctxkey"
 )
 
+// userIDKey is a typed key: unlike the raw string "userID" it cannot collide
+// with a key of another package, and reading it needs no type assertion.
+var userIDKey = ctxkey.New[int]("userID")
+
 func main() {
 	ctx := context.Background()
-	ctx = context.WithValue(ctx, "userID", 123)
+	ctx = ctxkey.WithValue(ctx, userIDKey, 123)
 
 	printUserID(ctx)
 }
 
 func printUserID(ctx context.Context) {
-	if userID, ok := ctx.Value("userID").(int); ok {
+	if userID, ok := ctxkey.From(ctx, userIDKey); ok {
 		fmt.Printf("User ID: %d\n", userID)
 	} else {
-		fmt.Println("User ID not found or wrong type")
+		fmt.Println("User ID not found")
 	}
 }
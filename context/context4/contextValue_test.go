@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"go-projects/ctxkey"
+	"go-projects/leakcheck"
+)
+
+func TestPrintUserID(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"user id set", ctxkey.WithValue(context.Background(), userIDKey, 123)},
+		{"user id missing", context.Background()},
+		{"same name, other key", ctxkey.WithValue(context.Background(), ctxkey.New[int]("userID"), 456)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+			printUserID(tt.ctx)
+		})
+	}
+}
+
+func TestKeysDoNotCollide(t *testing.T) {
+	other := ctxkey.New[int]("userID")
+	ctx := ctxkey.WithValue(context.Background(), userIDKey, 123)
+	ctx = ctxkey.WithValue(ctx, other, 456)
+
+	if got, _ := ctxkey.From(ctx, userIDKey); got != 123 {
+		t.Errorf("From(userIDKey) = %d, want 123", got)
+	}
+	if got, _ := ctxkey.From(ctx, other); got != 456 {
+		t.Errorf("From(other) = %d, want 456", got)
+	}
+}
@@ -5,17 +5,23 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"go-projects/clock"
 )
 
-func deliverPizza(ctx context.Context, pizzaName string) {
+// deliverPizza prepares the pizza for 25 to 34 seconds on clk. It returns
+// nil once delivered, or ctx.Err() if the order was cancelled first.
+func deliverPizza(ctx context.Context, clk clock.Clock, pizzaName string) error {
 	fmt.Printf("Pizza '%s' is being prepared...\n", pizzaName)
 
 	prepareRand := rand.Intn(10) + 25 // Random between 25-34 seconds
 	select {
-	case <-time.After(time.Duration(prepareRand) * time.Second):
+	case <-clk.After(time.Duration(prepareRand) * time.Second):
 		fmt.Printf("Pizza '%s' delivered! 🍕\n", pizzaName)
+		return nil
 	case <-ctx.Done():
 		fmt.Printf("Pizza '%s' cancelled: %v\n", pizzaName, ctx.Err())
+		return ctx.Err()
 	}
 }
 
@@ -28,10 +34,14 @@ func main() {
 	defer cancel()
 
 	// Start pizza delivery in a separate goroutine
-	go deliverPizza(ctx, "Pepperoni")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = deliverPizza(ctx, clock.Real{}, "Pepperoni")
+	}()
 
 	fmt.Println("Wait to see if pizza is delivered or cancelled.....")
-	time.Sleep(30 * time.Second)
+	<-done // Sleeping a fixed time could exit before the delivery goroutine finishes
 	fmt.Println("Finished waiting.")
 
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-projects/clock"
+	"go-projects/leakcheck"
+)
+
+func TestDeliverPizza(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// advance is how far the clock moves once deliverPizza is waiting;
+		// preparation takes 25 to 34 seconds
+		advance time.Duration
+		wantErr error
+	}{
+		{"already expired", 0, 0, context.DeadlineExceeded},
+		{"expires while preparing", 20 * time.Second, 20 * time.Second, context.DeadlineExceeded},
+		{"delivered", time.Hour, 35 * time.Second, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+			fake := clock.NewFake(time.Time{})
+			ctx, cancel := clock.WithTimeout(context.Background(), fake, tt.timeout)
+			defer cancel()
+
+			done := make(chan error)
+			go func() { done <- deliverPizza(ctx, fake, "Margherita") }()
+
+			if tt.advance > 0 {
+				fake.BlockUntil(2)
+				fake.Advance(tt.advance)
+			}
+			if err := <-done; err != tt.wantErr {
+				t.Errorf("deliverPizza = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
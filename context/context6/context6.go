@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/random"
+	"github.com/cooler-SAI/go-Tools/zerolog"
+
+	"go-projects/budget"
+)
+
+// step simulates a call that takes duration unless its context expires first.
+func step(duration time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case <-time.After(duration):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// handleRequest serves one request within timeout: query the database, write
+// the cache, then call an external API. Instead of a fixed WithTimeout per
+// step, the request deadline is split across the steps.
+func handleRequest(name string, timeout, dbTime, cacheTime, apiTime time.Duration) {
+	logger := zerolog.Log.With().Str("request", name).Logger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	report, err := budget.Run(ctx,
+		budget.Stage{Name: "db", Weight: 2, Run: step(dbTime)},
+		budget.Stage{Name: "cache", Weight: 1, Run: step(cacheTime)},
+		// The external API is useless with less than 300ms, so reserve it
+		budget.Stage{Name: "external API", Weight: 2, Min: 300 * time.Millisecond, Run: step(apiTime)},
+	)
+	for _, s := range report.Stages {
+		logger.Info().
+			Str("stage", s.Name).
+			Dur("allotted", s.Allotted).
+			Dur("used", s.Used).
+			Bool("skipped", s.Skipped).
+			Msg("Stage finished")
+	}
+	if err != nil {
+		culprit, _ := report.Culprit()
+		logger.Warn().Err(err).Str("culprit", culprit.Name).Msg("Request failed")
+		return
+	}
+	logger.Info().Dur("used", report.Used).Msg("Request served")
+}
+
+func main() {
+	zerolog.Init()
+	zerolog.Log.Info().Msg("Starting deadline budget scenarios")
+
+	// Scenario 1: every step is fast, time saved by the db goes to later steps
+	handleRequest("fast", 2*time.Second, 200*time.Millisecond, 50*time.Millisecond, 400*time.Millisecond)
+
+	// Scenario 2: the db is slow and hits its share of the budget
+	handleRequest("slow db", 2*time.Second, 3*time.Second, 50*time.Millisecond, 400*time.Millisecond)
+
+	// Scenario 3: the budget is too small for the API reserve, nothing starts
+	handleRequest("tiny budget", 200*time.Millisecond, 50*time.Millisecond, 50*time.Millisecond, 100*time.Millisecond)
+
+	// Scenario 4: random step durations, like context5's random prepare time
+	handleRequest("random", 2*time.Second,
+		time.Duration(random.RandRange(100, 900))*time.Millisecond,
+		time.Duration(random.RandRange(10, 200))*time.Millisecond,
+		time.Duration(random.RandRange(200, 900))*time.Millisecond)
+
+	zerolog.Log.Info().Msg("All scenarios completed")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-projects/clock"
+	"go-projects/leakcheck"
+)
+
+func TestPerformLongTask(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		timeout  time.Duration
+		wantErr  error
+	}{
+		{"completes before timeout", 2 * time.Second, 3 * time.Second, nil},
+		{"canceled by timeout", 3 * time.Second, time.Second, context.DeadlineExceeded},
+		{"already expired", 5 * time.Second, 0, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+			fake := clock.NewFake(time.Time{})
+			ctx, cancel := clock.WithTimeout(context.Background(), fake, tt.timeout)
+			defer cancel()
+
+			done := make(chan error)
+			go func() { done <- performLongTask(ctx, fake, tt.name, tt.duration) }()
+
+			// The task returns as soon as the first of its two waits ends
+			if tt.timeout > 0 {
+				fake.BlockUntil(2)
+				fake.Advance(min(tt.duration, tt.timeout))
+			}
+			if err := <-done; err != tt.wantErr {
+				t.Errorf("performLongTask = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
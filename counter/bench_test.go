@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go-projects/actor"
+)
+
+// BenchmarkWorker runs the same ten workers as main.
+func BenchmarkWorker(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		wg.Add(10)
+		for j := 0; j < 10; j++ {
+			go worker(j)
+		}
+		wg.Wait()
+	}
+}
+
+func BenchmarkMutexIncrement(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mu.Lock()
+			counter++
+			mu.Unlock()
+		}
+	})
+}
+
+func BenchmarkAtomicIncrement(b *testing.B) {
+	var n atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n.Add(1)
+		}
+	})
+}
+
+func BenchmarkRWMutexIncrement(b *testing.B) {
+	var (
+		rw sync.RWMutex
+		n  int
+	)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rw.Lock()
+			n++
+			rw.Unlock()
+		}
+	})
+}
+
+// BenchmarkActorIncrement owns the counter in an actor instead of locking
+// it: Send enqueues the increment, Ask also waits for it to run.
+func BenchmarkActorIncrement(b *testing.B) {
+	b.Run("send", func(b *testing.B) {
+		a := actor.New(func() int { return 0 }, actor.Options{})
+		defer a.Stop()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = a.Send(context.Background(), func(n *int) { *n++ })
+			}
+		})
+	})
+	b.Run("ask", func(b *testing.B) {
+		a := actor.New(func() int { return 0 }, actor.Options{})
+		defer a.Stop()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = actor.Ask(context.Background(), a, func(n *int) int { *n++; return *n })
+			}
+		})
+	})
+}
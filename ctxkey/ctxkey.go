@@ -0,0 +1,49 @@
+// Package ctxkey provides typed context keys.
+//
+// A raw string key such as "userID" is shared by every package that happens to
+// pick the same string, and reading it back needs an unchecked type assertion.
+// A Key is compared by identity, so two keys never collide even if they have
+// the same name, and From returns the value with its type:
+//
+//	var UserID = ctxkey.New[int]("user_id")
+//
+//	ctx = ctxkey.WithValue(ctx, UserID, 123)
+//	id, ok := ctxkey.From(ctx, UserID)
+package ctxkey
+
+import "context"
+
+// Key identifies a value of type T stored in a context. Create keys with New
+// and keep them in package-level variables.
+type Key[T any] struct {
+	name string
+}
+
+// New returns a new key. The name is only used for String and logging.
+func New[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String returns the name the key was created with.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// WithValue returns a copy of ctx carrying v under key.
+func WithValue[T any](ctx context.Context, key *Key[T], v T) context.Context {
+	return context.WithValue(ctx, key, v)
+}
+
+// From returns the value stored under key and whether it was set.
+func From[T any](ctx context.Context, key *Key[T]) (T, bool) {
+	v, ok := ctx.Value(key).(T)
+	return v, ok
+}
+
+// FromOr returns the value stored under key, or def if it was not set.
+func FromOr[T any](ctx context.Context, key *Key[T], def T) T {
+	if v, ok := From(ctx, key); ok {
+		return v
+	}
+	return def
+}
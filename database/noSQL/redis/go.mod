@@ -2,9 +2,21 @@ module redis
 
 go 1.25
 
-require github.com/redis/go-redis/v9 v9.14.0
+require (
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/cooler-SAI/go-Tools v0.0.8
+	github.com/redis/go-redis/v9 v9.14.0
+	go-projects v0.0.0-00010101000000-000000000000
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/rs/zerolog v1.34.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/sys v0.36.0 // indirect
 )
+
+replace go-projects => ../../..
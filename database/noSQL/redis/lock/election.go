@@ -0,0 +1,206 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotLeader is returned by Resign when this candidate is not the leader.
+var ErrNotLeader = errors.New("lock: not the leader")
+
+// Election elects one leader among several instances sharing a Redis server.
+// Leadership is a lease on the key "{election:<name>}", so a leader that crashes
+// is replaced after at most one TTL.
+//
+// Lock and Unlock have the same shape as sync.Mutex: Lock blocks until this
+// candidate becomes leader and Unlock resigns.
+type Election struct {
+	locker *Locker
+	id     string
+
+	mu    sync.Mutex
+	lease *Lease
+}
+
+var _ sync.Locker = (*Election)(nil)
+
+// NewElection creates a candidate with the given id for the election name.
+func NewElection(rdb redis.Cmdable, name, id string, opts Options) *Election {
+	return &Election{
+		locker: New(rdb, "election:"+name, opts),
+		id:     id,
+	}
+}
+
+// ID returns the identity of this candidate.
+func (e *Election) ID() string {
+	return e.id
+}
+
+// Campaign blocks until this candidate becomes leader or ctx is done.
+// Leadership ends when Resign is called, when ctx is canceled or when the
+// lease is lost; watch Lost to find out about the last case. Campaign
+// returns nil right away when this candidate is already the leader.
+func (e *Election) Campaign(ctx context.Context) error {
+	e.mu.Lock()
+	old := e.lease
+	e.mu.Unlock()
+	if old != nil {
+		select {
+		case <-old.Lost():
+			// Stop its renewal; the key is no longer ours
+			_ = old.Release(ctx)
+		default:
+			return nil
+		}
+	}
+
+	lease, err := e.locker.acquire(ctx, e.id+"/")
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.lease = lease
+	e.mu.Unlock()
+	return nil
+}
+
+// Resign gives up leadership so another candidate can take over immediately.
+func (e *Election) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	lease := e.lease
+	e.lease = nil
+	e.mu.Unlock()
+
+	if lease == nil {
+		return ErrNotLeader
+	}
+	return lease.Release(ctx)
+}
+
+// IsLeader reports whether this candidate currently holds a live lease.
+func (e *Election) IsLeader() bool {
+	e.mu.Lock()
+	lease := e.lease
+	e.mu.Unlock()
+
+	if lease == nil {
+		return false
+	}
+	select {
+	case <-lease.Lost():
+		return false
+	default:
+		return true
+	}
+}
+
+// Lost returns a channel closed when the current leadership is lost.
+// It returns nil (blocks forever) when this candidate is not the leader.
+func (e *Election) Lost() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.lease == nil {
+		return nil
+	}
+	return e.lease.Lost()
+}
+
+// Token returns the fencing token of the current term, or 0 when not leader.
+func (e *Election) Token() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.lease == nil {
+		return 0
+	}
+	return e.lease.token
+}
+
+// Leader returns the id of the current leader, or "" when there is none.
+func (e *Election) Leader(ctx context.Context) (string, error) {
+	value, err := e.locker.rdb.Get(ctx, e.locker.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("election %s: get leader: %w", e.locker.key, err)
+	}
+
+	if i := strings.LastIndex(value, "/"); i >= 0 {
+		return value[:i], nil
+	}
+	return value, nil
+}
+
+// Lock blocks until this candidate becomes leader, retrying through Redis errors.
+func (e *Election) Lock() {
+	for {
+		if err := e.Campaign(context.Background()); err == nil {
+			return
+		}
+		time.Sleep(e.locker.opts.RetryInterval)
+	}
+}
+
+// Unlock resigns leadership. It panics if this candidate is not the leader.
+func (e *Election) Unlock() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.locker.opts.TTL)
+	defer cancel()
+
+	if err := e.Resign(ctx); errors.Is(err, ErrNotLeader) {
+		panic("lock: unlock of election not held")
+	}
+}
+
+// Run campaigns in a loop and calls fn every time this candidate becomes
+// leader. The context passed to fn is canceled when leadership is lost, so fn
+// should return promptly once it is done. Run returns when ctx is done.
+func (e *Election) Run(ctx context.Context, fn func(ctx context.Context)) error {
+	for {
+		if err := e.Campaign(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Redis is unavailable; try again after a pause.
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(e.locker.opts.RetryInterval):
+			}
+			continue
+		}
+
+		termCtx, cancel := context.WithCancel(ctx)
+		lost := e.Lost()
+		go func() {
+			select {
+			case <-lost:
+				cancel()
+			case <-termCtx.Done():
+			}
+		}()
+
+		fn(termCtx)
+		cancel()
+
+		resignCtx, resignCancel := context.WithTimeout(context.Background(), e.locker.opts.TTL)
+		_ = e.Resign(resignCtx)
+		resignCancel()
+
+		// Step back for one retry interval so the other candidates get a fair chance.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.locker.opts.RetryInterval):
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package lock implements a distributed lock on top of Redis.
+//
+// A lock is a single key written with SET NX PX. Every successful acquire
+// also increments a fencing counter, so each holder gets a token that is
+// strictly larger than the one of any previous holder. Downstream systems can
+// reject writes carrying an older token, which protects against a holder that
+// paused (GC, network) long enough for its lease to expire.
+//
+// The lock key and the fencing counter must live on one node for the acquire
+// script to touch both, so the lock key is wrapped in a Redis Cluster hash tag
+// ("{orders}" for "orders") unless it already has one.
+//
+// Unlock and lease renewal run as Lua scripts that compare the stored value
+// with the owner's random value, so one instance never deletes or extends a
+// lock that was taken over by another instance.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNotAcquired is returned by TryAcquire when the lock is held by someone else.
+	ErrNotAcquired = errors.New("lock: not acquired")
+	// ErrNotHeld is returned by Release when the lease already expired or was taken over.
+	ErrNotHeld = errors.New("lock: not held")
+)
+
+// acquireScript sets the lock key only if it does not exist and, on success,
+// returns the next fencing token. It returns 0 when the lock is busy.
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lock key only if it still holds our value.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewScript extends the lock TTL only if it still holds our value.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Options configures a Locker. Zero values are replaced by defaults.
+type Options struct {
+	// TTL is the lease length written with PX. Default: 10s.
+	TTL time.Duration
+	// RetryInterval is the pause between attempts in Acquire. Default: 100ms.
+	RetryInterval time.Duration
+	// RenewInterval is how often a held lease is extended. Default: TTL/3.
+	RenewInterval time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.TTL <= 0 {
+		o.TTL = 10 * time.Second
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = 100 * time.Millisecond
+	}
+	if o.RenewInterval <= 0 || o.RenewInterval >= o.TTL {
+		o.RenewInterval = o.TTL / 3
+	}
+	return o
+}
+
+// Locker hands out leases on a single Redis key.
+type Locker struct {
+	rdb  redis.Cmdable
+	key  string
+	opts Options
+}
+
+// New creates a Locker for key. A key without a hash tag is stored as
+// "{"+key+"}"; the fencing counter is stored in that key + ":fence", which
+// has the same hash tag and so the same cluster slot.
+func New(rdb redis.Cmdable, key string, opts Options) *Locker {
+	if !hasHashTag(key) {
+		key = "{" + key + "}"
+	}
+	return &Locker{rdb: rdb, key: key, opts: opts.withDefaults()}
+}
+
+// Key returns the Redis key guarded by this Locker, with its hash tag.
+func (l *Locker) Key() string {
+	return l.key
+}
+
+func (l *Locker) fenceKey() string {
+	return l.key + ":fence"
+}
+
+// hasHashTag reports whether Redis Cluster hashes only a part of key: the
+// text between the first "{" and the next "}", if it is not empty.
+func hasHashTag(key string) bool {
+	_, rest, ok := strings.Cut(key, "{")
+	if !ok {
+		return false
+	}
+	tag, _, ok := strings.Cut(rest, "}")
+	return ok && tag != ""
+}
+
+// TryAcquire makes a single attempt to take the lock and returns
+// ErrNotAcquired if it is busy.
+//
+// The returned lease is renewed in the background until Release is called or
+// ctx is canceled. When ctx is canceled the lease is released on a best-effort
+// basis, so the lock never outlives the work it protects.
+func (l *Locker) TryAcquire(ctx context.Context) (*Lease, error) {
+	return l.tryAcquire(ctx, "")
+}
+
+// Acquire blocks until the lock is taken or ctx is done.
+func (l *Locker) Acquire(ctx context.Context) (*Lease, error) {
+	return l.acquire(ctx, "")
+}
+
+func (l *Locker) acquire(ctx context.Context, prefix string) (*Lease, error) {
+	for {
+		lease, err := l.tryAcquire(ctx, prefix)
+		if err == nil {
+			return lease, nil
+		}
+		if !errors.Is(err, ErrNotAcquired) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.opts.RetryInterval):
+		}
+	}
+}
+
+func (l *Locker) tryAcquire(ctx context.Context, prefix string) (*Lease, error) {
+	value, err := randomValue(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// The TTL starts at the latest when Redis runs the script, so counting
+	// from before the request errs on the safe side
+	sent := time.Now()
+	token, err := acquireScript.Run(ctx, l.rdb, []string{l.key, l.fenceKey()},
+		value, l.opts.TTL.Milliseconds()).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("lock %s: acquire: %w", l.key, err)
+	}
+	if token == 0 {
+		return nil, ErrNotAcquired
+	}
+
+	lease := &Lease{
+		locker: l,
+		value:  value,
+		token:  token,
+		lost:   make(chan struct{}),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go lease.keepAlive(ctx, sent.Add(l.opts.TTL))
+	return lease, nil
+}
+
+// Lease is a held lock. It is renewed in the background until released.
+type Lease struct {
+	locker *Locker
+	value  string
+	token  int64
+
+	lostOnce sync.Once
+	lost     chan struct{}
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Token returns the fencing token of this lease. Tokens grow monotonically
+// across all holders of the same key.
+func (le *Lease) Token() int64 {
+	return le.token
+}
+
+// Lost is closed when the lease is about to expire without a successful
+// renewal, or when the key was taken over by another owner. It is closed
+// one RenewInterval before the key can expire, so work guarded by the lease
+// that stops as soon as Lost is closed is done before anybody else can
+// acquire the lock.
+func (le *Lease) Lost() <-chan struct{} {
+	return le.lost
+}
+
+// Release stops renewal and deletes the lock if it is still ours.
+// It returns ErrNotHeld if the lease had already expired.
+func (le *Lease) Release(ctx context.Context) error {
+	le.stopOnce.Do(func() { close(le.stop) })
+	<-le.done
+
+	n, err := releaseScript.Run(ctx, le.locker.rdb, []string{le.locker.key}, le.value).Int64()
+	if err != nil {
+		return fmt.Errorf("lock %s: release: %w", le.locker.key, err)
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// keepAlive extends the TTL every RenewInterval. A renewal that fails with a
+// network error is retried on the next tick. Without a successful renewal
+// the lease is reported as lost one RenewInterval before validUntil, while
+// the key is still ours, which leaves the holder that long to stop.
+func (le *Lease) keepAlive(ctx context.Context, validUntil time.Time) {
+	defer close(le.done)
+
+	opts := le.locker.opts
+	ticker := time.NewTicker(opts.RenewInterval)
+	defer ticker.Stop()
+
+	lostAt := validUntil.Add(-opts.RenewInterval)
+	expiring := time.NewTimer(time.Until(lostAt))
+	defer expiring.Stop()
+
+	for {
+		select {
+		case <-le.stop:
+			return
+		case <-ctx.Done():
+			// The owner is gone: give the lock back instead of waiting for the TTL.
+			releaseCtx, cancel := context.WithTimeout(context.Background(), opts.RenewInterval)
+			releaseScript.Run(releaseCtx, le.locker.rdb, []string{le.locker.key}, le.value)
+			cancel()
+			le.markLost()
+			return
+		case <-expiring.C:
+			le.markLost()
+			return
+		case <-ticker.C:
+			// A slow renewal must not delay the lost signal past lostAt
+			sent := time.Now()
+			deadline := sent.Add(opts.RenewInterval)
+			if lostAt.Before(deadline) {
+				deadline = lostAt
+			}
+			renewCtx, cancel := context.WithDeadline(ctx, deadline)
+			n, err := renewScript.Run(renewCtx, le.locker.rdb, []string{le.locker.key},
+				le.value, opts.TTL.Milliseconds()).Int64()
+			cancel()
+
+			switch {
+			case err == nil && n == 1:
+				lostAt = sent.Add(opts.TTL - opts.RenewInterval)
+				expiring.Reset(time.Until(lostAt))
+			case err == nil:
+				// The key expired or belongs to someone else now.
+				le.markLost()
+				return
+			case !time.Now().Before(lostAt):
+				le.markLost()
+				return
+			}
+		}
+	}
+}
+
+func (le *Lease) markLost() {
+	le.lostOnce.Do(func() { close(le.lost) })
+}
+
+// randomValue builds the owner value stored in the lock key. The prefix lets
+// callers such as Election embed a readable identity in front of the random part.
+func randomValue(prefix string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("lock: generate owner value: %w", err)
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
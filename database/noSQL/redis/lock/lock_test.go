@@ -0,0 +1,219 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return m, client
+}
+
+func TestFencingTokens(t *testing.T) {
+	_, client := newRedis(t)
+	l := New(client, "lock:test", Options{})
+	ctx := context.Background()
+
+	for want := int64(1); want <= 3; want++ {
+		lease, err := l.TryAcquire(ctx)
+		if err != nil {
+			t.Fatalf("TryAcquire: %v", err)
+		}
+		if lease.Token() != want {
+			t.Errorf("token = %d, want %d", lease.Token(), want)
+		}
+		if _, err := l.TryAcquire(ctx); !errors.Is(err, ErrNotAcquired) {
+			t.Errorf("TryAcquire while held: err = %v, want ErrNotAcquired", err)
+		}
+		if err := lease.Release(ctx); err != nil {
+			t.Errorf("Release: %v", err)
+		}
+	}
+}
+
+func TestKeysShareHashTag(t *testing.T) {
+	tests := []struct {
+		key, lock, fence string
+	}{
+		{"lock:test", "{lock:test}", "{lock:test}:fence"},
+		{"{orders}:lock", "{orders}:lock", "{orders}:lock:fence"},
+		{"{}:lock", "{{}:lock}", "{{}:lock}:fence"}, // An empty tag hashes the whole key
+	}
+	for _, tt := range tests {
+		m, client := newRedis(t)
+		l := New(client, tt.key, Options{})
+		lease, err := l.TryAcquire(context.Background())
+		if err != nil {
+			t.Fatalf("%s: TryAcquire: %v", tt.key, err)
+		}
+		if l.Key() != tt.lock || !m.Exists(tt.lock) || !m.Exists(tt.fence) {
+			t.Errorf("%s: keys %v, want %s and %s", tt.key, m.Keys(), tt.lock, tt.fence)
+		}
+		_ = lease.Release(context.Background())
+	}
+}
+
+func TestReleaseChecksOwner(t *testing.T) {
+	m, client := newRedis(t)
+	l := New(client, "lock:test", Options{TTL: 10 * time.Second})
+	ctx := context.Background()
+
+	old, err := l.TryAcquire(ctx)
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	m.FastForward(10 * time.Second) // The lease expires while its owner is paused
+
+	current, err := l.TryAcquire(ctx)
+	if err != nil {
+		t.Fatalf("TryAcquire after expiry: %v", err)
+	}
+	defer func() { _ = current.Release(ctx) }()
+
+	if err := old.Release(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Release of the expired lease: err = %v, want ErrNotHeld", err)
+	}
+	if got, _ := m.Get("{lock:test}"); got != current.value {
+		t.Errorf("the old owner's Release removed the new owner's key, value now %q", got)
+	}
+}
+
+func TestRenewChecksOwner(t *testing.T) {
+	m, client := newRedis(t)
+	ctx := context.Background()
+	keys := []string{"{lock:test}"}
+
+	if err := client.Set(ctx, "{lock:test}", "other", time.Second).Err(); err != nil {
+		t.Fatal(err)
+	}
+	n, err := renewScript.Run(ctx, client, keys, "mine", 5000).Int64()
+	if err != nil || n != 0 {
+		t.Errorf("renew of someone else's key = %d, %v; want 0", n, err)
+	}
+	if ttl := m.TTL("{lock:test}"); ttl != time.Second {
+		t.Errorf("TTL = %v, someone else's lease was extended", ttl)
+	}
+
+	n, err = renewScript.Run(ctx, client, keys, "other", 5000).Int64()
+	if err != nil || n != 1 {
+		t.Errorf("renew by the owner = %d, %v; want 1", n, err)
+	}
+	if ttl := m.TTL("{lock:test}"); ttl != 5*time.Second {
+		t.Errorf("TTL = %v, want 5s", ttl)
+	}
+}
+
+func TestLostOnTakeover(t *testing.T) {
+	m, client := newRedis(t)
+	l := New(client, "lock:test", Options{TTL: time.Second, RenewInterval: 20 * time.Millisecond})
+
+	lease, err := l.TryAcquire(context.Background())
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	if err := m.Set("{lock:test}", "intruder"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Lost not closed after the key was taken over")
+	}
+}
+
+func TestLostBeforeExpiry(t *testing.T) {
+	m, client := newRedis(t)
+	const ttl = 600 * time.Millisecond
+	l := New(client, "lock:test", Options{TTL: ttl, RenewInterval: 100 * time.Millisecond})
+
+	start := time.Now()
+	lease, err := l.TryAcquire(context.Background())
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	m.Close() // Every renewal fails from now on
+
+	select {
+	case <-lease.Lost():
+		if d := time.Since(start); d >= ttl {
+			t.Errorf("Lost closed after %v, the key could already belong to someone else after %v", d, ttl)
+		}
+	case <-time.After(2 * ttl):
+		t.Fatal("Lost not closed while Redis is down")
+	}
+}
+
+func TestCampaignWhileLeader(t *testing.T) {
+	m, client := newRedis(t)
+	e := NewElection(client, "test", "node-1", Options{TTL: time.Second, RenewInterval: 20 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := e.Campaign(ctx); err != nil {
+		t.Fatalf("Campaign: %v", err)
+	}
+	first := e.lease
+	if err := e.Campaign(ctx); err != nil {
+		t.Fatalf("second Campaign: %v", err)
+	}
+	if e.lease != first || e.Token() != 1 {
+		t.Errorf("second Campaign replaced the live lease (token %d)", e.Token())
+	}
+
+	// After losing the lease, Campaign starts a new term and stops the old renewal
+	_ = m.Set("{election:test}", "intruder")
+	<-e.Lost()
+	m.Del("{election:test}")
+	if err := e.Campaign(ctx); err != nil {
+		t.Fatalf("Campaign after losing: %v", err)
+	}
+	select {
+	case <-first.done:
+	default:
+		t.Error("the old lease is still being renewed")
+	}
+	if e.Token() != 2 || !e.IsLeader() {
+		t.Errorf("token = %d, leader %t; want a new term", e.Token(), e.IsLeader())
+	}
+	if err := e.Resign(ctx); err != nil {
+		t.Errorf("Resign: %v", err)
+	}
+}
+
+func TestMutex(t *testing.T) {
+	_, client := newRedis(t)
+
+	counter := 0
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Separate Mutexes, like separate processes would have
+			m := NewMutex(New(client, "lock:counter", Options{RetryInterval: time.Millisecond}))
+			for range 10 {
+				m.Lock()
+				n := counter
+				time.Sleep(time.Millisecond)
+				counter = n + 1
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counter != 30 {
+		t.Errorf("counter = %d, want 30", counter)
+	}
+}
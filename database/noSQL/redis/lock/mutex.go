@@ -0,0 +1,77 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Mutex adapts a Locker to the sync.Locker interface, so code written
+// against sync.Mutex can be pointed at a lock shared by several processes.
+//
+// Lock keeps retrying through Redis errors, the same way sync.Mutex.Lock
+// simply waits. Use LockContext when the caller needs to give up.
+type Mutex struct {
+	locker *Locker
+
+	mu    sync.Mutex
+	lease *Lease
+}
+
+var _ sync.Locker = (*Mutex)(nil)
+
+// NewMutex creates a Mutex backed by locker.
+func NewMutex(locker *Locker) *Mutex {
+	return &Mutex{locker: locker}
+}
+
+// Lock blocks until the distributed lock is held.
+func (m *Mutex) Lock() {
+	for {
+		if err := m.LockContext(context.Background()); err == nil {
+			return
+		}
+		time.Sleep(m.locker.opts.RetryInterval)
+	}
+}
+
+// LockContext blocks until the lock is held, ctx is done or Redis fails.
+func (m *Mutex) LockContext(ctx context.Context) error {
+	lease, err := m.locker.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.lease = lease
+	m.mu.Unlock()
+	return nil
+}
+
+// Unlock releases the lock. Like sync.Mutex it panics if the lock is not held.
+func (m *Mutex) Unlock() {
+	m.mu.Lock()
+	lease := m.lease
+	m.lease = nil
+	m.mu.Unlock()
+
+	if lease == nil {
+		panic("lock: unlock of unlocked mutex")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.locker.opts.TTL)
+	defer cancel()
+	// ErrNotHeld means the lease expired on its own; there is nothing left to undo.
+	_ = lease.Release(ctx)
+}
+
+// Token returns the fencing token of the current holder, or 0 when unlocked.
+func (m *Mutex) Token() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lease == nil {
+		return 0
+	}
+	return m.lease.token
+}
@@ -0,0 +1,139 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// Memory is an in-process Broker. It delivers only within one program, in
+// publish order per subscriber.
+type Memory struct {
+	opts Options
+
+	mu     sync.RWMutex
+	subs   map[*memorySubscription]struct{}
+	closed bool
+}
+
+var _ Broker = (*Memory)(nil)
+
+// NewMemory creates an in-process broker.
+func NewMemory(opts Options) *Memory {
+	return &Memory{opts: opts.withDefaults(), subs: make(map[*memorySubscription]struct{})}
+}
+
+type memorySubscription struct {
+	*subscriber
+	broker   *Memory
+	channels map[string]bool
+	patterns []pattern // In subscription order
+}
+
+type pattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+// match returns what Redis would deliver channel for: "" for an exact
+// channel subscription, then every matching pattern in subscription order.
+func (s *memorySubscription) match(channel string) []string {
+	var matches []string
+	if s.channels[channel] {
+		matches = append(matches, "")
+	}
+	for _, p := range s.patterns {
+		if p.re.MatchString(channel) {
+			matches = append(matches, p.glob)
+		}
+	}
+	return matches
+}
+
+// Publish implements Broker. With the Block policy it waits for slow
+// subscribers until ctx is done.
+func (m *Memory) Publish(ctx context.Context, channel, payload string) error {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return ErrClosed
+	}
+	// Deliver outside the lock, so a blocked delivery does not hold up
+	// Subscribe and Unsubscribe
+	type target struct {
+		sub     *memorySubscription
+		pattern string
+	}
+	var targets []target
+	for sub := range m.subs {
+		for _, pattern := range sub.match(channel) {
+			targets = append(targets, target{sub, pattern})
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, t := range targets {
+		msg := Message{Channel: channel, Pattern: t.pattern, Payload: payload}
+		if err := t.sub.deliver(ctx, msg); err != nil {
+			return fmt.Errorf("pubsub: publish to %s: %w", channel, err)
+		}
+	}
+	return nil
+}
+
+// Subscribe implements Broker.
+func (m *Memory) Subscribe(_ context.Context, patterns ...string) (Subscription, error) {
+	sub := &memorySubscription{
+		subscriber: newSubscriber(m.opts),
+		broker:     m,
+		channels:   make(map[string]bool),
+	}
+	for _, p := range patterns {
+		if !isPattern(p) {
+			sub.channels[p] = true
+			continue
+		}
+		if slices.ContainsFunc(sub.patterns, func(q pattern) bool { return q.glob == p }) {
+			continue // Redis ignores a repeated PSUBSCRIBE too
+		}
+		re, err := compilePattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("pubsub: pattern %q: %w", p, err)
+		}
+		sub.patterns = append(sub.patterns, pattern{glob: p, re: re})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	m.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe implements Subscription.
+func (s *memorySubscription) Unsubscribe() error {
+	s.broker.mu.Lock()
+	delete(s.broker.subs, s)
+	s.broker.mu.Unlock()
+
+	s.close()
+	return nil
+}
+
+// Close implements Broker.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	subs := m.subs
+	m.subs = make(map[*memorySubscription]struct{})
+	m.mu.Unlock()
+
+	for sub := range subs {
+		sub.close()
+	}
+	return nil
+}
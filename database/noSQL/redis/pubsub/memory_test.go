@@ -0,0 +1,227 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go-projects/leakcheck"
+)
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		channel string
+		want    bool
+	}{
+		{"notifications:*", "notifications:alerts", true},
+		{"notifications:*", "notifications:", true},
+		{"notifications:*", "orders:new", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "heello", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{`price\*`, "price*", true},
+		{`price\*`, "prices", false},
+		{"a.b", "axb", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.channel, func(t *testing.T) {
+			re, err := compilePattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("compilePattern: %v", err)
+			}
+			if got := re.MatchString(tt.channel); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func receiveAll(sub Subscription) []string {
+	var got []string
+	for {
+		select {
+		case msg := <-sub.Messages():
+			got = append(got, msg.Payload)
+		case <-time.After(50 * time.Millisecond):
+			return got
+		}
+	}
+}
+
+func TestMemoryRouting(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"exact channel", []string{"notifications:alerts"}, []string{"alert"}},
+		{"wildcard", []string{"notifications:*"}, []string{"alert", "news"}},
+		{"several patterns", []string{"orders:*", "notifications:news"}, []string{"news", "order"}},
+		{"no match", []string{"billing:*"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+
+			ctx := context.Background()
+			broker := NewMemory(Options{})
+			defer broker.Close()
+
+			sub, err := broker.Subscribe(ctx, tt.patterns...)
+			if err != nil {
+				t.Fatalf("Subscribe: %v", err)
+			}
+			_ = broker.Publish(ctx, "notifications:alerts", "alert")
+			_ = broker.Publish(ctx, "notifications:news", "news")
+			_ = broker.Publish(ctx, "orders:new", "order")
+
+			if got := receiveAll(sub); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// overlapping subscribes to patterns, publishes once to orders:new and
+// returns the Pattern of every delivered message.
+func overlapping(t *testing.T, broker Broker, patterns ...string) []string {
+	t.Helper()
+	ctx := context.Background()
+	sub, err := broker.Subscribe(ctx, patterns...)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	if err := broker.Publish(ctx, "orders:new", "order"); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	var got []string
+	for {
+		select {
+		case msg := <-sub.Messages():
+			got = append(got, msg.Pattern)
+		case <-time.After(50 * time.Millisecond):
+			return got
+		}
+	}
+}
+
+func TestMemoryOverlappingSubscriptions(t *testing.T) {
+	leakcheck.Check(t)
+	broker := NewMemory(Options{})
+	defer broker.Close()
+
+	// Once per match, like Redis: exact channel first, then the patterns
+	// in subscription order, a repeated pattern only once
+	want := []string{"", "orders:*", "*:new"}
+	for range 10 {
+		got := overlapping(t, broker, "orders:*", "orders:new", "orders:*", "*:new")
+		if !slices.Equal(got, want) {
+			t.Fatalf("patterns = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestMemorySlowConsumerPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      Policy
+		want        []string
+		wantDropped int64
+		wantErr     error
+	}{
+		{"drop oldest", DropOldest, []string{"3", "4"}, 2, nil},
+		{"drop newest", DropNewest, []string{"1", "2"}, 2, nil},
+		{"block until publish times out", Block, []string{"1", "2"}, 1, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+
+			broker := NewMemory(Options{Buffer: 2, Policy: tt.policy})
+			defer broker.Close()
+			sub, err := broker.Subscribe(context.Background(), "ticks")
+			if err != nil {
+				t.Fatalf("Subscribe: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			var publishErr error
+			for _, payload := range []string{"1", "2", "3", "4"} {
+				if publishErr = broker.Publish(ctx, "ticks", payload); publishErr != nil {
+					break
+				}
+			}
+			if !errors.Is(publishErr, tt.wantErr) {
+				t.Fatalf("Publish: err = %v, want %v", publishErr, tt.wantErr)
+			}
+
+			if got := receiveAll(sub); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if got := sub.Dropped(); got != tt.wantDropped {
+				t.Errorf("Dropped = %d, want %d", got, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestMemoryUnsubscribeReleasesBlockedPublisher(t *testing.T) {
+	leakcheck.Check(t)
+
+	broker := NewMemory(Options{Buffer: 1, Policy: Block})
+	defer broker.Close()
+	sub, _ := broker.Subscribe(context.Background(), "ticks")
+
+	_ = broker.Publish(context.Background(), "ticks", "fills the buffer")
+	published := make(chan error, 1)
+	go func() { published <- broker.Publish(context.Background(), "ticks", "blocks") }()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := sub.Unsubscribe(); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Errorf("Publish: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish still blocked after Unsubscribe")
+	}
+
+	// The channel is closed after the buffered message
+	<-sub.Messages()
+	if _, ok := <-sub.Messages(); ok {
+		t.Error("Messages not closed after Unsubscribe")
+	}
+}
+
+func TestMemoryClose(t *testing.T) {
+	broker := NewMemory(Options{})
+	sub, _ := broker.Subscribe(context.Background(), "notifications:*")
+	_ = broker.Close()
+
+	if _, ok := <-sub.Messages(); ok {
+		t.Error("Messages not closed by broker Close")
+	}
+	if err := broker.Publish(context.Background(), "notifications:x", "late"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish after Close: err = %v, want ErrClosed", err)
+	}
+	if _, err := broker.Subscribe(context.Background(), "x"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close: err = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,204 @@
+// Package pubsub is publish/subscribe with the semantics of Redis Pub/Sub,
+// backed either by Redis or by an in-process broker.
+//
+// Code written against Broker can run with Redis between several processes,
+// as in redis5, or with Memory inside a single binary and in tests, without
+// a Redis server. Subscriptions take channel names or Redis glob patterns
+// such as "notifications:*". Every subscriber has its own buffered channel;
+// what happens when it is full is decided by the Policy.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned by a closed Broker.
+var ErrClosed = errors.New("pubsub: broker closed")
+
+// Message is one published payload as seen by a subscriber.
+type Message struct {
+	Channel string
+	// Pattern is the subscription pattern that matched, empty for a plain
+	// channel subscription.
+	Pattern string
+	Payload string
+}
+
+// Policy decides what happens when a subscriber's buffer is full.
+type Policy int
+
+const (
+	// DropOldest discards the oldest buffered message to make room, so a
+	// slow subscriber always sees the most recent messages.
+	DropOldest Policy = iota
+	// DropNewest discards the message being published.
+	DropNewest
+	// Block makes the publisher wait until the subscriber has room or the
+	// publish context is done. One slow subscriber slows down everybody.
+	Block
+)
+
+// Options configures a Broker. Zero values are replaced by defaults.
+type Options struct {
+	// Buffer is the capacity of every subscriber's channel. Default: 64.
+	Buffer int
+	// Policy applies to all subscribers. Default: DropOldest.
+	Policy Policy
+}
+
+func (o Options) withDefaults() Options {
+	if o.Buffer <= 0 {
+		o.Buffer = 64
+	}
+	return o
+}
+
+// Broker publishes messages and creates subscriptions.
+type Broker interface {
+	Publish(ctx context.Context, channel, payload string) error
+	// Subscribe listens to the given channels and patterns. A pattern
+	// contains one of the glob characters *, ? or [. As in Redis, a message
+	// arrives once for the exact channel and once more per matching pattern.
+	Subscribe(ctx context.Context, patterns ...string) (Subscription, error)
+	// Close ends all subscriptions of the broker.
+	Close() error
+}
+
+// Subscription delivers messages until Unsubscribe or the broker's Close.
+type Subscription interface {
+	// Messages is closed when the subscription ends.
+	Messages() <-chan Message
+	Unsubscribe() error
+	// Dropped counts messages lost to the slow-consumer policy.
+	Dropped() int64
+}
+
+// isPattern reports whether s uses glob syntax and needs PSUBSCRIBE.
+func isPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// compilePattern translates a Redis glob pattern into a regular expression:
+// * matches any sequence, ? one character, [...] a character class, and a
+// backslash escapes the next character.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(pattern[i:]))
+				i = len(pattern)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "^") {
+				class = "^" + regexp.QuoteMeta(class[1:])
+			} else {
+				class = regexp.QuoteMeta(class)
+			}
+			// QuoteMeta leaves '-' alone, so ranges like a-z keep working
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// subscriber is the buffered channel of one subscription and applies the
+// slow-consumer policy. Memory and Redis both deliver through it.
+type subscriber struct {
+	ch      chan Message
+	policy  Policy
+	done    chan struct{}
+	dropped atomic.Int64
+
+	once   sync.Once
+	mu     sync.Mutex // Serializes deliveries with each other and with close
+	closed bool
+}
+
+func newSubscriber(opts Options) *subscriber {
+	return &subscriber{
+		ch:     make(chan Message, opts.Buffer),
+		policy: opts.Policy,
+		done:   make(chan struct{}),
+	}
+}
+
+// deliver hands msg to the subscriber according to its policy. Only Block
+// can wait, and it gives up when ctx is done or the subscription ends.
+func (s *subscriber) deliver(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	switch s.policy {
+	case Block:
+		select {
+		case s.ch <- msg:
+		case <-s.done:
+		case <-ctx.Done():
+			s.dropped.Add(1)
+			return ctx.Err()
+		}
+	case DropNewest:
+		select {
+		case s.ch <- msg:
+		default:
+			s.dropped.Add(1)
+		}
+	default:
+		for {
+			select {
+			case s.ch <- msg:
+				return nil
+			default:
+			}
+			// Full: make room, unless the reader just did
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	}
+	return nil
+}
+
+// close ends the subscription. done is closed first so that a delivery
+// blocked under the Block policy gives up and releases mu.
+func (s *subscriber) close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed = true
+		close(s.ch)
+	})
+}
+
+func (s *subscriber) Messages() <-chan Message { return s.ch }
+
+func (s *subscriber) Dropped() int64 { return s.dropped.Load() }
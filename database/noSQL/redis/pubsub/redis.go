@@ -0,0 +1,177 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a Broker on Redis Pub/Sub. Messages reach every process
+// subscribed to the same Redis server.
+//
+// go-redis reads each subscription on its own connection into a channel of
+// its own; the subscriber buffer and Policy apply after that. With Block, a
+// subscriber that stays full makes go-redis drop messages after its send
+// timeout, as the Redis server would not wait either.
+type Redis struct {
+	client *redis.Client
+	opts   Options
+
+	mu     sync.Mutex
+	subs   map[*redisSubscription]struct{}
+	closed bool
+}
+
+var _ Broker = (*Redis)(nil)
+
+// NewRedis creates a broker on client. Close ends the subscriptions but
+// leaves the client open.
+func NewRedis(client *redis.Client, opts Options) *Redis {
+	return &Redis{client: client, opts: opts.withDefaults(), subs: make(map[*redisSubscription]struct{})}
+}
+
+type redisSubscription struct {
+	*subscriber
+	broker *Redis
+	pubsub *redis.PubSub
+	wg     sync.WaitGroup
+}
+
+// Publish implements Broker.
+func (r *Redis) Publish(ctx context.Context, channel, payload string) error {
+	r.mu.Lock()
+	closed := r.closed
+	r.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	if err := r.client.Publish(ctx, channel, payload).Err(); err != nil {
+		return fmt.Errorf("pubsub: publish to %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Subscribe implements Broker. Plain names use SUBSCRIBE and glob patterns
+// PSUBSCRIBE; Subscribe returns once Redis has confirmed all of them.
+func (r *Redis) Subscribe(ctx context.Context, patterns ...string) (Subscription, error) {
+	var channels, globs []string
+	for _, p := range patterns {
+		if isPattern(p) {
+			globs = append(globs, p)
+		} else {
+			channels = append(channels, p)
+		}
+	}
+
+	ps := r.client.Subscribe(ctx)
+	early, err := subscribeAll(ctx, ps, channels, globs)
+	if err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+
+	sub := &redisSubscription{subscriber: newSubscriber(r.opts), broker: r, pubsub: ps}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		_ = ps.Close()
+		return nil, ErrClosed
+	}
+	r.subs[sub] = struct{}{}
+	r.mu.Unlock()
+
+	sub.wg.Add(1)
+	go sub.forward(early)
+	return sub, nil
+}
+
+// subscribeAll subscribes ps and waits until Redis has confirmed every
+// channel and pattern. Messages can arrive between the confirmations; they
+// are returned so the subscriber still gets them.
+func subscribeAll(ctx context.Context, ps *redis.PubSub, channels, globs []string) ([]*redis.Message, error) {
+	if len(channels) > 0 {
+		if err := ps.Subscribe(ctx, channels...); err != nil {
+			return nil, fmt.Errorf("pubsub: subscribe: %w", err)
+		}
+	}
+	if len(globs) > 0 {
+		if err := ps.PSubscribe(ctx, globs...); err != nil {
+			return nil, fmt.Errorf("pubsub: psubscribe: %w", err)
+		}
+	}
+
+	var early []*redis.Message
+	for pending := len(channels) + len(globs); pending > 0; {
+		reply, err := ps.Receive(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("pubsub: subscribe: %w", err)
+		}
+		switch reply := reply.(type) {
+		case *redis.Subscription:
+			pending--
+		case *redis.Message:
+			early = append(early, reply)
+		}
+	}
+	return early, nil
+}
+
+// forward copies the messages received while subscribing, then those from
+// go-redis, into the subscriber until the go-redis channel is closed by
+// Unsubscribe.
+func (s *redisSubscription) forward(early []*redis.Message) {
+	defer s.wg.Done()
+	defer s.close()
+
+	for _, m := range early {
+		s.forwardOne(m)
+	}
+	for m := range s.pubsub.Channel() {
+		s.forwardOne(m)
+	}
+}
+
+func (s *redisSubscription) forwardOne(m *redis.Message) {
+	msg := Message{Channel: m.Channel, Pattern: m.Pattern, Payload: m.Payload}
+	_ = s.deliver(context.Background(), msg) // Only fails on ctx, which never ends
+}
+
+// Unsubscribe implements Subscription.
+func (s *redisSubscription) Unsubscribe() error {
+	s.broker.mu.Lock()
+	delete(s.broker.subs, s)
+	s.broker.mu.Unlock()
+
+	return s.stop()
+}
+
+func (s *redisSubscription) stop() error {
+	s.close() // Releases a blocked deliver in forward
+	err := s.pubsub.Close()
+	s.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("pubsub: unsubscribe: %w", err)
+	}
+	return nil
+}
+
+// Close implements Broker.
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	subs := r.subs
+	r.subs = make(map[*redisSubscription]struct{})
+	r.mu.Unlock()
+
+	var firstErr error
+	for sub := range subs {
+		if err := sub.stop(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,60 @@
+package pubsub
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedisBroker(t *testing.T) (*Redis, *redis.Client) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	broker := NewRedis(client, Options{Buffer: 1024, Policy: Block})
+	t.Cleanup(func() { _ = broker.Close() })
+	return broker, client
+}
+
+func TestRedisOverlappingSubscriptions(t *testing.T) {
+	broker, _ := newRedisBroker(t)
+
+	// miniredis delivers only the first matching pattern, unlike Redis, so
+	// one pattern is all this can check, and in no particular order
+	got := overlapping(t, broker, "orders:*", "orders:new")
+	slices.Sort(got)
+	if want := []string{"", "orders:*"}; !slices.Equal(got, want) {
+		t.Errorf("patterns = %q, want %q", got, want)
+	}
+}
+
+func TestSubscribeAllKeepsEarlyMessages(t *testing.T) {
+	m := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	// Leave a confirmation and a message unread on the connection, as when
+	// a message arrives before all confirmations are in
+	ps := client.Subscribe(ctx)
+	defer ps.Close()
+	if err := ps.Subscribe(ctx, "ticks"); err != nil {
+		t.Fatal(err)
+	}
+	for m.PubSubNumSub("ticks")["ticks"] == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	m.Publish("ticks", "early")
+
+	early, err := subscribeAll(ctx, ps, []string{"ticks"}, []string{"other:*"})
+	if err != nil {
+		t.Fatalf("subscribeAll: %v", err)
+	}
+	if len(early) != 1 || early[0].Payload != "early" {
+		t.Errorf("early = %v, want the message between the confirmations", early)
+	}
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"go-projects/clock"
+)
+
+// Memory is an in-process token bucket with the same semantics as
+// TokenBucket. It is meant for tests and single-instance programs; every
+// process keeps its own budget.
+//
+// A bucket that has refilled completely is the same as no bucket, so such
+// buckets are dropped once per refill time and the map stays as large as the
+// set of recently active keys.
+type Memory struct {
+	rate   Rate
+	burst  int
+	refill time.Duration // Time for an empty bucket to fill up
+	clock  clock.Clock
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemory creates an in-memory limiter. A burst below 1 defaults to
+// rate.Limit. It panics if rate is invalid.
+func NewMemory(rate Rate, burst int) *Memory {
+	rate.mustBeValid()
+	if burst < 1 {
+		burst = rate.Limit
+	}
+	return &Memory{
+		rate:    rate,
+		burst:   burst,
+		refill:  time.Duration(float64(rate.Period) * float64(burst) / float64(rate.Limit)),
+		clock:   clock.Real{},
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow implements Limiter.
+func (m *Memory) Allow(_ context.Context, key string) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	if now.Sub(m.lastSweep) >= m.refill {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(m.burst), last: now}
+		m.buckets[key] = b
+	}
+
+	perNano := float64(m.rate.Limit) / float64(m.rate.Period)
+	b.tokens = math.Min(float64(m.burst), b.tokens+float64(now.Sub(b.last))*perNano)
+	b.last = now
+
+	if b.tokens < 1 {
+		return Result{
+			Limit:      m.burst,
+			RetryAfter: time.Duration(math.Ceil((1 - b.tokens) / perNano)),
+		}, nil
+	}
+
+	b.tokens--
+	return Result{
+		Allowed:   true,
+		Limit:     m.burst,
+		Remaining: int(b.tokens),
+	}, nil
+}
+
+// sweep drops the buckets that have been idle long enough to be full again.
+func (m *Memory) sweep(now time.Time) {
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if now.Sub(b.last) >= m.refill {
+			delete(m.buckets, key)
+		}
+	}
+}
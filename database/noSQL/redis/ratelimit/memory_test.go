@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-projects/clock"
+)
+
+func newMemory(rate Rate, burst int) (*Memory, *clock.Fake) {
+	c := clock.NewFake(time.Unix(0, 0))
+	m := NewMemory(rate, burst)
+	m.clock = c
+	m.lastSweep = c.Now()
+	return m, c
+}
+
+func TestMemoryAllow(t *testing.T) {
+	m, c := newMemory(PerSecond(2), 3)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		allowed   bool
+		remaining int
+		retry     time.Duration
+	}{
+		{"burst 1", 0, true, 2, 0},
+		{"burst 2", 0, true, 1, 0},
+		{"burst 3", 0, true, 0, 0},
+		{"empty", 0, false, 0, 500 * time.Millisecond},
+		{"still empty", 200 * time.Millisecond, false, 0, 300 * time.Millisecond},
+		{"refilled one", 300 * time.Millisecond, true, 0, 0},
+		{"refilled to burst only", time.Hour, true, 2, 0},
+	}
+	for _, tt := range tests {
+		c.Advance(tt.advance)
+		res, err := m.Allow(ctx, "k")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		want := Result{Allowed: tt.allowed, Limit: 3, Remaining: tt.remaining, RetryAfter: tt.retry}
+		if res != want {
+			t.Errorf("%s: Allow = %+v, want %+v", tt.name, res, want)
+		}
+	}
+}
+
+func TestMemoryKeysAreIndependent(t *testing.T) {
+	m, _ := newMemory(PerMinute(1), 1)
+	ctx := context.Background()
+
+	if res, _ := m.Allow(ctx, "a"); !res.Allowed {
+		t.Fatal("first event of a denied")
+	}
+	if res, _ := m.Allow(ctx, "a"); res.Allowed {
+		t.Error("second event of a allowed")
+	}
+	if res, _ := m.Allow(ctx, "b"); !res.Allowed {
+		t.Error("b is limited by a's budget")
+	}
+}
+
+func TestMemoryEvictsIdleBuckets(t *testing.T) {
+	m, c := newMemory(PerSecond(10), 10) // Refills in one second
+	ctx := context.Background()
+
+	for i := range 1000 {
+		_, _ = m.Allow(ctx, fmt.Sprintf("ip-%d", i))
+	}
+	c.Advance(500 * time.Millisecond)
+	_, _ = m.Allow(ctx, "ip-0")
+	if n := len(m.buckets); n != 1000 {
+		t.Errorf("%d buckets before the refill time, want 1000", n)
+	}
+
+	c.Advance(600 * time.Millisecond)
+	_, _ = m.Allow(ctx, "new")
+	if n := len(m.buckets); n != 2 {
+		t.Errorf("%d buckets after the refill time, want ip-0 and new", n)
+	}
+}
+
+func TestInvalidRate(t *testing.T) {
+	for _, rate := range []Rate{{}, {Limit: 1}, {Period: time.Second}, {Limit: -1, Period: time.Second}, {Limit: 1, Period: time.Microsecond}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewMemory(%+v) did not panic", rate)
+				}
+			}()
+			NewMemory(rate, 0)
+		}()
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"go-projects/reqctx"
+)
+
+// KeyFunc extracts the rate-limit key from a request.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP limits per client IP taken from RemoteAddr.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Middleware rejects requests over the limit with 429 Too Many Requests and a
+// Retry-After header in whole seconds. Allowed requests get X-RateLimit-Limit
+// and X-RateLimit-Remaining headers.
+//
+// If the limiter itself fails (for example Redis is down) the request is let
+// through: an unavailable limiter should not take the whole API down with it.
+// Put it behind reqctx.Middleware so the warning logged in that case carries
+// the request ID.
+func Middleware(limiter Limiter, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := limiter.Allow(r.Context(), key(r))
+			if err != nil {
+				reqctx.Logger(r.Context()).Warn().Err(err).Msg("Rate limiter unavailable, letting request through")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+
+			if !res.Allowed {
+				retry := int(math.Ceil(res.RetryAfter.Seconds()))
+				if retry < 1 {
+					retry = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
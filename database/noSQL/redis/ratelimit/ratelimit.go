@@ -0,0 +1,69 @@
+// Package ratelimit provides rate limiters that run atomically on Redis, so
+// every instance of a service shares the same budget per key.
+//
+// Three algorithms are available:
+//
+//   - FixedWindow counts requests with INCR in a window that starts at the first hit.
+//   - SlidingLog keeps one sorted-set entry per request and counts the last window exactly.
+//   - TokenBucket refills tokens continuously and allows bursts up to the bucket size.
+//
+// The state of every key lives in Redis and changes inside a Lua script, so
+// application servers never see each other's half-done updates. SlidingLog
+// and TokenBucket read the clock with the Redis TIME command, and FixedWindow
+// relies on the key's expiry (PEXPIRE/PTTL), so clock skew between
+// application servers does not matter. Memory implements the same Limiter
+// interface without Redis.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Rate is Limit events per Period.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// mustBeValid panics unless r allows at least one event per millisecond or
+// longer, the granularity of the Redis expiry the limiters rely on.
+func (r Rate) mustBeValid() {
+	if r.Limit < 1 || r.Period < time.Millisecond {
+		panic(fmt.Sprintf("ratelimit: invalid rate %d per %v", r.Limit, r.Period))
+	}
+}
+
+// PerSecond returns a Rate of n events per second.
+func PerSecond(n int) Rate {
+	return Rate{Limit: n, Period: time.Second}
+}
+
+// PerMinute returns a Rate of n events per minute.
+func PerMinute(n int) Rate {
+	return Rate{Limit: n, Period: time.Minute}
+}
+
+// Result describes the decision for a single call to Allow.
+type Result struct {
+	// Allowed reports whether the event may proceed.
+	Allowed bool
+	// Limit is the configured number of events per period.
+	Limit int
+	// Remaining is how many more events are allowed right now.
+	Remaining int
+	// RetryAfter is how long to wait before the next event can be allowed.
+	// It is zero when Allowed is true.
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether an event for key may happen now.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// microseconds converts a duration returned by a script in microseconds.
+func microseconds(us int64) time.Duration {
+	return time.Duration(us) * time.Microsecond
+}
@@ -0,0 +1,189 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fixedWindowScript increments the counter of the current window and starts
+// the window on the first hit. Returns {count, ttl_ms}.
+var fixedWindowScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// slidingLogScript drops entries older than the window, then records the
+// request if the log still has room. Returns {allowed, remaining, retry_us}.
+var slidingLogScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, string.format("%.0f-%s", now, ARGV[3]))
+	redis.call("PEXPIRE", KEYS[1], math.ceil(window / 1000))
+	return {1, limit - count - 1, 0}
+end
+
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return {0, 0, tonumber(oldest[2]) + window - now}
+`)
+
+// tokenBucketScript refills the bucket for the time elapsed since the last
+// call and takes one token if available. Returns {allowed, remaining, retry_us}.
+var tokenBucketScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate / 1000) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// FixedWindow allows Rate.Limit events per Rate.Period. The window starts at
+// the first event for a key, so bursts of up to 2*Limit are possible across a
+// window boundary; use SlidingLog when that matters.
+type FixedWindow struct {
+	rdb    redis.Cmdable
+	prefix string
+	rate   Rate
+}
+
+// NewFixedWindow creates a fixed-window limiter storing counters under prefix.
+// It panics if rate is invalid.
+func NewFixedWindow(rdb redis.Cmdable, prefix string, rate Rate) *FixedWindow {
+	rate.mustBeValid()
+	return &FixedWindow{rdb: rdb, prefix: prefix, rate: rate}
+}
+
+// Allow implements Limiter.
+func (l *FixedWindow) Allow(ctx context.Context, key string) (Result, error) {
+	values, err := fixedWindowScript.Run(ctx, l.rdb, []string{l.prefix + ":" + key},
+		l.rate.Period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: fixed window %s: %w", key, err)
+	}
+
+	count, ttlMillis := int(values[0]), values[1]
+	if count > l.rate.Limit {
+		return Result{
+			Limit:      l.rate.Limit,
+			RetryAfter: time.Duration(ttlMillis) * time.Millisecond,
+		}, nil
+	}
+	return Result{
+		Allowed:   true,
+		Limit:     l.rate.Limit,
+		Remaining: l.rate.Limit - count,
+	}, nil
+}
+
+// SlidingLog allows Rate.Limit events in any Rate.Period interval. It stores
+// one sorted-set member per allowed event, so memory grows with Limit.
+type SlidingLog struct {
+	rdb    redis.Cmdable
+	prefix string
+	rate   Rate
+}
+
+// NewSlidingLog creates a sliding-window-log limiter storing logs under prefix.
+// It panics if rate is invalid.
+func NewSlidingLog(rdb redis.Cmdable, prefix string, rate Rate) *SlidingLog {
+	rate.mustBeValid()
+	return &SlidingLog{rdb: rdb, prefix: prefix, rate: rate}
+}
+
+// Allow implements Limiter.
+func (l *SlidingLog) Allow(ctx context.Context, key string) (Result, error) {
+	// The random suffix keeps members unique when two events share a microsecond.
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return Result{}, fmt.Errorf("ratelimit: sliding log %s: %w", key, err)
+	}
+
+	values, err := slidingLogScript.Run(ctx, l.rdb, []string{l.prefix + ":" + key},
+		l.rate.Period.Microseconds(), l.rate.Limit, hex.EncodeToString(suffix)).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: sliding log %s: %w", key, err)
+	}
+	return scriptResult(l.rate.Limit, values), nil
+}
+
+// TokenBucket refills Rate.Limit tokens per Rate.Period and holds at most
+// Burst tokens. Each event takes one token.
+type TokenBucket struct {
+	rdb    redis.Cmdable
+	prefix string
+	rate   Rate
+	burst  int
+}
+
+// NewTokenBucket creates a token-bucket limiter storing buckets under prefix.
+// A burst below 1 defaults to rate.Limit. It panics if rate is invalid.
+func NewTokenBucket(rdb redis.Cmdable, prefix string, rate Rate, burst int) *TokenBucket {
+	rate.mustBeValid()
+	if burst < 1 {
+		burst = rate.Limit
+	}
+	return &TokenBucket{rdb: rdb, prefix: prefix, rate: rate, burst: burst}
+}
+
+// Allow implements Limiter.
+func (l *TokenBucket) Allow(ctx context.Context, key string) (Result, error) {
+	tokensPerMicro := float64(l.rate.Limit) / float64(l.rate.Period.Microseconds())
+
+	values, err := tokenBucketScript.Run(ctx, l.rdb, []string{l.prefix + ":" + key},
+		tokensPerMicro, l.burst).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: token bucket %s: %w", key, err)
+	}
+	return scriptResult(l.burst, values), nil
+}
+
+// scriptResult decodes the {allowed, remaining, retry_us} reply shared by
+// the sliding log and token bucket scripts.
+func scriptResult(limit int, values []int64) Result {
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		RetryAfter: microseconds(values[2]),
+	}
+}
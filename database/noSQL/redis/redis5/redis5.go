@@ -3,13 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/signal"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"go-projects/lifecycle"
 )
 
 func main() {
@@ -17,7 +16,10 @@ func main() {
 	client := redis.NewClient(&redis.Options{
 		Addr: "localhost:6379",
 	})
-	ctx := context.Background()
+
+	// Root context is cancelled on Ctrl+C
+	app := lifecycle.New(lifecycle.Options{})
+	ctx := app.Context()
 
 	// Check Redis connection
 	_, err := client.Ping(ctx).Result()
@@ -31,7 +33,7 @@ func main() {
 	var mu sync.Mutex // Mutex for output synchronization
 
 	// Subscriber 1 - "Radio-1" (first listener)
-	go func() {
+	app.Go("radio-1", func(ctx context.Context) error {
 		pubsub := client.Subscribe(ctx, "notifications:alerts")
 		defer func(pubsub *redis.PubSub) {
 			err := pubsub.Close()
@@ -44,17 +46,20 @@ func main() {
 		for {
 			msg, err := pubsub.ReceiveMessage(ctx)
 			if err != nil {
-				fmt.Printf("❌ Radio-1 error: %v\n", err)
-				return
+				if ctx.Err() != nil {
+					fmt.Println("📻 Radio-1 stopped listening")
+					return nil
+				}
+				return fmt.Errorf("radio-1 receive: %w", err)
 			}
 			mu.Lock()
 			fmt.Printf("📻 Radio-1 received: %s\n", msg.Payload)
 			mu.Unlock()
 		}
-	}()
+	})
 
 	// Subscriber 2 - "Radio-2" (second listener)
-	go func() {
+	app.Go("radio-2", func(ctx context.Context) error {
 		pubsub := client.Subscribe(ctx, "notifications:alerts")
 		defer func(pubsub *redis.PubSub) {
 			err := pubsub.Close()
@@ -67,20 +72,23 @@ func main() {
 		for {
 			msg, err := pubsub.ReceiveMessage(ctx)
 			if err != nil {
-				fmt.Printf("❌ Radio-2 error: %v\n", err)
-				return
+				if ctx.Err() != nil {
+					fmt.Println("📻 Radio-2 stopped listening")
+					return nil
+				}
+				return fmt.Errorf("radio-2 receive: %w", err)
 			}
 			mu.Lock()
 			fmt.Printf("📻 Radio-2 received: %s\n", msg.Payload)
 			mu.Unlock()
 		}
-	}()
+	})
 
 	// Give subscribers time to connect
 	time.Sleep(1 * time.Second)
 
 	// Publisher - "DJ" (sends messages)
-	go func() {
+	app.Go("dj", func(ctx context.Context) error {
 		time.Sleep(500 * time.Millisecond) // Wait for subscribers to be ready
 
 		messages := []string{
@@ -99,28 +107,37 @@ func main() {
 			// Then send the message
 			err := client.Publish(ctx, "notifications:alerts", msg).Err()
 			if err != nil {
-				fmt.Printf("❌ DJ broadcast error: %v\n", err)
-				return
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("dj broadcast: %w", err)
 			}
 
-			time.Sleep(1 * time.Second) // Give subscribers time to receive and print
+			// Give subscribers time to receive and print
+			select {
+			case <-time.After(1 * time.Second):
+			case <-ctx.Done():
+				fmt.Println("🎤 DJ stopped broadcasting")
+				return nil
+			}
 		}
 		fmt.Println("✅ Broadcast completed!")
-	}()
-
-	// Graceful shutdown with Ctrl+C
-	fmt.Println("\n⏳ Program is running... Press Ctrl+C to exit")
+		return nil
+	})
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	// Graceful shutdown with Ctrl+C: subscribers stop first, then the client is closed
+	app.OnShutdown("redis client", func(ctx context.Context) error {
+		if err := client.Close(); err != nil {
+			return err
+		}
+		fmt.Println("✅ Redis client closed")
+		return nil
+	})
 
-	fmt.Println("\n👋 Shutting down...")
-	err2 := client.Close()
-	if err2 != nil {
-		fmt.Printf("❌ Error closing Redis client: %v\n", err)
+	fmt.Println("\n⏳ Program is running... Press Ctrl+C to exit")
+	if err := app.Wait(); err != nil {
+		fmt.Printf("❌ Shutdown error: %v\n", err)
 		return
 	}
-	fmt.Println("✅ Redis client closed")
 	fmt.Println("👋 Goodbye!")
 }
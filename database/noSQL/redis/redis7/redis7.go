@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"redis/lock"
+)
+
+// newClient gives every "instance" its own connection, like separate processes would have.
+func newClient() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+		DB:   0,
+	})
+}
+
+// lockDemo runs three instances that all want to update the same report.
+// Only one of them can hold the lock at a time, and each holder gets a larger fencing token.
+func lockDemo(ctx context.Context) {
+	fmt.Println("\n--- DISTRIBUTED LOCK ---")
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+
+			client := newClient()
+			defer func(client *redis.Client) {
+				err := client.Close()
+				if err != nil {
+					fmt.Printf("Warning: Error closing Redis: %v\n", err)
+				}
+			}(client)
+
+			mutex := lock.NewMutex(lock.New(client, "lock:daily_report", lock.Options{TTL: 2 * time.Second}))
+
+			fmt.Printf("⏳ Instance %d: waiting for lock...\n", id)
+			if err := mutex.LockContext(ctx); err != nil {
+				fmt.Printf("❌ Instance %d: failed to lock: %v\n", id, err)
+				return
+			}
+			fmt.Printf("🔒 Instance %d: got lock (fencing token %d)\n", id, mutex.Token())
+			time.Sleep(500 * time.Millisecond) // Simulate work
+			mutex.Unlock()
+			fmt.Printf("🔓 Instance %d: released lock\n", id)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// electionDemo starts three candidates. The leader resigns after a short
+// term and one of the others takes over.
+func electionDemo(ctx context.Context) {
+	fmt.Println("\n--- LEADER ELECTION ---")
+
+	ctx, cancel := context.WithTimeout(ctx, 4*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"node-a", "node-b", "node-c"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+
+			client := newClient()
+			defer func(client *redis.Client) {
+				err := client.Close()
+				if err != nil {
+					fmt.Printf("Warning: Error closing Redis: %v\n", err)
+				}
+			}(client)
+
+			election := lock.NewElection(client, "scheduler", id, lock.Options{TTL: 2 * time.Second})
+			_ = election.Run(ctx, func(termCtx context.Context) {
+				fmt.Printf("👑 %s is the leader (term %d)\n", id, election.Token())
+				select {
+				case <-time.After(1 * time.Second):
+					fmt.Printf("🏳️ %s resigns\n", id)
+				case <-termCtx.Done():
+					fmt.Printf("⚠️ %s lost leadership\n", id)
+				}
+			})
+		}(id)
+	}
+	wg.Wait()
+}
+
+func main() {
+	fmt.Println("🚀 Redis Distributed Lock Demo")
+
+	client := newClient()
+	ctx := context.Background()
+
+	// Test connection
+	if err := client.Ping(ctx).Err(); err != nil {
+		fmt.Printf("❌ Redis connection failed: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Connected to Redis!")
+	if err := client.Close(); err != nil {
+		fmt.Printf("Warning: Error closing Redis: %v\n", err)
+	}
+
+	lockDemo(ctx)
+	electionDemo(ctx)
+
+	fmt.Println("\n🎊 Demo completed!")
+}
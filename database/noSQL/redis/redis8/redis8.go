@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+	"github.com/redis/go-redis/v9"
+
+	"go-projects/reqctx"
+	"redis/ratelimit"
+)
+
+// hammer sends n requests for the same key and prints every decision.
+func hammer(ctx context.Context, name string, limiter ratelimit.Limiter, n int) {
+	fmt.Printf("\n--- %s ---\n", name)
+	for i := 1; i <= n; i++ {
+		res, err := limiter.Allow(ctx, "user:42")
+		if err != nil {
+			fmt.Printf("❌ Request %d: limiter error: %v\n", i, err)
+			return
+		}
+		if res.Allowed {
+			fmt.Printf("✅ Request %d: allowed (remaining %d)\n", i, res.Remaining)
+		} else {
+			fmt.Printf("⛔ Request %d: rejected, retry after %v\n", i, res.RetryAfter.Round(time.Millisecond))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// middlewareDemo puts the token bucket in front of an HTTP handler.
+func middlewareDemo(limiter ratelimit.Limiter) {
+	fmt.Println("\n--- HTTP MIDDLEWARE ---")
+
+	// reqctx.Middleware runs first, so everything after it logs with the request ID
+	handler := reqctx.Middleware(ratelimit.Middleware(limiter, ratelimit.KeyByIP)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqctx.Logger(r.Context()).Info().Str("path", r.URL.Path).Msg("Handling request")
+			_, _ = fmt.Fprintln(w, "Hello!")
+		})))
+
+	for i := 1; i <= 4; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		fmt.Printf("🌐 GET /api/orders -> %d (Retry-After: %q, %s: %s)\n",
+			rec.Code, rec.Header().Get("Retry-After"), reqctx.HeaderRequestID, rec.Header().Get(reqctx.HeaderRequestID))
+	}
+}
+
+func main() {
+	zerolog.Init()
+	fmt.Println("🚦 Redis Rate Limiter Demo")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+		DB:   0,
+	})
+	defer func(client *redis.Client) {
+		err := client.Close()
+		if err != nil {
+			fmt.Printf("Warning: Error closing Redis: %v\n", err)
+		}
+	}(client)
+
+	ctx := context.Background()
+
+	// Test connection
+	if err := client.Ping(ctx).Err(); err != nil {
+		fmt.Printf("❌ Redis connection failed: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Connected to Redis!")
+
+	rate := ratelimit.PerSecond(3)
+	hammer(ctx, "FIXED WINDOW (3 per second)", ratelimit.NewFixedWindow(client, "rl:fixed", rate), 8)
+	hammer(ctx, "SLIDING LOG (3 per second)", ratelimit.NewSlidingLog(client, "rl:sliding", rate), 8)
+	hammer(ctx, "TOKEN BUCKET (3 per second, burst 5)", ratelimit.NewTokenBucket(client, "rl:bucket", rate, 5), 8)
+	hammer(ctx, "IN-MEMORY TOKEN BUCKET (3 per second, burst 5)", ratelimit.NewMemory(rate, 5), 8)
+
+	middlewareDemo(ratelimit.NewTokenBucket(client, "rl:http", ratelimit.PerMinute(2), 2))
+
+	fmt.Println("\n🎊 Rate limiter demo completed!")
+}
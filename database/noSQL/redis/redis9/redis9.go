@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-projects/lazy"
+	"redis/pubsub"
+	"redis/redisconn"
+)
+
+// radio is Radio-1/Radio-2 of redis5, written against pubsub.Broker so it
+// does not care whether Redis or the in-memory broker is behind it.
+func radio(ctx context.Context, name string, broker pubsub.Broker, ready, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	sub, err := broker.Subscribe(ctx, "notifications:*")
+	ready.Done()
+	if err != nil {
+		fmt.Printf("❌ %s cannot subscribe: %v\n", name, err)
+		return
+	}
+	defer func(sub pubsub.Subscription) {
+		err := sub.Unsubscribe()
+		if err != nil {
+			fmt.Printf("❌ %s unsubscribe error: %v\n", name, err)
+		}
+	}(sub)
+
+	fmt.Printf("📻 %s is listening to notifications:*\n", name)
+	for {
+		select {
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return
+			}
+			fmt.Printf("📻 %s received on %s: %s\n", name, msg.Channel, msg.Payload)
+		case <-ctx.Done():
+			fmt.Printf("📻 %s stopped listening (%d dropped)\n", name, sub.Dropped())
+			return
+		}
+	}
+}
+
+// newBroker connects to Redis, or falls back to the in-memory broker.
+func newBroker(ctx context.Context, useMemory bool) (pubsub.Broker, func()) {
+	opts := pubsub.Options{Buffer: 16, Policy: pubsub.DropOldest}
+	if !useMemory {
+		cfg, err := redisconn.ConfigFromEnv()
+		if err != nil {
+			fmt.Printf("⚠️ %v, using the defaults\n", err)
+		}
+		conn := redisconn.Lazy(cfg.Options(), lazy.Options{MaxAttempts: 3})
+		client, err := conn.Get(ctx)
+		if err == nil {
+			fmt.Println("✅ Using Redis Pub/Sub")
+			return pubsub.NewRedis(client, opts), func() { _ = client.Close() }
+		}
+		fmt.Printf("⚠️ Redis not available (%v)\n", err)
+	}
+	fmt.Println("✅ Using the in-memory broker")
+	return pubsub.NewMemory(opts), func() {}
+}
+
+func main() {
+	useMemory := flag.Bool("memory", false, "use the in-memory broker even if Redis is running")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker, closeClient := newBroker(ctx, *useMemory)
+	defer closeClient()
+
+	var wg, ready sync.WaitGroup
+	for _, name := range []string{"Radio-1", "Radio-2"} {
+		wg.Add(1)
+		ready.Add(1)
+		go radio(ctx, name, broker, &ready, &wg)
+	}
+	ready.Wait()
+
+	messages := []struct{ channel, payload string }{
+		{"notifications:alerts", "Server CPU usage is high"},
+		{"notifications:users", "New user registration: john_doe"},
+		{"notifications:database", "Database backup completed successfully"},
+		{"orders:new", "Nobody listens to this one"},
+	}
+	fmt.Println("\n🚀 Starting broadcast...")
+	for _, m := range messages {
+		fmt.Printf("🎤 DJ broadcasting on %s: %s\n", m.channel, m.payload)
+		if err := broker.Publish(ctx, m.channel, m.payload); err != nil {
+			fmt.Printf("❌ Broadcast error: %v\n", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	if err := broker.Close(); err != nil {
+		fmt.Printf("❌ Broker close error: %v\n", err)
+	}
+	fmt.Println("👋 Goodbye!")
+}
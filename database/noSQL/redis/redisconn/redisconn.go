@@ -0,0 +1,142 @@
+// Package redisconn connects to Redis on first use.
+//
+// redis.NewClient does not connect, so a server that is down only shows up
+// on the first command. Lazy pings the server before handing out the
+// client and retries with backoff while it can't reach it.
+//
+// Config holds the pool settings, which the demos used to leave at the
+// go-redis defaults or hard-code.
+package redisconn
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go-projects/envconf"
+	"go-projects/errs"
+	"go-projects/lazy"
+	"go-projects/poolstats"
+)
+
+// Addr is the Redis server the demos use.
+const Addr = "localhost:6379"
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvAddr            = "REDIS_ADDR"
+	EnvPassword        = "REDIS_PASSWORD"
+	EnvPoolSize        = "REDIS_POOL_SIZE"
+	EnvMinIdleConns    = "REDIS_MIN_IDLE_CONNS"
+	EnvPoolTimeout     = "REDIS_POOL_TIMEOUT" // A time.Duration, like "2s"
+	EnvConnMaxIdleTime = "REDIS_CONN_MAX_IDLE_TIME"
+	EnvConnMaxLifetime = "REDIS_CONN_MAX_LIFETIME"
+)
+
+// Config configures the client and its connection pool. Zero values keep
+// the go-redis defaults.
+type Config struct {
+	// Addr is host:port of the server. Default: Addr.
+	Addr     string
+	Password string
+	DB       int
+	// PoolSize caps the open connections. Commands beyond it wait for a
+	// free connection. Default: 10 per GOMAXPROCS.
+	PoolSize int
+	// MinIdleConns keeps connections open ahead of a burst.
+	MinIdleConns int
+	// PoolTimeout bounds the wait for a free connection; a command that
+	// waits longer fails. Default: ReadTimeout + 1s.
+	PoolTimeout     time.Duration
+	ConnMaxIdleTime time.Duration
+	ConnMaxLifetime time.Duration
+}
+
+// ConfigFromEnv returns a Config set from the REDIS_* environment
+// variables. Unset variables keep their defaults.
+func ConfigFromEnv() (Config, error) {
+	c := Config{Addr: os.Getenv(EnvAddr), Password: os.Getenv(EnvPassword)}
+	var err error
+	if c.PoolSize, err = envconf.Int(EnvPoolSize); err != nil {
+		return c, fmt.Errorf("redisconn: %w", err)
+	}
+	if c.MinIdleConns, err = envconf.Int(EnvMinIdleConns); err != nil {
+		return c, fmt.Errorf("redisconn: %w", err)
+	}
+	if c.PoolTimeout, err = envconf.Duration(EnvPoolTimeout); err != nil {
+		return c, fmt.Errorf("redisconn: %w", err)
+	}
+	if c.ConnMaxIdleTime, err = envconf.Duration(EnvConnMaxIdleTime); err != nil {
+		return c, fmt.Errorf("redisconn: %w", err)
+	}
+	if c.ConnMaxLifetime, err = envconf.Duration(EnvConnMaxLifetime); err != nil {
+		return c, fmt.Errorf("redisconn: %w", err)
+	}
+	return c, nil
+}
+
+// Options returns the go-redis options for c.
+func (c Config) Options() *redis.Options {
+	if c.Addr == "" {
+		c.Addr = Addr
+	}
+	return &redis.Options{
+		Addr:            c.Addr,
+		Password:        c.Password,
+		DB:              c.DB,
+		PoolSize:        c.PoolSize,
+		MinIdleConns:    c.MinIdleConns,
+		PoolTimeout:     c.PoolTimeout,
+		ConnMaxIdleTime: c.ConnMaxIdleTime,
+		ConnMaxLifetime: c.ConnMaxLifetime,
+	}
+}
+
+// Stats returns a poolstats.Source for the pool of client.
+func Stats(client *redis.Client) poolstats.Source {
+	maxOpen := client.Options().PoolSize
+	return func() poolstats.Stats {
+		s := client.PoolStats()
+		return poolstats.Stats{
+			MaxOpen:      maxOpen,
+			Open:         int(s.TotalConns),
+			InUse:        int(s.TotalConns) - int(s.IdleConns),
+			Idle:         int(s.IdleConns),
+			WaitCount:    int64(s.WaitCount),
+			WaitDuration: time.Duration(s.WaitDurationNs),
+			Timeouts:     int64(s.Timeouts),
+			Closed:       int64(s.StaleConns),
+		}
+	}
+}
+
+// Lazy returns a Lazy that creates and pings a client on the first Get.
+// After a lost connection, Reset it and close the old client it returns.
+func Lazy(opts *redis.Options, lazyOpts lazy.Options) *lazy.Lazy[*redis.Client] {
+	return lazy.New(func(ctx context.Context) (*redis.Client, error) {
+		return Open(ctx, opts)
+	}, lazyOpts)
+}
+
+// Open creates a client and checks that the server answers.
+func Open(ctx context.Context, opts *redis.Options) (*redis.Client, error) {
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("redisconn: ping %s: %w", opts.Addr, classify(err))
+	}
+	return client, nil
+}
+
+// classify marks authentication errors, which retrying won't fix.
+func classify(err error) error {
+	msg := err.Error()
+	if strings.HasPrefix(msg, "WRONGPASS") || strings.HasPrefix(msg, "NOAUTH") {
+		return errs.MarkPermanent(err)
+	}
+	return err
+}
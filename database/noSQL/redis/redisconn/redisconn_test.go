@@ -0,0 +1,108 @@
+package redisconn
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"go-projects/errs"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{name: "unset", want: Config{}},
+		{
+			name: "all set",
+			env: map[string]string{
+				EnvAddr:            "redis:6380",
+				EnvPassword:        "secret",
+				EnvPoolSize:        "20",
+				EnvMinIdleConns:    "2",
+				EnvPoolTimeout:     "2s",
+				EnvConnMaxIdleTime: "1m",
+				EnvConnMaxLifetime: "1h",
+			},
+			want: Config{
+				Addr: "redis:6380", Password: "secret", PoolSize: 20, MinIdleConns: 2,
+				PoolTimeout: 2 * time.Second, ConnMaxIdleTime: time.Minute, ConnMaxLifetime: time.Hour,
+			},
+		},
+		{name: "bad number", env: map[string]string{EnvPoolSize: "ten"}, wantErr: true},
+		{name: "bad duration", env: map[string]string{EnvPoolTimeout: "2"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{EnvAddr, EnvPassword, EnvPoolSize, EnvMinIdleConns, EnvPoolTimeout, EnvConnMaxIdleTime, EnvConnMaxLifetime} {
+				v, ok := tt.env[name]
+				t.Setenv(name, v) // Restores the variable after the test
+				if !ok {
+					_ = os.Unsetenv(name)
+				}
+			}
+
+			got, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv error = %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ConfigFromEnv = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		addr string
+	}{
+		{"default address", Config{}, Addr},
+		{"own address", Config{Addr: "redis:6380"}, "redis:6380"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Password, tt.cfg.DB, tt.cfg.PoolSize, tt.cfg.MinIdleConns = "secret", 3, 20, 2
+			tt.cfg.PoolTimeout, tt.cfg.ConnMaxIdleTime, tt.cfg.ConnMaxLifetime = time.Second, time.Minute, time.Hour
+
+			o := tt.cfg.Options()
+			if o.Addr != tt.addr {
+				t.Errorf("Addr = %q, want %q", o.Addr, tt.addr)
+			}
+			if o.Password != "secret" || o.DB != 3 || o.PoolSize != 20 || o.MinIdleConns != 2 ||
+				o.PoolTimeout != time.Second || o.ConnMaxIdleTime != time.Minute || o.ConnMaxLifetime != time.Hour {
+				t.Errorf("Options = %+v, want the fields of %+v", o, tt.cfg)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"wrong password", errors.New("WRONGPASS invalid username-password pair"), true},
+		{"no password", errors.New("NOAUTH Authentication required."), true},
+		{"network", errors.New("dial tcp: connection refused"), false},
+		{"loading", errors.New("LOADING Redis is loading the dataset in memory"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classify(tt.err)
+			if got := errs.IsPermanent(err); got != tt.permanent {
+				t.Errorf("IsPermanent = %t, want %t", got, tt.permanent)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classify lost the original error %v", tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Package envconf reads typed settings from environment variables.
+//
+// Every reader returns the zero value for an unset variable, so a Config
+// filled from the environment keeps its defaults, and an error naming the
+// variable for one that does not parse.
+package envconf
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Int returns the integer in the variable name.
+func Int(name string) (int, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return n, nil
+}
+
+// Duration returns the time.Duration in the variable name, like "30s".
+func Duration(name string) (time.Duration, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,58 @@
+package envconf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		set     bool
+		want    int
+		wantErr bool
+	}{
+		{"unset", "", false, 0, false},
+		{"number", "42", true, 42, false},
+		{"negative", "-1", true, -1, false},
+		{"empty", "", true, 0, true},
+		{"not a number", "ten", true, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set {
+				t.Setenv("ENVCONF_TEST", tt.value)
+			}
+			got, err := Int("ENVCONF_TEST")
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("Int = %d, %v; want %d, error %t", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		set     bool
+		want    time.Duration
+		wantErr bool
+	}{
+		{"unset", "", false, 0, false},
+		{"duration", "1m30s", true, 90 * time.Second, false},
+		{"bare number", "30", true, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set {
+				t.Setenv("ENVCONF_TEST", tt.value)
+			}
+			got, err := Duration("ENVCONF_TEST")
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("Duration = %v, %v; want %v, error %t", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
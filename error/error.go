@@ -6,6 +6,8 @@ import (
 	"math/rand"
 	"sync"
 	"time"
+
+	"go-projects/errs"
 )
 
 type workerResult struct {
@@ -20,8 +22,13 @@ func worker(id int, results chan<- workerResult, wg *sync.WaitGroup) {
 	time.Sleep(time.Duration(rand.Intn(500)+100) * time.Millisecond)
 
 	if rand.Intn(100) < 30 {
-		err := errors.New(fmt.Sprintf("Worker %d: Failed due to random error", id))
-		results <- workerResult{id: id, err: err}
+		var err error
+		if rand.Intn(2) == 0 {
+			err = errs.MarkRetryable(errors.New("service temporarily unavailable"))
+		} else {
+			err = errs.MarkPermanent(errors.New("invalid input data"))
+		}
+		results <- workerResult{id: id, err: errs.WithTask(err, id, 1)}
 		fmt.Printf("Worker %d: Task failed.\n", id)
 		return
 	}
@@ -53,13 +60,13 @@ func main() {
 	}()
 
 	var successfulResults []string
-	var failedResults []error
+	var failedResults errs.Collector
 
 	for res := range resultsChan {
 		if res.err != nil {
 			fmt.Printf("Main: Received error from Worker %d: %v\n",
 				res.id, res.err)
-			failedResults = append(failedResults, res.err)
+			failedResults.Add(res.err)
 		} else {
 			fmt.Printf("Main: Received successful result from Worker %d:"+
 				" %s\n", res.id, res.value)
@@ -72,9 +79,15 @@ func main() {
 	for _, val := range successfulResults {
 		fmt.Printf("  - %s\n", val)
 	}
-	fmt.Printf("Counts of Errors: %d\n", len(failedResults))
-	for _, err := range failedResults {
-		fmt.Printf("  - %v\n", err)
+	fmt.Printf("Counts of Errors: %d\n", failedResults.Len())
+	if multi, ok := failedResults.Err().(*errs.Multi); ok {
+		for class, list := range multi.ByClass() {
+			fmt.Printf("  %s:\n", class)
+			for _, err := range list {
+				fmt.Printf("    - %v\n", err)
+			}
+		}
+		fmt.Printf("Retry the batch: %v\n", errs.IsRetryable(multi))
 	}
 
 	fmt.Println("\nDemonstration of sync.Error completed.")
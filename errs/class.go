@@ -0,0 +1,144 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// Class is what a caller should do about an error.
+type Class int
+
+// Classes ordered by severity; see Classify for how a Multi is classified.
+const (
+	// Unknown errors carry no classification.
+	Unknown Class = iota
+	// Retryable errors are transient: trying again may succeed.
+	Retryable
+	// Timeout errors ran out of time. They are retryable, with a new deadline.
+	Timeout
+	// Canceled errors were stopped on purpose and should not be retried.
+	Canceled
+	// Permanent errors will fail the same way every time.
+	Permanent
+)
+
+func (c Class) String() string {
+	switch c {
+	case Retryable:
+		return "retryable"
+	case Timeout:
+		return "timeout"
+	case Canceled:
+		return "canceled"
+	case Permanent:
+		return "permanent"
+	default:
+		return "unknown"
+	}
+}
+
+// severity orders classes for Multi: the most severe member wins.
+func (c Class) severity() int {
+	switch c {
+	case Retryable:
+		return 1
+	case Timeout:
+		return 2
+	case Canceled:
+		return 3
+	case Unknown:
+		return 4
+	default: // Permanent
+		return 5
+	}
+}
+
+type classified struct {
+	err   error
+	class Class
+}
+
+func (e *classified) Error() string { return e.err.Error() }
+func (e *classified) Unwrap() error { return e.err }
+
+// Mark classifies err explicitly. It returns nil for a nil err.
+func Mark(err error, class Class) error {
+	if err == nil {
+		return nil
+	}
+	return &classified{err: err, class: class}
+}
+
+// MarkRetryable is Mark(err, Retryable).
+func MarkRetryable(err error) error { return Mark(err, Retryable) }
+
+// MarkPermanent is Mark(err, Permanent).
+func MarkPermanent(err error) error { return Mark(err, Permanent) }
+
+// Classify walks the chain of err and returns the first class it finds:
+//
+//   - an explicit Mark;
+//   - Timeout for context.DeadlineExceeded, os.ErrDeadlineExceeded and errors
+//     with a Timeout() bool method returning true, such as net.Error;
+//   - Canceled for context.Canceled;
+//   - Retryable for errors with a Temporary() bool method returning true.
+//
+// A Multi, or any error with Unwrap() []error, gets the most severe class
+// of its members in the order Retryable, Timeout, Canceled, Unknown,
+// Permanent, so it is only retried if every member may be retried.
+func Classify(err error) Class {
+	for err != nil {
+		switch e := err.(type) {
+		case *classified:
+			return e.class
+		case interface{ Unwrap() []error }:
+			return worst(e.Unwrap())
+		}
+
+		switch {
+		case err == context.DeadlineExceeded || err == os.ErrDeadlineExceeded:
+			return Timeout
+		case err == context.Canceled:
+			return Canceled
+		}
+		if t, ok := err.(interface{ Timeout() bool }); ok && t.Timeout() {
+			return Timeout
+		}
+		if t, ok := err.(interface{ Temporary() bool }); ok && t.Temporary() {
+			return Retryable
+		}
+		err = errors.Unwrap(err)
+	}
+	return Unknown
+}
+
+func worst(errs []error) Class {
+	result, found := Unknown, false
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		c := Classify(err)
+		if !found || c.severity() > result.severity() {
+			result, found = c, true
+		}
+	}
+	return result
+}
+
+// IsRetryable reports whether trying again may succeed: the class is
+// Retryable or Timeout.
+func IsRetryable(err error) bool {
+	c := Classify(err)
+	return c == Retryable || c == Timeout
+}
+
+// IsTimeout reports whether err is classified as Timeout.
+func IsTimeout(err error) bool { return Classify(err) == Timeout }
+
+// IsCanceled reports whether err is classified as Canceled.
+func IsCanceled(err error) bool { return Classify(err) == Canceled }
+
+// IsPermanent reports whether err is classified as Permanent.
+func IsPermanent(err error) bool { return Classify(err) == Permanent }
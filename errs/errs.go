@@ -0,0 +1,105 @@
+// Package errs collects, annotates and classifies errors from concurrent work.
+//
+// Multi holds the errors of many tasks and, like errors.Join, lets errors.Is
+// and errors.As look at every member. WithMeta attaches the task ID and
+// attempt number to an error. Classify sorts an error into a Class, so a
+// worker pool or scheduler can decide between retrying, giving up and
+// reporting a timeout without matching error strings.
+package errs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Multi is a list of errors that is itself an error. errors.Is and errors.As
+// match it if they match any member.
+type Multi struct {
+	errs []error
+}
+
+// Join returns a *Multi of the non-nil errors, or nil if there are none.
+// Members that are *Multi themselves are flattened.
+func Join(errs ...error) error {
+	var m Multi
+	for _, err := range errs {
+		m.add(err)
+	}
+	if len(m.errs) == 0 {
+		return nil
+	}
+	return &m
+}
+
+func (m *Multi) add(err error) {
+	if nested, ok := err.(*Multi); ok {
+		m.errs = append(m.errs, nested.errs...)
+		return
+	}
+	if err != nil {
+		m.errs = append(m.errs, err)
+	}
+}
+
+// Error lists the members one per line.
+func (m *Multi) Error() string {
+	if len(m.errs) == 1 {
+		return m.errs[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors occurred:", len(m.errs))
+	for _, err := range m.errs {
+		b.WriteString("\n\t* ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the members, which is what errors.Is and errors.As walk.
+func (m *Multi) Unwrap() []error {
+	return m.errs
+}
+
+// Errors returns a copy of the members.
+func (m *Multi) Errors() []error {
+	return append([]error(nil), m.errs...)
+}
+
+// ByClass groups the members by Classify.
+func (m *Multi) ByClass() map[Class][]error {
+	groups := make(map[Class][]error)
+	for _, err := range m.errs {
+		c := Classify(err)
+		groups[c] = append(groups[c], err)
+	}
+	return groups
+}
+
+// Collector gathers errors from several goroutines. The zero value is ready
+// to use.
+type Collector struct {
+	mu sync.Mutex
+	m  Multi
+}
+
+// Add records err; nil is ignored.
+func (c *Collector) Add(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m.add(err)
+}
+
+// Len returns the number of recorded errors.
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.m.errs)
+}
+
+// Err returns the recorded errors as a *Multi, or nil if there are none.
+func (c *Collector) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Join(c.m.errs...)
+}
@@ -0,0 +1,153 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	errBoom   = errors.New("boom")
+	errBroken = errors.New("broken")
+)
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{"nil", nil, Unknown},
+		{"plain", errBoom, Unknown},
+		{"deadline", context.DeadlineExceeded, Timeout},
+		{"wrapped deadline", fmt.Errorf("task 1: %w", context.DeadlineExceeded), Timeout},
+		{"os deadline", os.ErrDeadlineExceeded, Timeout},
+		{"net timeout", &net.OpError{Op: "dial", Err: timeoutErr{}}, Timeout},
+		{"canceled", context.Canceled, Canceled},
+		{"marked retryable", MarkRetryable(errBoom), Retryable},
+		{"marked permanent", MarkPermanent(errBoom), Permanent},
+		{"mark wins over cause", MarkPermanent(context.DeadlineExceeded), Permanent},
+		{"outer mark wins", fmt.Errorf("outer: %w", Mark(MarkRetryable(errBoom), Permanent)), Permanent},
+		{"through meta", WithTask(context.Canceled, 3, 1), Canceled},
+		{"multi all retryable", Join(MarkRetryable(errBoom), context.DeadlineExceeded), Timeout},
+		{"multi with permanent", Join(MarkRetryable(errBoom), MarkPermanent(errBroken)), Permanent},
+		{"multi with unknown", Join(MarkRetryable(errBoom), errBroken), Unknown},
+		{"errors.Join", errors.Join(context.Canceled, MarkRetryable(errBoom)), Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiIsAndAs(t *testing.T) {
+	err := Join(
+		WithTask(errBoom, 1, 1),
+		nil,
+		Join(fmt.Errorf("wrapped: %w", context.DeadlineExceeded), WithTask(errBroken, 7, 3)),
+	)
+
+	multi := err.(*Multi)
+	if n := len(multi.Errors()); n != 3 {
+		t.Fatalf("Join kept %d errors, want 3 after flattening and dropping nil", n)
+	}
+
+	for _, target := range []error{errBoom, errBroken, context.DeadlineExceeded} {
+		if !errors.Is(err, target) {
+			t.Errorf("errors.Is(err, %v) = false", target)
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Error("errors.Is(err, context.Canceled) = true")
+	}
+
+	var me *MetaError
+	if !errors.As(err, &me) || me.Meta.TaskID != "1" {
+		t.Errorf("errors.As found %+v, want task 1", me)
+	}
+
+	groups := multi.ByClass()
+	if len(groups[Timeout]) != 1 || len(groups[Unknown]) != 2 {
+		t.Errorf("ByClass = %v", groups)
+	}
+}
+
+func TestJoinNil(t *testing.T) {
+	if err := Join(nil, nil); err != nil {
+		t.Errorf("Join(nil, nil) = %v, want nil", err)
+	}
+	var c Collector
+	if err := c.Err(); err != nil {
+		t.Errorf("empty Collector.Err() = %v, want nil", err)
+	}
+}
+
+func TestMeta(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantMsg string
+		wantOK  bool
+	}{
+		{"task and attempt", WithTask(errBoom, 3, 2), "task 3 attempt 2: boom", true},
+		{"fields", WithMeta(errBoom, Meta{TaskID: "a", Fields: map[string]string{"worker": "2", "queue": "q"}}), "task a queue=q worker=2: boom", true},
+		{"wrapped", fmt.Errorf("run: %w", WithTask(errBoom, 3, 0)), "run: task 3: boom", true},
+		{"no meta", errBoom, "boom", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", tt.err.Error(), tt.wantMsg)
+			}
+			if _, ok := MetaOf(tt.err); ok != tt.wantOK {
+				t.Errorf("MetaOf ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !errors.Is(tt.err, errBoom) {
+				t.Error("errors.Is lost the cause")
+			}
+		})
+	}
+	if WithTask(nil, 1, 1) != nil {
+		t.Error("WithTask(nil) != nil")
+	}
+}
+
+func TestCollectorConcurrent(t *testing.T) {
+	var c Collector
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				c.Add(WithTask(errBoom, i, 1))
+			} else {
+				c.Add(nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c.Len() != 25 {
+		t.Errorf("Len = %d, want 25", c.Len())
+	}
+	if msg := c.Err().Error(); !strings.HasPrefix(msg, "25 errors occurred:") {
+		t.Errorf("Error() = %q", msg)
+	}
+}
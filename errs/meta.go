@@ -0,0 +1,69 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Meta describes where an error happened.
+type Meta struct {
+	TaskID  string
+	Attempt int // Zero if unknown
+	Fields  map[string]string
+}
+
+// MetaError is an error annotated with Meta.
+type MetaError struct {
+	Err  error
+	Meta Meta
+}
+
+// WithMeta annotates err with meta. It returns nil for a nil err.
+func WithMeta(err error, meta Meta) error {
+	if err == nil {
+		return nil
+	}
+	return &MetaError{Err: err, Meta: meta}
+}
+
+// WithTask is WithMeta for the common case of a task ID and attempt.
+func WithTask(err error, taskID any, attempt int) error {
+	return WithMeta(err, Meta{TaskID: fmt.Sprint(taskID), Attempt: attempt})
+}
+
+// MetaOf returns the metadata of the outermost MetaError in err's chain.
+func MetaOf(err error) (Meta, bool) {
+	var me *MetaError
+	if errors.As(err, &me) {
+		return me.Meta, true
+	}
+	return Meta{}, false
+}
+
+func (e *MetaError) Error() string {
+	var parts []string
+	if e.Meta.TaskID != "" {
+		parts = append(parts, "task "+e.Meta.TaskID)
+	}
+	if e.Meta.Attempt > 0 {
+		parts = append(parts, fmt.Sprintf("attempt %d", e.Meta.Attempt))
+	}
+	keys := make([]string, 0, len(e.Meta.Fields))
+	for k := range e.Meta.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, k+"="+e.Meta.Fields[k])
+	}
+	if len(parts) == 0 {
+		return e.Err.Error()
+	}
+	return strings.Join(parts, " ") + ": " + e.Err.Error()
+}
+
+func (e *MetaError) Unwrap() error {
+	return e.Err
+}
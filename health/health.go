@@ -0,0 +1,307 @@
+// Package health watches the dependencies a service needs, such as its
+// database and cache, by pinging them periodically.
+//
+// Each dependency is Up, Down or Unknown before its first check. A single
+// failed ping does not take a dependency down, nor does a single success
+// bring it back: it takes Options.FailureThreshold consecutive failures to
+// go Down and Options.SuccessThreshold consecutive successes to come Up
+// again, so a flapping connection doesn't flip readiness on every check.
+// Transitions are reported to Options.OnChange.
+//
+// The Monitor only observes. Reconnecting is left to the client's own
+// pool: database/sql and go-redis both open new connections on demand
+// once the server is back, which the next successful ping then shows.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-projects/clock"
+)
+
+// Check pings one dependency. It must return when ctx is done.
+type Check func(ctx context.Context) error
+
+// Status is the health of one dependency.
+type Status int
+
+const (
+	Unknown Status = iota
+	Up
+	Down
+)
+
+func (s Status) String() string {
+	switch s {
+	case Up:
+		return "up"
+	case Down:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText makes Status appear as its name in JSON.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses the names written by MarshalText.
+func (s *Status) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "up":
+		*s = Up
+	case "down":
+		*s = Down
+	case "unknown":
+		*s = Unknown
+	default:
+		return fmt.Errorf("health: unknown status %q", text)
+	}
+	return nil
+}
+
+// Event reports a change of status.
+type Event struct {
+	Name     string
+	From, To Status
+	// Err is the error of the check that caused the change, nil for Up.
+	Err  error
+	Time time.Time
+}
+
+func (e Event) String() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s -> %s: %v", e.Name, e.From, e.To, e.Err)
+	}
+	return fmt.Sprintf("%s: %s -> %s", e.Name, e.From, e.To)
+}
+
+// State is the last known health of one dependency.
+type State struct {
+	Name      string        `json:"name"`
+	Status    Status        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	LastCheck time.Time     `json:"last_check"`
+	Latency   time.Duration `json:"latency_ns"`
+	// Failures and Successes count consecutive results; one of them is 0.
+	Failures  int `json:"failures"`
+	Successes int `json:"successes"`
+}
+
+// Options configures a Monitor. Zero values are replaced by defaults.
+type Options struct {
+	// Interval is the time between two rounds of checks. Default: 10s.
+	Interval time.Duration
+	// Timeout bounds a single check. Default: 2s.
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failures that take an
+	// Up dependency Down. Default: 3.
+	FailureThreshold int
+	// SuccessThreshold is the number of consecutive successes that bring a
+	// Down dependency Up. Default: 2.
+	SuccessThreshold int
+	// Clock drives the interval and the timeouts. Default: clock.Real{}.
+	Clock clock.Clock
+	// OnChange is called for every transition, after the round of checks
+	// that caused it, in registration order. The first check of a
+	// dependency moves it out of Unknown right away.
+	OnChange func(Event)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = 10 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 2 * time.Second
+	}
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = 3
+	}
+	if o.SuccessThreshold <= 0 {
+		o.SuccessThreshold = 2
+	}
+	if o.Clock == nil {
+		o.Clock = clock.Real{}
+	}
+	return o
+}
+
+type dependency struct {
+	name  string
+	check Check
+	state State
+}
+
+// Monitor checks registered dependencies. Create it with New.
+type Monitor struct {
+	opts Options
+
+	mu   sync.Mutex
+	deps []*dependency
+}
+
+// New creates a Monitor without dependencies.
+func New(opts Options) *Monitor {
+	return &Monitor{opts: opts.withDefaults()}
+}
+
+// Register adds a dependency. Its status is Unknown until the first check.
+// It panics if name is already registered.
+func (m *Monitor) Register(name string, check Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deps {
+		if d.name == name {
+			panic("health: duplicate dependency " + name)
+		}
+	}
+	m.deps = append(m.deps, &dependency{name: name, check: check, state: State{Name: name}})
+}
+
+// Run checks all dependencies right away and then every Interval until ctx
+// is done. It returns nil.
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := m.opts.Clock.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+	for {
+		m.CheckAll(ctx)
+		select {
+		case <-ticker.C():
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// CheckAll runs one round of checks concurrently and waits for all of them.
+func (m *Monitor) CheckAll(ctx context.Context) {
+	m.mu.Lock()
+	deps := append([]*dependency(nil), m.deps...)
+	m.mu.Unlock()
+
+	type result struct {
+		err     error
+		at      time.Time
+		latency time.Duration
+	}
+	results := make([]result, len(deps))
+	var wg sync.WaitGroup
+	for i, d := range deps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := m.opts.Clock.Now()
+			err := m.run(ctx, d.check)
+			results[i] = result{err: err, at: start, latency: m.opts.Clock.Since(start)}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		// The checks failed because we stopped, not because of the dependencies
+		return
+	}
+
+	var events []Event
+	m.mu.Lock()
+	for i, d := range deps {
+		r := results[i]
+		if e, changed := m.record(d, r.err, r.at, r.latency); changed {
+			events = append(events, e)
+		}
+	}
+	m.mu.Unlock()
+
+	if m.opts.OnChange != nil {
+		for _, e := range events {
+			m.opts.OnChange(e)
+		}
+	}
+}
+
+// run calls check with the timeout. A check that ignores its context is
+// abandoned when the timeout expires and finishes in the background.
+func (m *Monitor) run(ctx context.Context, check Check) error {
+	ctx, cancel := clock.WithTimeout(ctx, m.opts.Clock, m.opts.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("health: check timed out: %w", ctx.Err())
+	}
+}
+
+// record applies one check result to d and reports a transition.
+func (m *Monitor) record(d *dependency, err error, at time.Time, latency time.Duration) (Event, bool) {
+	s := &d.state
+	s.LastCheck, s.Latency = at, latency
+	from := s.Status
+
+	if err == nil {
+		s.Error = ""
+		s.Successes++
+		s.Failures = 0
+		if from != Up && (from == Unknown || s.Successes >= m.opts.SuccessThreshold) {
+			s.Status = Up
+		}
+	} else {
+		s.Error = err.Error()
+		s.Failures++
+		s.Successes = 0
+		if from != Down && (from == Unknown || s.Failures >= m.opts.FailureThreshold) {
+			s.Status = Down
+		}
+	}
+
+	if s.Status == from {
+		return Event{}, false
+	}
+	return Event{Name: d.name, From: from, To: s.Status, Err: err, Time: at}, true
+}
+
+// States returns the state of every dependency in registration order.
+func (m *Monitor) States() []State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states := make([]State, len(m.deps))
+	for i, d := range m.deps {
+		states[i] = d.state
+	}
+	return states
+}
+
+// Status returns the status of the named dependency, Unknown if it isn't
+// registered.
+func (m *Monitor) Status(name string) Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deps {
+		if d.name == name {
+			return d.state.Status
+		}
+	}
+	return Unknown
+}
+
+// Ready reports whether every dependency is Up.
+func (m *Monitor) Ready() bool {
+	return allUp(m.States())
+}
+
+func allUp(states []State) bool {
+	for _, s := range states {
+		if s.Status != Up {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go-projects/clock"
+	"go-projects/leakcheck"
+)
+
+var errRefused = errors.New("connection refused")
+
+// switchable is a dependency whose health the test sets.
+type switchable struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (s *switchable) set(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *switchable) check(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func TestHysteresis(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []error
+		want    []Status // after each round
+	}{
+		{
+			name:    "first check decides",
+			results: []error{nil},
+			want:    []Status{Up},
+		},
+		{
+			name:    "first failure goes down",
+			results: []error{errRefused},
+			want:    []Status{Down},
+		},
+		{
+			name:    "three failures to go down",
+			results: []error{nil, errRefused, errRefused, errRefused},
+			want:    []Status{Up, Up, Up, Down},
+		},
+		{
+			name:    "success resets the failure count",
+			results: []error{nil, errRefused, errRefused, nil, errRefused, errRefused},
+			want:    []Status{Up, Up, Up, Up, Up, Up},
+		},
+		{
+			name:    "two successes to come back",
+			results: []error{errRefused, nil, errRefused, nil, nil},
+			want:    []Status{Down, Down, Down, Down, Up},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dep := &switchable{}
+			var events []Event
+			m := New(Options{OnChange: func(e Event) { events = append(events, e) }})
+			m.Register("db", dep.check)
+
+			var got []Status
+			for _, err := range tt.results {
+				dep.set(err)
+				m.CheckAll(context.Background())
+				got = append(got, m.Status("db"))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("statuses = %v, want %v", got, tt.want)
+			}
+
+			// One event per change, starting from Unknown
+			var wantEvents []Status
+			for i, s := range tt.want {
+				if i == 0 || s != tt.want[i-1] {
+					wantEvents = append(wantEvents, s)
+				}
+			}
+			var gotEvents []Status
+			for _, e := range events {
+				gotEvents = append(gotEvents, e.To)
+				if e.To == Down && !errors.Is(e.Err, errRefused) {
+					t.Errorf("event %v: want the check error", e)
+				}
+			}
+			if !slices.Equal(gotEvents, wantEvents) {
+				t.Errorf("events = %v, want transitions to %v", events, wantEvents)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	leakcheck.Check(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	m := New(Options{Timeout: 20 * time.Millisecond})
+	m.Register("honors ctx", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	m.Register("ignores ctx", func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	start := time.Now()
+	m.CheckAll(context.Background())
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("CheckAll took %v, the timeout is 20ms", d)
+	}
+	for _, s := range m.States() {
+		if s.Status != Down || s.Error == "" {
+			t.Errorf("%s: status %v, error %q; want down with an error", s.Name, s.Status, s.Error)
+		}
+	}
+}
+
+func TestCanceledRound(t *testing.T) {
+	m := New(Options{})
+	m.Register("db", func(ctx context.Context) error { return ctx.Err() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m.CheckAll(ctx)
+	if s := m.Status("db"); s != Unknown {
+		t.Errorf("status after a canceled round = %v, want unknown", s)
+	}
+}
+
+func TestRun(t *testing.T) {
+	leakcheck.Check(t)
+
+	c := clock.NewFake(time.Time{})
+	checked := make(chan struct{})
+	m := New(Options{Interval: 10 * time.Second, Clock: c})
+	m.Register("db", func(context.Context) error {
+		checked <- struct{}{}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+
+	<-checked // Right away
+	for range 2 {
+		c.BlockUntil(1) // Only the ticker, the timeout timer is gone
+		c.Advance(10 * time.Second)
+		<-checked
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+	if s := m.Status("db"); s != Up {
+		t.Errorf("status = %v, want up", s)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	db, cache := &switchable{}, &switchable{err: errRefused}
+	m := New(Options{SuccessThreshold: 1})
+	m.Register("db", db.check)
+	m.Register("cache", cache.check)
+	mux := http.NewServeMux()
+	m.Mount(mux)
+
+	get := func(path string) (int, report) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var rep report
+		if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil {
+			t.Fatalf("GET %s: %v: %s", path, err, rec.Body)
+		}
+		return rec.Code, rep
+	}
+
+	m.CheckAll(context.Background())
+	if code, _ := get(LivePath); code != http.StatusOK {
+		t.Errorf("live with a dependency down = %d, want 200", code)
+	}
+	code, rep := get(ReadyPath)
+	if code != http.StatusServiceUnavailable || rep.Status != "not ready" {
+		t.Errorf("ready = %d %q, want 503 not ready", code, rep.Status)
+	}
+	if len(rep.Checks) != 2 || rep.Checks[1].Error != errRefused.Error() {
+		t.Errorf("checks = %+v, want the cache error", rep.Checks)
+	}
+
+	cache.set(nil)
+	m.CheckAll(context.Background())
+	if code, rep := get(ReadyPath); code != http.StatusOK || rep.Status != "ready" {
+		t.Errorf("ready = %d %q, want 200 ready", code, rep.Status)
+	}
+}
@@ -0,0 +1,52 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Paths the handlers are usually mounted at, as in Kubernetes.
+const (
+	LivePath  = "/livez"
+	ReadyPath = "/readyz"
+)
+
+type report struct {
+	Status string  `json:"status"`
+	Checks []State `json:"checks,omitempty"`
+}
+
+// LiveHandler answers 200 as long as the process can serve HTTP. It does
+// not look at the dependencies: restarting the service would not bring a
+// database back.
+func (m *Monitor) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, report{Status: "ok"})
+	})
+}
+
+// ReadyHandler answers 200 when every dependency is Up and 503 otherwise,
+// with the state of each dependency as JSON.
+func (m *Monitor) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		states := m.States()
+		code, rep := http.StatusOK, report{Status: "ready", Checks: states}
+		if !allUp(states) {
+			code, rep.Status = http.StatusServiceUnavailable, "not ready"
+		}
+		writeJSON(w, code, rep)
+	})
+}
+
+// Mount registers LiveHandler and ReadyHandler at LivePath and ReadyPath.
+func (m *Monitor) Mount(mux *http.ServeMux) {
+	mux.Handle("GET "+LivePath, m.LiveHandler())
+	mux.Handle("GET "+ReadyPath, m.ReadyHandler())
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,157 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"github.com/cooler-SAI/go-Tools/zerolog"
+)
+
+// GroupConsumer is a Consumer backed by a sarama.ConsumerGroup with
+// auto-commit disabled.
+type GroupConsumer struct {
+	group  sarama.ConsumerGroup
+	topics []string
+}
+
+var _ Consumer = (*GroupConsumer)(nil)
+
+// NewGroupConsumer joins groupID and subscribes to topics. A group that has no
+// committed offsets yet starts from the oldest message.
+func NewGroupConsumer(cfg Config, groupID string, topics ...string) (*GroupConsumer, error) {
+	cfg = cfg.withDefaults()
+
+	sc := sarama.NewConfig()
+	sc.Version = kafkaVersion
+	sc.ClientID = cfg.ClientID
+	sc.Consumer.Offsets.Initial = sarama.OffsetOldest
+	sc.Consumer.Offsets.AutoCommit.Enable = false
+	sc.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+
+	group, err := sarama.NewConsumerGroup(cfg.Brokers, groupID, sc)
+	if err != nil {
+		return nil, fmt.Errorf("kafka: join group %s: %w", groupID, err)
+	}
+	return &GroupConsumer{group: group, topics: topics}, nil
+}
+
+// Consume implements Consumer.
+//
+// Each rebalance ends the current session: the claim loops return, the
+// offsets marked so far are committed in Cleanup, and a new session starts
+// with the new partition assignment. Consume keeps rejoining until ctx is done
+// or handler fails.
+func (c *GroupConsumer) Consume(ctx context.Context, handler Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	h := &groupHandler{handler: handler, cancel: cancel}
+	for {
+		err := c.group.Consume(ctx, c.topics, h)
+		if failure := h.failure(); failure != nil {
+			return failure
+		}
+		if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("kafka: consume: %w", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// Close leaves the group.
+func (c *GroupConsumer) Close() error {
+	return c.group.Close()
+}
+
+// groupHandler adapts a Handler to sarama.ConsumerGroupHandler.
+type groupHandler struct {
+	handler Handler
+	cancel  context.CancelFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// fail records the first handler error and ends the session. ConsumeClaim
+// runs once per partition, so several goroutines may fail at the same time.
+func (h *groupHandler) fail(err error) {
+	h.mu.Lock()
+	if h.err == nil {
+		h.err = err
+	}
+	h.mu.Unlock()
+	h.cancel()
+}
+
+func (h *groupHandler) failure() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// Setup runs after partitions were assigned for a new session.
+func (h *groupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	zerolog.Log.Info().
+		Int32("generation", session.GenerationID()).
+		Interface("claims", session.Claims()).
+		Msg("Kafka partitions assigned")
+	return nil
+}
+
+// Cleanup runs when partitions are about to be revoked. Committing here
+// means the next owner of a partition starts right after our last processed
+// message instead of replaying it.
+func (h *groupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	session.Commit()
+	zerolog.Log.Info().
+		Int32("generation", session.GenerationID()).
+		Msg("Kafka partitions revoked, offsets committed")
+	return nil
+}
+
+// ConsumeClaim processes one partition until the session ends.
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			m := fromConsumerMessage(msg)
+			if err := h.handler(handlerContext(session.Context(), m), m); err != nil {
+				h.fail(fmt.Errorf("kafka: handle %s/%d@%d: %w", msg.Topic, msg.Partition, msg.Offset, err))
+				return nil
+			}
+			session.MarkMessage(msg, "")
+			session.Commit()
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+func fromConsumerMessage(msg *sarama.ConsumerMessage) Message {
+	m := Message{
+		Topic:     msg.Topic,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp,
+	}
+	if len(msg.Headers) > 0 {
+		m.Headers = make(map[string]string, len(msg.Headers))
+		for _, h := range msg.Headers {
+			m.Headers[string(h.Key)] = string(h.Value)
+		}
+	}
+	return m
+}
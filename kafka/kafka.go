@@ -0,0 +1,114 @@
+// Package kafka talks to the KRaft broker started by kafka/docker-compose.yml.
+//
+// Producer sends messages with an idempotent producer, so broker-side retries
+// never write duplicates. GroupConsumer reads as part of a consumer group and
+// commits offsets manually, only after the handler accepted a message.
+// MemoryBroker implements the same Producer and Consumer interfaces in memory,
+// which lets code built on this package be tested without Docker.
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"go-projects/reqctx"
+)
+
+// DefaultBrokers is the listener advertised by kafka/docker-compose.yml.
+var DefaultBrokers = []string{"localhost:9092"}
+
+// Message is a single Kafka record.
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+
+	// Partition, Offset and Timestamp are filled in for consumed messages.
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+}
+
+// Producer publishes messages. Messages with the same key always land in the
+// same partition, so their order is preserved.
+type Producer interface {
+	// Send blocks until all messages are acknowledged by the broker. The
+	// request metadata in ctx (see reqctx) is added to the message headers.
+	Send(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// Handler processes one consumed message. Returning an error stops
+// consumption; the message is not committed and will be delivered again.
+// The context carries the request metadata found in the message headers, so
+// reqctx.Logger(ctx) logs with the request ID of the producing request.
+type Handler func(ctx context.Context, msg Message) error
+
+// Consumer reads messages as a member of a consumer group.
+type Consumer interface {
+	// Consume calls handler for every message until ctx is done or handler
+	// fails. The offset of a message is committed after handler returns nil.
+	Consume(ctx context.Context, handler Handler) error
+	Close() error
+}
+
+// Config holds connection and batching settings shared by producer and consumer.
+type Config struct {
+	// Brokers defaults to DefaultBrokers.
+	Brokers []string
+	// ClientID identifies this program in broker logs. Default: "go-projects".
+	ClientID string
+
+	// BatchSize is the number of messages the producer collects before
+	// flushing. A batch that doesn't fill is flushed after BatchTimeout, so
+	// Send returns within BatchTimeout even for fewer messages. Zero
+	// flushes every Send immediately.
+	BatchSize int
+	// BatchTimeout is the longest a message waits for its batch to fill.
+	// Default: 10ms when BatchSize is set.
+	BatchTimeout time.Duration
+	// Retries is how often a failed produce request is retried. Default: 5.
+	Retries int
+}
+
+func (c Config) withDefaults() Config {
+	if len(c.Brokers) == 0 {
+		c.Brokers = DefaultBrokers
+	}
+	if c.ClientID == "" {
+		c.ClientID = "go-projects"
+	}
+	if c.Retries <= 0 {
+		c.Retries = 5
+	}
+	if c.BatchSize > 0 && c.BatchTimeout <= 0 {
+		// Without it a partial batch is never flushed and the sync
+		// producer blocks forever
+		c.BatchTimeout = 10 * time.Millisecond
+	}
+	return c
+}
+
+// withMetadata adds the request metadata of ctx to the headers of msg.
+// Headers already set on msg win, and the caller's map is not modified.
+func withMetadata(ctx context.Context, msg Message) Message {
+	md := reqctx.Headers(ctx)
+	if len(md) == 0 {
+		return msg
+	}
+	headers := make(map[string]string, len(msg.Headers)+len(md))
+	for k, v := range md {
+		headers[k] = v
+	}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	msg.Headers = headers
+	return msg
+}
+
+// handlerContext returns the context a Handler is called with for msg.
+func handlerContext(ctx context.Context, msg Message) context.Context {
+	return reqctx.FromHeaders(ctx, msg.Headers)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+
+	"go-projects/lifecycle"
+	"kafka"
+)
+
+const topic = "orders"
+
+// orderSource is the producerGoroutine of channels/channels.go, emitting orders instead of ints.
+func orderSource(outChan chan<- kafka.Message, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer close(outChan)
+
+	customers := []string{"alice", "bob", "carol"}
+	for i := 0; i < 9; i++ {
+		customer := customers[i%len(customers)]
+		outChan <- kafka.Message{
+			Topic:   topic,
+			Key:     []byte(customer), // Same customer -> same partition -> ordered
+			Value:   []byte(fmt.Sprintf("order #%d for %s", i+1, customer)),
+			Headers: map[string]string{"source": "kafka1"},
+		}
+	}
+}
+
+// orderPrinter is the consumerGoroutine of channels/channels.go.
+func orderPrinter(inChan <-chan kafka.Message, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for msg := range inChan {
+		zerolog.Log.Info().
+			Str("key", string(msg.Key)).
+			Int32("partition", msg.Partition).
+			Int64("offset", msg.Offset).
+			Msg(string(msg.Value))
+	}
+}
+
+func main() {
+	zerolog.Init()
+	app := lifecycle.New(lifecycle.Options{})
+	zerolog.Log.Info().Msg("Starting Kafka demonstration (docker compose -f kafka/docker-compose.yml up -d)")
+
+	producer, err := kafka.NewProducer(kafka.Config{BatchSize: 3, BatchTimeout: 50 * time.Millisecond})
+	if err != nil {
+		zerolog.Log.Fatal().Err(err).Msg("Kafka is not reachable")
+	}
+	defer func(producer *kafka.SaramaProducer) {
+		err := producer.Close()
+		if err != nil {
+			zerolog.Log.Error().Err(err).Msg("Error closing producer")
+		}
+	}(producer)
+
+	consumer, err := kafka.NewGroupConsumer(kafka.Config{}, "kafka1-demo", topic)
+	if err != nil {
+		zerolog.Log.Fatal().Err(err).Msg("Failed to join consumer group")
+	}
+	defer func(consumer *kafka.GroupConsumer) {
+		err := consumer.Close()
+		if err != nil {
+			zerolog.Log.Error().Err(err).Msg("Error closing consumer")
+		}
+	}(consumer)
+
+	// The consumer keeps running until Ctrl+C
+	ctx := app.Context()
+
+	var wg sync.WaitGroup
+
+	// Channel -> Kafka
+	orders := make(chan kafka.Message, 5)
+	wg.Add(2)
+	go orderSource(orders, &wg)
+	go kafka.ConsumerGoroutine(ctx, producer, orders, &wg)
+
+	// Kafka -> channel
+	received := make(chan kafka.Message, 5)
+	wg.Add(2)
+	go kafka.ProducerGoroutine(ctx, consumer, received, &wg)
+	go orderPrinter(received, &wg)
+
+	zerolog.Log.Info().Msg("Waiting for orders... Press Ctrl+C to exit")
+	app.OnShutdown("pipeline", func(ctx context.Context) error {
+		wg.Wait()
+		return nil
+	})
+	if err := app.Wait(); err != nil {
+		zerolog.Log.Error().Err(err).Msg("Shutdown finished with errors")
+	}
+	zerolog.Log.Info().Msg("Kafka demonstration finished")
+}
@@ -0,0 +1,26 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want time.Duration
+	}{
+		{"no batching", Config{}, 0},
+		{"batch size alone gets a timeout", Config{BatchSize: 100}, 10 * time.Millisecond},
+		{"explicit timeout kept", Config{BatchSize: 100, BatchTimeout: time.Second}, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.withDefaults().BatchTimeout; got != tt.want {
+				t.Errorf("BatchTimeout = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by memory producers and consumers after Close.
+var ErrClosed = errors.New("kafka: closed")
+
+// MemoryBroker is an in-memory stand-in for a Kafka cluster. It keeps every
+// topic as a fixed number of append-only partitions, tracks committed offsets
+// per consumer group and spreads partitions across the members of a group,
+// rebalancing whenever a member joins or leaves.
+type MemoryBroker struct {
+	partitions int
+
+	mu      sync.Mutex
+	topics  map[string][][]Message
+	groups  map[string]*memoryGroup
+	changed chan struct{}
+	next    int
+}
+
+type memoryGroup struct {
+	members    []*MemoryConsumer
+	committed  map[topicPartition]int64
+	generation int
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// NewMemoryBroker creates a broker whose topics have the given number of partitions.
+func NewMemoryBroker(partitions int) *MemoryBroker {
+	if partitions < 1 {
+		partitions = 1
+	}
+	return &MemoryBroker{
+		partitions: partitions,
+		topics:     make(map[string][][]Message),
+		groups:     make(map[string]*memoryGroup),
+		changed:    make(chan struct{}),
+	}
+}
+
+// notifyLocked wakes up every consumer waiting for new messages or a rebalance.
+func (b *MemoryBroker) notifyLocked() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func (b *MemoryBroker) topicLocked(topic string) [][]Message {
+	parts, ok := b.topics[topic]
+	if !ok {
+		parts = make([][]Message, b.partitions)
+		b.topics[topic] = parts
+	}
+	return parts
+}
+
+// partitionFor mirrors sarama's hash partitioner for keyed messages and
+// falls back to round-robin for messages without a key.
+func (b *MemoryBroker) partitionFor(key []byte) int32 {
+	if key == nil {
+		b.next++
+		return int32(b.next % b.partitions)
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(key)
+	p := int32(h.Sum32()) % int32(b.partitions)
+	if p < 0 {
+		p = -p
+	}
+	return p
+}
+
+// Messages returns a copy of everything written to topic, partition by partition.
+func (b *MemoryBroker) Messages(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []Message
+	for _, part := range b.topics[topic] {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// Committed returns the next offset group will read from topic/partition.
+func (b *MemoryBroker) Committed(group, topic string, partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g, ok := b.groups[group]
+	if !ok {
+		return 0
+	}
+	return g.committed[topicPartition{topic, partition}]
+}
+
+// Producer returns a Producer writing to this broker.
+func (b *MemoryBroker) Producer() *MemoryProducer {
+	return &MemoryProducer{broker: b}
+}
+
+// MemoryProducer is a Producer writing to a MemoryBroker.
+type MemoryProducer struct {
+	broker *MemoryBroker
+
+	mu     sync.Mutex
+	closed bool
+}
+
+var _ Producer = (*MemoryProducer)(nil)
+
+// Send implements Producer. Messages are visible to consumers immediately.
+func (p *MemoryProducer) Send(ctx context.Context, msgs ...Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	b := p.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, msg := range msgs {
+		msg = withMetadata(ctx, msg)
+		parts := b.topicLocked(msg.Topic)
+		partition := b.partitionFor(msg.Key)
+
+		msg.Partition = partition
+		msg.Offset = int64(len(parts[partition]))
+		msg.Timestamp = time.Now()
+		parts[partition] = append(parts[partition], msg)
+	}
+	b.notifyLocked()
+	return nil
+}
+
+// Close implements Producer.
+func (p *MemoryProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	return nil
+}
+
+// Consumer joins group and subscribes to topics. Every member of a group must
+// subscribe to the same topics.
+func (b *MemoryBroker) Consumer(group string, topics ...string) *MemoryConsumer {
+	c := &MemoryConsumer{broker: b, group: group, topics: topics, closed: make(chan struct{})}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g, ok := b.groups[group]
+	if !ok {
+		g = &memoryGroup{committed: make(map[topicPartition]int64)}
+		b.groups[group] = g
+	}
+	g.members = append(g.members, c)
+	g.generation++
+	b.notifyLocked()
+	return c
+}
+
+// MemoryConsumer is a Consumer reading from a MemoryBroker.
+type MemoryConsumer struct {
+	broker *MemoryBroker
+	group  string
+	topics []string
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+var _ Consumer = (*MemoryConsumer)(nil)
+
+// assignedLocked returns the partitions owned by c in the current generation.
+// Partitions are dealt out round-robin in (topic, partition) order.
+func (c *MemoryConsumer) assignedLocked(g *memoryGroup) []topicPartition {
+	index := -1
+	for i, m := range g.members {
+		if m == c {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+
+	topics := append([]string(nil), c.topics...)
+	sort.Strings(topics)
+
+	var owned []topicPartition
+	n := 0
+	for _, topic := range topics {
+		for p := 0; p < c.broker.partitions; p++ {
+			if n%len(g.members) == index {
+				owned = append(owned, topicPartition{topic, int32(p)})
+			}
+			n++
+		}
+	}
+	return owned
+}
+
+// Consume implements Consumer. Like a real group member it starts every
+// generation from the committed offsets, so messages handled but not yet
+// committed before a rebalance are delivered again.
+func (c *MemoryConsumer) Consume(ctx context.Context, handler Handler) error {
+	b := c.broker
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		b.mu.Lock()
+		g := b.groups[c.group]
+		generation := g.generation
+		owned := c.assignedLocked(g)
+		wait := b.changed
+
+		var (
+			next  Message
+			found bool
+		)
+		for _, tp := range owned {
+			part := b.topicLocked(tp.topic)[tp.partition]
+			if offset := g.committed[tp]; offset < int64(len(part)) {
+				next, found = part[offset], true
+				break
+			}
+		}
+		b.mu.Unlock()
+
+		if !found {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-c.closed:
+				return nil
+			case <-wait:
+				continue
+			}
+		}
+
+		if err := handler(handlerContext(ctx, next), next); err != nil {
+			return err
+		}
+
+		b.mu.Lock()
+		// A rebalance while the handler ran may have moved the partition;
+		// the new owner re-reads from the committed offset, as in Kafka.
+		if g.generation == generation {
+			g.committed[topicPartition{next.Topic, next.Partition}] = next.Offset + 1
+		}
+		b.mu.Unlock()
+	}
+}
+
+// Close leaves the group and triggers a rebalance.
+func (c *MemoryConsumer) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		b := c.broker
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		g := b.groups[c.group]
+		for i, m := range g.members {
+			if m == c {
+				g.members = append(g.members[:i], g.members[i+1:]...)
+				break
+			}
+		}
+		g.generation++
+		b.notifyLocked()
+	})
+	return nil
+}
@@ -0,0 +1,221 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-projects/reqctx"
+)
+
+func TestMemoryBrokerKeyedOrdering(t *testing.T) {
+	broker := NewMemoryBroker(4)
+	producer := broker.Producer()
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		msg := Message{Topic: "orders", Key: []byte("alice"), Value: []byte(fmt.Sprint(i))}
+		if err := producer.Send(ctx, msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	msgs := broker.Messages("orders")
+	if len(msgs) != 10 {
+		t.Fatalf("expected 10 messages, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if msg.Partition != msgs[0].Partition {
+			t.Errorf("message %d: expected partition %d, got %d", i, msgs[0].Partition, msg.Partition)
+		}
+		if msg.Offset != int64(i) || string(msg.Value) != fmt.Sprint(i) {
+			t.Errorf("message %d: out of order: offset %d value %s", i, msg.Offset, msg.Value)
+		}
+	}
+}
+
+func TestMemoryConsumerCommitsOnlyHandledMessages(t *testing.T) {
+	broker := NewMemoryBroker(1)
+	ctx := context.Background()
+
+	err := broker.Producer().Send(ctx,
+		Message{Topic: "orders", Value: []byte("ok")},
+		Message{Topic: "orders", Value: []byte("boom")},
+	)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	consumer := broker.Consumer("billing", "orders")
+	errBoom := errors.New("boom")
+	err = consumer.Consume(ctx, func(ctx context.Context, msg Message) error {
+		if string(msg.Value) == "boom" {
+			return errBoom
+		}
+		return nil
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	if got := broker.Committed("billing", "orders", 0); got != 1 {
+		t.Fatalf("expected committed offset 1, got %d", got)
+	}
+
+	// The failed message is delivered again on the next run.
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	var redelivered string
+	err = consumer.Consume(ctx, func(ctx context.Context, msg Message) error {
+		redelivered = string(msg.Value)
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if redelivered != "boom" {
+		t.Fatalf("expected redelivery of %q, got %q", "boom", redelivered)
+	}
+}
+
+func TestMemoryConsumerGroupRebalance(t *testing.T) {
+	broker := NewMemoryBroker(4)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first := broker.Consumer("billing", "orders")
+	second := broker.Consumer("billing", "orders")
+
+	var (
+		mu   sync.Mutex
+		seen = map[string]int{}
+	)
+	handle := func(name string) Handler {
+		return func(ctx context.Context, msg Message) error {
+			mu.Lock()
+			seen[name]++
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); _ = first.Consume(ctx, handle("first")) }()
+	go func() { defer wg.Done(); _ = second.Consume(ctx, handle("second")) }()
+
+	producer := broker.Producer()
+	for i := 0; i < 40; i++ {
+		msg := Message{Topic: "orders", Key: []byte(fmt.Sprintf("customer-%d", i)), Value: []byte("order")}
+		if err := producer.Send(ctx, msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return seen["first"]+seen["second"] == 40
+	})
+
+	mu.Lock()
+	if seen["first"] == 0 || seen["second"] == 0 {
+		t.Errorf("expected both members to get partitions, got %v", seen)
+	}
+	mu.Unlock()
+
+	// After the second member leaves, the first one owns every partition.
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		msg := Message{Topic: "orders", Key: []byte(fmt.Sprintf("customer-%d", i)), Value: []byte("order")}
+		if err := producer.Send(ctx, msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return seen["first"]+seen["second"] == 60
+	})
+
+	cancel()
+	wg.Wait()
+}
+
+func TestPipelineGoroutines(t *testing.T) {
+	broker := NewMemoryBroker(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	out := make(chan Message)
+	wg.Add(1)
+	go ConsumerGoroutine(ctx, broker.Producer(), out, &wg)
+	for i := 0; i < 5; i++ {
+		out <- Message{Topic: "numbers", Key: []byte("k"), Value: []byte(fmt.Sprint(i))}
+	}
+	close(out)
+	wg.Wait()
+
+	in := make(chan Message)
+	wg.Add(1)
+	go ProducerGoroutine(ctx, broker.Consumer("printer", "numbers"), in, &wg)
+
+	for i := 0; i < 5; i++ {
+		msg := <-in
+		if string(msg.Value) != fmt.Sprint(i) {
+			t.Errorf("expected %d, got %s", i, msg.Value)
+		}
+	}
+	cancel()
+	for range in {
+	}
+	wg.Wait()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMemoryBrokerPropagatesRequestMetadata(t *testing.T) {
+	broker := NewMemoryBroker(1)
+	md := reqctx.Metadata{RequestID: "req-1", UserID: "42", TraceID: "trace-1"}
+	ctx := reqctx.With(context.Background(), md)
+
+	headers := map[string]string{"source": "test"}
+	if err := broker.Producer().Send(ctx, Message{Topic: "orders", Headers: headers}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(headers) != 1 {
+		t.Errorf("Send modified the caller's headers: %v", headers)
+	}
+
+	consumeCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got reqctx.Metadata
+	err := broker.Consumer("billing", "orders").Consume(consumeCtx, func(ctx context.Context, msg Message) error {
+		got = reqctx.FromContext(ctx)
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if got != md {
+		t.Errorf("handler metadata = %+v, want %+v", got, md)
+	}
+}
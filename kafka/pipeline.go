@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+
+	"go-projects/reqctx"
+)
+
+// ProducerGoroutine feeds a channel pipeline from Kafka, in the style of
+// producerGoroutine in channels/channels.go: it consumes from c, sends every
+// message to outChan and closes outChan when ctx is done.
+//
+// A message is committed once it has been handed to outChan, so a crash in a
+// later stage can lose it. Use Consumer.Consume directly when processing must
+// finish before the commit.
+func ProducerGoroutine(ctx context.Context, c Consumer, outChan chan<- Message, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer close(outChan)
+
+	zerolog.Log.Info().Msg("Kafka producer goroutine started")
+	err := c.Consume(ctx, func(ctx context.Context, msg Message) error {
+		select {
+		case outChan <- msg:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		zerolog.Log.Error().Err(err).Msg("Kafka producer goroutine failed")
+	}
+	zerolog.Log.Info().Msg("Kafka producer goroutine stopped")
+}
+
+// ConsumerGoroutine drains a channel pipeline into Kafka, in the style of
+// consumerGoroutine in channels/channels.go: it reads inChan until it is
+// closed and sends every message with p.
+func ConsumerGoroutine(ctx context.Context, p Producer, inChan <-chan Message, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	zerolog.Log.Info().Msg("Kafka consumer goroutine started")
+	for msg := range inChan {
+		log := reqctx.Logger(handlerContext(ctx, msg))
+		if err := p.Send(ctx, msg); err != nil {
+			log.Error().Err(err).Str("topic", msg.Topic).Msg("Kafka consumer goroutine failed to send")
+			continue
+		}
+		log.Debug().Str("topic", msg.Topic).Bytes("key", msg.Key).Msg("Kafka consumer goroutine sent message")
+	}
+	zerolog.Log.Info().Msg("Kafka consumer goroutine stopped")
+}
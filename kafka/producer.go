@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// kafkaVersion matches the apache/kafka image in docker-compose.yml.
+var kafkaVersion = sarama.V3_7_0_0
+
+// SaramaProducer is an idempotent Producer backed by a sarama.SyncProducer.
+type SaramaProducer struct {
+	producer sarama.SyncProducer
+}
+
+var _ Producer = (*SaramaProducer)(nil)
+
+// NewProducer connects an idempotent producer to the brokers in cfg.
+//
+// Idempotence requires acks from all in-sync replicas and a single in-flight
+// request per connection; both are set here and cannot be overridden.
+func NewProducer(cfg Config) (*SaramaProducer, error) {
+	cfg = cfg.withDefaults()
+
+	sc := sarama.NewConfig()
+	sc.Version = kafkaVersion
+	sc.ClientID = cfg.ClientID
+	sc.Producer.Idempotent = true
+	sc.Producer.RequiredAcks = sarama.WaitForAll
+	sc.Net.MaxOpenRequests = 1
+	sc.Producer.Retry.Max = cfg.Retries
+	sc.Producer.Return.Successes = true
+	sc.Producer.Partitioner = sarama.NewHashPartitioner
+	sc.Producer.Flush.Messages = cfg.BatchSize
+	sc.Producer.Flush.Frequency = cfg.BatchTimeout
+
+	producer, err := sarama.NewSyncProducer(cfg.Brokers, sc)
+	if err != nil {
+		return nil, fmt.Errorf("kafka: create producer: %w", err)
+	}
+	return &SaramaProducer{producer: producer}, nil
+}
+
+// Send implements Producer. All messages are handed to sarama as one batch.
+//
+// The sarama call itself cannot be canceled; ctx is only checked before the
+// batch is sent.
+func (p *SaramaProducer) Send(ctx context.Context, msgs ...Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	batch := make([]*sarama.ProducerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		batch = append(batch, toProducerMessage(withMetadata(ctx, msg)))
+	}
+
+	err := p.producer.SendMessages(batch)
+	var produceErrs sarama.ProducerErrors
+	if errors.As(err, &produceErrs) {
+		return fmt.Errorf("kafka: %d of %d messages failed, first: %w", len(produceErrs), len(msgs), produceErrs[0].Err)
+	}
+	if err != nil {
+		return fmt.Errorf("kafka: send: %w", err)
+	}
+	return nil
+}
+
+// Close flushes buffered messages and closes the connection.
+func (p *SaramaProducer) Close() error {
+	return p.producer.Close()
+}
+
+func toProducerMessage(msg Message) *sarama.ProducerMessage {
+	pm := &sarama.ProducerMessage{
+		Topic: msg.Topic,
+		Value: sarama.ByteEncoder(msg.Value),
+	}
+	if msg.Key != nil {
+		pm.Key = sarama.ByteEncoder(msg.Key)
+	}
+	for k, v := range msg.Headers {
+		pm.Headers = append(pm.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	return pm
+}
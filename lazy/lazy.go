@@ -0,0 +1,259 @@
+// Package lazy builds a value on first use, like sync.Once, for
+// initializers that can fail: opening a database, connecting to Redis,
+// loading remote configuration.
+//
+// With sync.Once a failed initializer is never run again and its callers
+// can't see why it failed. A Lazy returns the error from Get and retries
+// with exponential backoff on the next attempts. Callers waiting for
+// another goroutine's initialization can give up through their context,
+// and Reset throws the value away so the next Get builds a new one, for
+// example after a lost connection.
+package lazy
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go-projects/clock"
+	"go-projects/errs"
+)
+
+// PanicError is returned by Get when the initializer panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("lazy: initializer panicked: %v", e.Value)
+}
+
+// Options configures a Lazy. Zero values are replaced by defaults.
+type Options struct {
+	// MinBackoff is the wait after the first failed attempt. Default: 100ms.
+	MinBackoff time.Duration
+	// MaxBackoff caps the wait, which doubles after every failure.
+	// Default: 10s.
+	MaxBackoff time.Duration
+	// MaxAttempts is how many times one Get runs the initializer before it
+	// returns the error. Default: 5. Negative means retry until the
+	// caller's context ends.
+	MaxAttempts int
+	// Clock is used for the backoff waits. Default: clock.Real{}.
+	Clock clock.Clock
+	// OnError is called after every failed attempt.
+	OnError func(attempt int, err error)
+}
+
+func (o Options) withDefaults() Options {
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = 100 * time.Millisecond
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 10 * time.Second
+	}
+	o.MaxBackoff = max(o.MaxBackoff, o.MinBackoff)
+	if o.MaxAttempts == 0 {
+		o.MaxAttempts = 5
+	}
+	if o.Clock == nil {
+		o.Clock = clock.Real{}
+	}
+	return o
+}
+
+// Lazy holds a value of type T that is built on the first successful Get.
+// It is safe for concurrent use.
+type Lazy[T any] struct {
+	init func(ctx context.Context) (T, error)
+	opts Options
+
+	mu       sync.Mutex
+	ok       bool
+	value    T
+	running  *run      // Initialization in progress, nil if none
+	failures int       // Consecutive failed attempts, drives the backoff
+	failedAt time.Time // When the last attempt failed
+}
+
+// run is one initialization, shared by the goroutine running it and the
+// ones waiting for it.
+type run struct {
+	done chan struct{}
+	err  error
+	// abandoned is set when the initializing caller's context ended, so
+	// the error is about that caller and a waiter should take over.
+	abandoned bool
+}
+
+// New returns a Lazy that builds its value with init. init gets the
+// context of the Get that runs it.
+func New[T any](init func(ctx context.Context) (T, error), opts Options) *Lazy[T] {
+	return &Lazy[T]{init: init, opts: opts.withDefaults()}
+}
+
+// Get returns the value, building it first if needed. Only one goroutine
+// runs the initializer at a time; the others wait for its result or until
+// their own context ends. If the initializing caller gives up, one of the
+// waiters carries on in its place.
+func (l *Lazy[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	for {
+		l.mu.Lock()
+		if l.ok {
+			v := l.value
+			l.mu.Unlock()
+			return v, nil
+		}
+		if r := l.running; r != nil {
+			l.mu.Unlock()
+			select {
+			case <-r.done:
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			}
+			if r.err != nil && !r.abandoned {
+				return zero, r.err
+			}
+			continue
+		}
+		r := &run{done: make(chan struct{})}
+		l.running = r
+		l.mu.Unlock()
+		return l.lead(ctx, r)
+	}
+}
+
+// lead runs the initialization r and hands its result to the waiters. A
+// panic in the initializer or OnError is returned as a *PanicError and
+// counts as one failed attempt.
+func (l *Lazy[T]) lead(ctx context.Context, r *run) (v T, err error) {
+	defer func() {
+		var zero T
+		p := recover()
+		l.mu.Lock()
+		if p != nil {
+			v, err = zero, &PanicError{Value: p, Stack: debug.Stack()}
+			l.failures++
+			l.failedAt = l.opts.Clock.Now()
+		}
+		l.running = nil
+		if err == nil {
+			l.ok, l.value = true, v
+		}
+		r.err = err
+		r.abandoned = err != nil && ctx.Err() != nil
+		l.mu.Unlock()
+		close(r.done)
+	}()
+	return l.initialize(ctx)
+}
+
+// Reset discards the value so that the next Get builds a new one, and
+// forgets earlier failures. It returns the old value, if there was one, so
+// the caller can release it; goroutines that already got it keep using it.
+// An initialization in progress is not interrupted.
+func (l *Lazy[T]) Reset() (old T, ok bool) {
+	var zero T
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old, ok = l.value, l.ok
+	l.value, l.ok = zero, false
+	l.failures = 0
+	return old, ok
+}
+
+// initialize runs the initializer until it succeeds, fails permanently,
+// runs out of attempts or ctx ends.
+func (l *Lazy[T]) initialize(ctx context.Context) (T, error) {
+	var zero T
+	var last error
+	for attempt := 1; ; attempt++ {
+		if err := l.backoff(ctx); err != nil {
+			if last == nil {
+				return zero, err
+			}
+			return zero, fmt.Errorf("lazy: %w, last error: %w", err, last)
+		}
+
+		v, err := l.init(ctx)
+		if err == nil {
+			l.mu.Lock()
+			l.failures = 0
+			l.mu.Unlock()
+			return v, nil
+		}
+		if ctx.Err() != nil {
+			// The caller gave up, the initializer did not fail on its own
+			return zero, err
+		}
+
+		last = err
+		l.mu.Lock()
+		l.failures++
+		l.failedAt = l.opts.Clock.Now()
+		l.mu.Unlock()
+		if perr := l.onError(attempt, err); perr != nil {
+			return zero, perr
+		}
+
+		if errs.IsPermanent(err) {
+			return zero, err
+		}
+		if l.opts.MaxAttempts > 0 && attempt >= l.opts.MaxAttempts {
+			return zero, fmt.Errorf("lazy: giving up after %d attempts: %w", attempt, err)
+		}
+	}
+}
+
+// onError calls OnError and returns a panic in it as a *PanicError. The
+// failure is already counted, so the panic must not reach lead's recover.
+func (l *Lazy[T]) onError(attempt int, err error) (perr error) {
+	if l.opts.OnError == nil {
+		return nil
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			perr = &PanicError{Value: p, Stack: debug.Stack()}
+		}
+	}()
+	l.opts.OnError(attempt, err)
+	return nil
+}
+
+// backoff waits until the next attempt is due. The wait doubles with every
+// consecutive failure, also across Get calls, so callers that retry in a
+// loop don't hammer a server that is down.
+func (l *Lazy[T]) backoff(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	failures, failedAt := l.failures, l.failedAt
+	l.mu.Unlock()
+	if failures == 0 {
+		return nil
+	}
+
+	delay := l.opts.MinBackoff
+	for i := 1; i < failures && delay < l.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	wait := l.opts.Clock.Until(failedAt.Add(min(delay, l.opts.MaxBackoff)))
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := l.opts.Clock.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,271 @@
+package lazy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-projects/clock"
+	"go-projects/errs"
+	"go-projects/leakcheck"
+)
+
+var errDown = errors.New("server down")
+
+// flaky fails its first n calls.
+func flaky(n int, calls *atomic.Int32) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		if int(calls.Add(1)) <= n {
+			return "", errDown
+		}
+		return "conn", nil
+	}
+}
+
+func TestGetOnce(t *testing.T) {
+	leakcheck.Check(t)
+
+	var calls atomic.Int32
+	l := New(flaky(0, &calls), Options{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := l.Get(context.Background()); err != nil || v != "conn" {
+				t.Errorf("Get = %q, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("initializer ran %d times, want 1", n)
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	leakcheck.Check(t)
+
+	c := clock.NewFake(time.Time{})
+	var calls atomic.Int32
+	l := New(flaky(2, &calls), Options{MinBackoff: time.Second, Clock: c})
+
+	type result struct {
+		v   string
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		v, err := l.Get(context.Background())
+		done <- result{v, err}
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(999 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("%d attempts before the first backoff ended, want 1", n)
+	}
+	c.Advance(time.Millisecond)
+
+	// The second wait is twice as long
+	c.BlockUntil(1)
+	c.Advance(2 * time.Second)
+
+	r := <-done
+	if r.err != nil || r.v != "conn" {
+		t.Fatalf("Get = %q, %v", r.v, r.err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("initializer ran %d times, want 3", n)
+	}
+}
+
+func TestGiveUp(t *testing.T) {
+	leakcheck.Check(t)
+
+	var calls atomic.Int32
+	var failed []int
+	l := New(flaky(5, &calls), Options{
+		MinBackoff:  time.Millisecond,
+		MaxAttempts: 3,
+		OnError:     func(attempt int, err error) { failed = append(failed, attempt) },
+	})
+
+	_, err := l.Get(context.Background())
+	if !errors.Is(err, errDown) {
+		t.Fatalf("Get: err = %v, want errDown", err)
+	}
+	if len(failed) != 3 {
+		t.Errorf("OnError got attempts %v, want 3 of them", failed)
+	}
+
+	// Unlike sync.Once, a later Get tries again
+	if v, err := l.Get(context.Background()); err != nil || v != "conn" {
+		t.Errorf("second Get = %q, %v", v, err)
+	}
+}
+
+func TestPermanentError(t *testing.T) {
+	var calls atomic.Int32
+	l := New(func(context.Context) (int, error) {
+		calls.Add(1)
+		return 0, errs.MarkPermanent(errors.New("bad password"))
+	}, Options{MinBackoff: time.Millisecond})
+
+	if _, err := l.Get(context.Background()); !errs.IsPermanent(err) {
+		t.Fatalf("Get: err = %v, want a permanent error", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("initializer ran %d times, want 1", n)
+	}
+}
+
+func TestCancelDuringBackoff(t *testing.T) {
+	leakcheck.Check(t)
+
+	c := clock.NewFake(time.Time{})
+	var calls atomic.Int32
+	l := New(flaky(1, &calls), Options{MinBackoff: time.Minute, Clock: c})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := l.Get(ctx)
+		done <- err
+	}()
+
+	c.BlockUntil(1)
+	cancel()
+	err := <-done
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errDown) {
+		t.Errorf("Get: err = %v, want context.Canceled and the last error", err)
+	}
+}
+
+func TestWaiters(t *testing.T) {
+	leakcheck.Check(t)
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+	l := New(func(ctx context.Context) (int, error) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+		return 42, nil
+	}, Options{})
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	defer cancelFirst()
+	firstDone := make(chan error)
+	go func() {
+		_, err := l.Get(first)
+		firstDone <- err
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Run("waiter gives up", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := l.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Get: err = %v, want DeadlineExceeded", err)
+		}
+	})
+
+	t.Run("waiter takes over", func(t *testing.T) {
+		done := make(chan int)
+		go func() {
+			v, _ := l.Get(context.Background())
+			done <- v
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancelFirst()
+
+		if err := <-firstDone; !errors.Is(err, context.Canceled) {
+			t.Errorf("first Get: err = %v, want Canceled", err)
+		}
+		if v := <-done; v != 42 {
+			t.Errorf("waiter Get = %d, want 42", v)
+		}
+	})
+	close(release)
+}
+
+func TestReset(t *testing.T) {
+	var calls atomic.Int32
+	l := New(func(context.Context) (int32, error) {
+		return calls.Add(1), nil
+	}, Options{})
+
+	if _, ok := l.Reset(); ok {
+		t.Error("Reset before Get returned a value")
+	}
+
+	v, _ := l.Get(context.Background())
+	old, ok := l.Reset()
+	if !ok || old != v {
+		t.Errorf("Reset = %d, %t; want %d, true", old, ok, v)
+	}
+	if v, _ := l.Get(context.Background()); v != 2 {
+		t.Errorf("Get after Reset = %d, want a new value 2", v)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	leakcheck.Check(t)
+
+	c := clock.NewFake(time.Time{})
+	var calls atomic.Int32
+	l := New(func(context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			panic("nil map")
+		}
+		return "conn", nil
+	}, Options{MinBackoff: time.Second, Clock: c})
+
+	_, err := l.Get(context.Background())
+	var perr *PanicError
+	if !errors.As(err, &perr) || perr.Value != "nil map" {
+		t.Fatalf("Get: err = %v, want a PanicError", err)
+	}
+
+	// The panic counts as a failure, so the next Get backs off, and it does
+	// not leave the Lazy stuck in an initialization that never ends
+	done := make(chan string)
+	go func() {
+		v, _ := l.Get(context.Background())
+		done <- v
+	}()
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	if v := <-done; v != "conn" {
+		t.Errorf("Get after the panic = %q, want conn", v)
+	}
+}
+
+func TestPanicInOnError(t *testing.T) {
+	leakcheck.Check(t)
+
+	l := New(func(context.Context) (string, error) {
+		return "", errDown
+	}, Options{OnError: func(int, error) { panic("bad hook") }})
+
+	_, err := l.Get(context.Background())
+	var perr *PanicError
+	if !errors.As(err, &perr) || perr.Value != "bad hook" {
+		t.Fatalf("Get: err = %v, want a PanicError", err)
+	}
+	if l.failures != 1 {
+		t.Errorf("failures = %d, want 1 for the one failed attempt", l.failures)
+	}
+}
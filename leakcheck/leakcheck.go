@@ -0,0 +1,134 @@
+// Package leakcheck finds goroutines that a test started but never stopped.
+//
+// Check takes a snapshot of the running goroutines when it is called and
+// compares it with the goroutines still running when the test finishes.
+// Goroutines that are new and do not exit within a grace period fail the
+// test, and their stacks are printed so the blocked line is easy to find.
+package leakcheck
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Option customises Check.
+type Option func(*config)
+
+type config struct {
+	timeout time.Duration
+	ignore  []string
+}
+
+// Timeout sets how long Check waits for new goroutines to exit. Default: 1s.
+func Timeout(d time.Duration) Option {
+	return func(c *config) { c.timeout = d }
+}
+
+// IgnoreFunction ignores goroutines whose stack contains the given function,
+// for example "os/signal.signal_recv" for a signal listener that lives as
+// long as the process.
+func IgnoreFunction(name string) Option {
+	return func(c *config) { c.ignore = append(c.ignore, name) }
+}
+
+// Goroutine is one entry of a full goroutine dump.
+type Goroutine struct {
+	ID    string
+	State string
+	Stack string
+}
+
+// Check records the current goroutines and registers a cleanup on t that
+// fails the test if goroutines started after this call are still running.
+// Call it first in a test or subtest, before anything starts goroutines.
+func Check(t testing.TB, opts ...Option) {
+	t.Helper()
+
+	cfg := config{timeout: time.Second}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	before := make(map[string]bool)
+	for _, g := range Snapshot() {
+		before[g.ID] = true
+	}
+
+	t.Cleanup(func() {
+		leaked := Wait(before, cfg.timeout, cfg.ignore...)
+		if len(leaked) == 0 {
+			return
+		}
+
+		var report strings.Builder
+		for _, g := range leaked {
+			fmt.Fprintf(&report, "\ngoroutine %s [%s]:\n%s\n", g.ID, g.State, g.Stack)
+		}
+		t.Errorf("leakcheck: %d goroutine(s) still running after the test:%s", len(leaked), report.String())
+	})
+}
+
+// Wait polls until every goroutine not listed in before has exited or
+// timeout passes, and returns the ones still running.
+func Wait(before map[string]bool, timeout time.Duration, ignore ...string) []Goroutine {
+	deadline := time.Now().Add(timeout)
+	for {
+		leaked := diff(before, ignore)
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func diff(before map[string]bool, ignore []string) []Goroutine {
+	var leaked []Goroutine
+	for _, g := range Snapshot() {
+		if before[g.ID] || isIgnored(g, ignore) {
+			continue
+		}
+		leaked = append(leaked, g)
+	}
+	return leaked
+}
+
+func isIgnored(g Goroutine, ignore []string) bool {
+	for _, name := range ignore {
+		if strings.Contains(g.Stack, name) {
+			return true
+		}
+	}
+	// The goroutine calling Snapshot is always new when Check runs in a cleanup.
+	return strings.Contains(g.Stack, "go-projects/leakcheck.Snapshot")
+}
+
+// Snapshot returns all running goroutines.
+func Snapshot() []Goroutine {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var goroutines []Goroutine
+	for _, block := range bytes.Split(buf, []byte("\n\n")) {
+		header, stack, _ := strings.Cut(string(block), "\n")
+		// header looks like "goroutine 18 [chan receive, 2 minutes]:"
+		rest, ok := strings.CutPrefix(header, "goroutine ")
+		if !ok {
+			continue
+		}
+		id, state, _ := strings.Cut(rest, " ")
+		state = strings.TrimSuffix(strings.TrimPrefix(state, "["), "]:")
+		goroutines = append(goroutines, Goroutine{ID: id, State: state, Stack: stack})
+	}
+	return goroutines
+}
@@ -0,0 +1,213 @@
+// Package lifecycle coordinates graceful shutdown of long-running programs.
+//
+// A Manager owns a root context that is canceled on the first SIGINT or
+// SIGTERM (or an explicit Shutdown). Wait then lets the workers started with Go
+// finish and runs the registered shutdown hooks in reverse registration order,
+// like deferred calls, each with its own timeout. If the whole shutdown takes
+// longer than the hard deadline, or a second signal arrives, the process exits
+// immediately with status 1.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+)
+
+// ErrShutdown is the cause of the root context when Shutdown was called.
+var ErrShutdown = errors.New("lifecycle: shutdown requested")
+
+// Options configures a Manager. Zero values are replaced by defaults.
+type Options struct {
+	// Signals that start a graceful shutdown. Default: SIGINT, SIGTERM.
+	Signals []os.Signal
+	// HookTimeout bounds every hook registered with OnShutdown. Default: 5s.
+	HookTimeout time.Duration
+	// HardDeadline bounds the whole shutdown, workers and hooks included.
+	// When it passes the process exits with status 1. Default: 15s.
+	HardDeadline time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if len(o.Signals) == 0 {
+		o.Signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	if o.HookTimeout <= 0 {
+		o.HookTimeout = 5 * time.Second
+	}
+	if o.HardDeadline <= 0 {
+		o.HardDeadline = 15 * time.Second
+	}
+	return o
+}
+
+type hook struct {
+	name    string
+	timeout time.Duration
+	fn      func(ctx context.Context) error
+}
+
+// Manager is the lifecycle of one program. Create it with New at the top of main.
+type Manager struct {
+	opts   Options
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	signals chan os.Signal
+	done    chan struct{}
+	exit    func(code int)
+
+	mu    sync.Mutex
+	hooks []hook
+	errs  []error
+
+	workers sync.WaitGroup
+}
+
+// New creates a Manager and starts listening for shutdown signals.
+func New(opts Options) *Manager {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	m := &Manager{
+		opts:    opts,
+		ctx:     ctx,
+		cancel:  cancel,
+		signals: make(chan os.Signal, 2),
+		done:    make(chan struct{}),
+		exit:    os.Exit,
+	}
+	signal.Notify(m.signals, opts.Signals...)
+	go m.watchSignals()
+	return m
+}
+
+// Context returns the root context. It is canceled when shutdown starts;
+// context.Cause tells whether a signal or Shutdown triggered it.
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Shutdown starts a graceful shutdown as if a signal had been received.
+func (m *Manager) Shutdown() {
+	m.cancel(ErrShutdown)
+}
+
+// Go runs fn in a goroutine with the root context. Wait lets it finish before
+// running the hooks. A non-nil error from fn starts the shutdown.
+//
+// fn must return by itself once ctx is done. The hooks run only after every
+// worker has returned, so a worker that waits for a hook, like
+// ListenAndServe for an http.Server whose Shutdown is registered with
+// OnShutdown, holds up the shutdown until HardDeadline kills the process.
+// Stop such a server inside fn when ctx is done instead.
+func (m *Manager) Go(name string, fn func(ctx context.Context) error) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+
+		if err := fn(m.ctx); err != nil && m.ctx.Err() == nil {
+			zerolog.Log.Error().Err(err).Str("worker", name).Msg("Worker failed, shutting down")
+			m.recordError(fmt.Errorf("worker %s: %w", name, err))
+			m.cancel(err)
+		}
+	}()
+}
+
+// OnShutdown registers fn to run during shutdown with the default HookTimeout.
+// Hooks run in reverse registration order, so resources opened first are
+// closed last, and only after the workers started with Go have returned, so
+// they can close what the workers use. A hook must not be what makes a
+// worker return; see Go.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.OnShutdownTimeout(name, m.opts.HookTimeout, fn)
+}
+
+// OnShutdownTimeout is OnShutdown with a per-hook timeout.
+func (m *Manager) OnShutdownTimeout(name string, timeout time.Duration, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, timeout: timeout, fn: fn})
+}
+
+// Wait blocks until shutdown starts, then waits for the workers and runs the
+// hooks. It returns the errors of failed workers and hooks joined together.
+func (m *Manager) Wait() error {
+	<-m.ctx.Done()
+	defer close(m.done)
+	defer signal.Stop(m.signals)
+
+	deadline := time.AfterFunc(m.opts.HardDeadline, func() {
+		zerolog.Log.Error().Dur("deadline", m.opts.HardDeadline).Msg("Shutdown deadline exceeded, forcing exit")
+		m.exit(1)
+	})
+	defer deadline.Stop()
+
+	zerolog.Log.Info().Str("cause", context.Cause(m.ctx).Error()).Msg("Shutting down...")
+	m.workers.Wait()
+
+	m.mu.Lock()
+	hooks := append([]hook(nil), m.hooks...)
+	m.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := runHook(hooks[i]); err != nil {
+			zerolog.Log.Error().Err(err).Str("hook", hooks[i].name).Msg("Shutdown hook failed")
+			m.recordError(fmt.Errorf("hook %s: %w", hooks[i].name, err))
+		}
+	}
+
+	zerolog.Log.Info().Msg("Shutdown complete")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return errors.Join(m.errs...)
+}
+
+// runHook calls the hook and gives up on it once its timeout has passed,
+// even if the hook ignores its context.
+func runHook(h hook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() { result <- h.fn(ctx) }()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %v", h.timeout)
+	}
+}
+
+func (m *Manager) recordError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errs = append(m.errs, err)
+}
+
+// watchSignals cancels the root context on the first signal and exits the
+// process on the second one.
+func (m *Manager) watchSignals() {
+	select {
+	case sig := <-m.signals:
+		zerolog.Log.Warn().Str("signal", sig.String()).Msg("Shutdown requested, send the signal again to force exit")
+		m.cancel(fmt.Errorf("lifecycle: received %s", sig))
+	case <-m.ctx.Done():
+	}
+
+	select {
+	case sig := <-m.signals:
+		zerolog.Log.Error().Str("signal", sig.String()).Msg("Second signal received, forcing exit")
+		m.exit(1)
+	case <-m.done:
+	}
+}
@@ -0,0 +1,140 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newManager creates a Manager whose exit records the code instead of
+// ending the test binary.
+func newManager(t *testing.T, opts Options) (*Manager, <-chan int) {
+	t.Helper()
+	m := New(opts)
+	exited := make(chan int, 2)
+	m.exit = func(code int) { exited <- code }
+	return m, exited
+}
+
+func TestHookOrder(t *testing.T) {
+	m, _ := newManager(t, Options{})
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	m.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond) // Hooks must still wait for this
+		return record("worker")(ctx)
+	})
+	m.OnShutdown("database", record("database"))
+	m.OnShutdown("cache", record("cache"))
+	m.OnShutdown("server", record("server"))
+
+	m.Shutdown()
+	if err := m.Wait(); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if want := []string{"worker", "server", "cache", "database"}; !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if cause := context.Cause(m.Context()); !errors.Is(cause, ErrShutdown) {
+		t.Errorf("cause = %v, want ErrShutdown", cause)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	m, _ := newManager(t, Options{})
+	errWorker := errors.New("worker broke")
+	errHook := errors.New("hook broke")
+
+	m.Go("failing", func(context.Context) error { return errWorker })
+	m.OnShutdown("failing", func(context.Context) error { return errHook })
+	m.OnShutdownTimeout("slow", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	err := m.Wait() // The failing worker starts the shutdown
+	if !errors.Is(err, errWorker) || !errors.Is(err, errHook) {
+		t.Errorf("Wait = %v, want both the worker and the hook error", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "hook slow: timed out") {
+		t.Errorf("Wait = %v, want the slow hook's timeout", err)
+	}
+}
+
+func TestHardDeadline(t *testing.T) {
+	m, exited := newManager(t, Options{HardDeadline: 20 * time.Millisecond})
+
+	release := make(chan struct{})
+	m.Go("stuck", func(context.Context) error {
+		<-release // Ignores its context
+		return nil
+	})
+
+	done := make(chan error)
+	go func() { done <- m.Wait() }()
+	m.Shutdown()
+
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("exit code = %d, want 1", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no exit after the hard deadline")
+	}
+	close(release)
+	<-done
+}
+
+func TestSecondSignal(t *testing.T) {
+	m, exited := newManager(t, Options{})
+
+	release := make(chan struct{})
+	m.Go("slow", func(context.Context) error {
+		<-release
+		return nil
+	})
+	done := make(chan error)
+	go func() { done <- m.Wait() }()
+
+	m.signals <- syscall.SIGTERM
+	<-m.Context().Done()
+	if cause := context.Cause(m.Context()); cause == nil || !strings.Contains(cause.Error(), "terminated") {
+		t.Errorf("cause = %v, want the signal", cause)
+	}
+	select {
+	case code := <-exited:
+		t.Fatalf("exit(%d) after the first signal", code)
+	default:
+	}
+
+	m.signals <- syscall.SIGTERM
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("exit code = %d, want 1", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no exit after the second signal")
+	}
+	close(release)
+	<-done
+}
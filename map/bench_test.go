@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+const benchKeys = 1000
+
+func keys() []string {
+	out := make([]string, benchKeys)
+	for i := range out {
+		out[i] = fmt.Sprintf("key_%d", i)
+	}
+	return out
+}
+
+func BenchmarkSyncMapStore(b *testing.B) {
+	var m sync.Map
+	ks := keys()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Store(ks[i%benchKeys], i)
+	}
+}
+
+func BenchmarkSyncMapLoad(b *testing.B) {
+	var m sync.Map
+	ks := keys()
+	for i, k := range ks {
+		m.Store(k, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Load(ks[i%benchKeys])
+			i++
+		}
+	})
+}
+
+// BenchmarkSyncMapMixed does one store for every nine loads.
+func BenchmarkSyncMapMixed(b *testing.B) {
+	var m sync.Map
+	ks := keys()
+	for i, k := range ks {
+		m.Store(k, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%10 == 0 {
+				m.Store(ks[i%benchKeys], i)
+			} else {
+				m.Load(ks[i%benchKeys])
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkSyncMapRange(b *testing.B) {
+	var m sync.Map
+	for i, k := range keys() {
+		m.Store(k, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Range(func(key, value any) bool { return true })
+	}
+}
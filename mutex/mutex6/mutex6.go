@@ -1,28 +1,54 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go-projects/lazy"
 )
 
-var databaseConnection string
-var once sync.Once
+var attempts atomic.Int32
 
-func initializeDatabase() {
-	fmt.Println("Initializing database connection...")
-	time.Sleep(2 * time.Second)
-	databaseConnection = "Database connected successfully!"
+// initializeDatabase fails on its first attempt, like a database that is
+// still starting up. With sync.Once that first failure would be final and
+// no goroutine could see why.
+func initializeDatabase(ctx context.Context) (string, error) {
+	attempt := attempts.Add(1)
+	fmt.Printf("Initializing database connection (attempt %d)...\n", attempt)
+	select {
+	case <-time.After(2 * time.Second):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if attempt == 1 {
+		return "", errors.New("connection refused")
+	}
 	fmt.Println("Database initialization completed.")
+	return fmt.Sprintf("Database connected successfully! (connection #%d)", attempt), nil
 }
 
-func GetDatabaseConnection() {
-	once.Do(initializeDatabase)
-	fmt.Printf("Connection accessed. Status: %s\n", databaseConnection)
+var database = lazy.New(initializeDatabase, lazy.Options{
+	MinBackoff: 500 * time.Millisecond,
+	OnError: func(attempt int, err error) {
+		fmt.Printf("Attempt %d failed: %v, retrying...\n", attempt, err)
+	},
+})
+
+func GetDatabaseConnection(ctx context.Context) {
+	conn, err := database.Get(ctx)
+	if err != nil {
+		fmt.Printf("Connection failed: %v\n", err)
+		return
+	}
+	fmt.Printf("Connection accessed. Status: %s\n", conn)
 }
 
 func main() {
-	fmt.Println("Starting demonstration of sync.Once...")
+	fmt.Println("Starting demonstration of lazy initialization...")
 	var wg sync.WaitGroup
 
 	numGoroutines := 5
@@ -31,10 +57,18 @@ func main() {
 		go func(id int) {
 			defer wg.Done()
 			fmt.Printf("Goroutine %d trying to get connection...\n", id)
-			GetDatabaseConnection()
+			GetDatabaseConnection(context.Background())
 		}(i)
 	}
-
 	wg.Wait()
+
+	// A caller that can't wait that long gives up without stopping the others
+	fmt.Println("\nConnection lost, resetting...")
+	database.Reset()
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	GetDatabaseConnection(ctx)
+	cancel()
+	GetDatabaseConnection(context.Background())
+
 	fmt.Println("\nAll goroutines have finished.")
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"mutex/race"
+)
+
+// Runs the counter from the other mutex demos under every synchronization
+// strategy several times, so lost updates are counted instead of eyeballed
+func main() {
+	var cfg race.Config
+	flag.IntVar(&cfg.Runs, "runs", 10, "runs per strategy")
+	flag.IntVar(&cfg.Goroutines, "goroutines", 1000, "concurrent goroutines")
+	flag.IntVar(&cfg.Increments, "increments", 1000, "increments per goroutine")
+	asJSON := flag.Bool("json", false, "print JSON instead of a table")
+	flag.Parse()
+
+	if !*asJSON {
+		fmt.Println("🏁 Racing the counter strategies...")
+	}
+	report := race.Run(cfg)
+
+	var err error
+	if *asJSON {
+		err = race.WriteJSON(os.Stdout, report)
+	} else {
+		err = race.WriteTable(os.Stdout, report)
+	}
+	if err != nil {
+		fmt.Println("❌ Could not write report:", err)
+		os.Exit(1)
+	}
+}
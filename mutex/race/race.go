@@ -0,0 +1,193 @@
+package race
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Config describes one harness run. Zero values are replaced by defaults.
+type Config struct {
+	Runs       int // Runs per strategy. Default: 10
+	Goroutines int // Concurrent workers. Default: 1000
+	Increments int // Adds per worker. Default: 1000
+	Strategies []Strategy
+}
+
+func (c Config) withDefaults() Config {
+	if c.Runs <= 0 {
+		c.Runs = 10
+	}
+	if c.Goroutines <= 0 {
+		c.Goroutines = 1000
+	}
+	if c.Increments <= 0 {
+		c.Increments = 1000
+	}
+	if c.Strategies == nil {
+		c.Strategies = Strategies()
+	}
+	return c
+}
+
+// Distribution summarizes one number over the runs.
+type Distribution struct {
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Mean   float64 `json:"mean"`
+	Max    float64 `json:"max"`
+}
+
+func distribution(xs []float64) Distribution {
+	if len(xs) == 0 {
+		return Distribution{}
+	}
+	xs = slices.Clone(xs)
+	slices.Sort(xs)
+
+	d := Distribution{Min: xs[0], Max: xs[len(xs)-1]}
+	for _, x := range xs {
+		d.Mean += x
+	}
+	d.Mean /= float64(len(xs))
+	if n := len(xs); n%2 == 1 {
+		d.Median = xs[n/2]
+	} else {
+		d.Median = (xs[n/2-1] + xs[n/2]) / 2
+	}
+	return d
+}
+
+// Result is the outcome of one strategy.
+type Result struct {
+	Strategy string `json:"strategy"`
+	Runs     int    `json:"runs"`
+	Expected int64  `json:"expected"`
+	// Lost is the number of updates missing from the final value.
+	Lost Distribution `json:"lost"`
+	// LossyRuns counts runs that lost at least one update.
+	LossyRuns int          `json:"lossy_runs"`
+	NsPerOp   Distribution `json:"ns_per_op"`
+	// Contentions is the number of times a goroutine had to wait for a
+	// lock, from the runtime mutex profile. It includes the runtime's own
+	// locks, such as the one inside every channel.
+	Contentions Distribution `json:"contentions"`
+}
+
+// Report is the outcome of Run.
+type Report struct {
+	Goroutines int      `json:"goroutines"`
+	Increments int      `json:"increments"`
+	GOMAXPROCS int      `json:"gomaxprocs"`
+	Results    []Result `json:"results"`
+}
+
+// Run runs every strategy cfg.Runs times. It enables mutex profiling with
+// runtime.SetMutexProfileFraction(1) while it runs and restores the
+// previous rate afterwards.
+func Run(cfg Config) Report {
+	cfg = cfg.withDefaults()
+	prev := runtime.SetMutexProfileFraction(1)
+	defer runtime.SetMutexProfileFraction(prev)
+
+	report := Report{Goroutines: cfg.Goroutines, Increments: cfg.Increments, GOMAXPROCS: runtime.GOMAXPROCS(0)}
+	for _, s := range cfg.Strategies {
+		report.Results = append(report.Results, runStrategy(cfg, s))
+	}
+	return report
+}
+
+func runStrategy(cfg Config, s Strategy) Result {
+	expected := int64(cfg.Goroutines) * int64(cfg.Increments)
+	res := Result{Strategy: s.Name, Runs: cfg.Runs, Expected: expected}
+
+	var lost, nsPerOp, contentions []float64
+	for range cfg.Runs {
+		c := s.New()
+		before := mutexContentions()
+		elapsed := hammer(c, cfg.Goroutines, cfg.Increments)
+		after := mutexContentions()
+
+		l := expected - c.Value()
+		c.Close()
+		if l != 0 {
+			res.LossyRuns++
+		}
+		lost = append(lost, float64(l))
+		nsPerOp = append(nsPerOp, float64(elapsed.Nanoseconds())/float64(expected))
+		contentions = append(contentions, float64(after-before))
+	}
+
+	res.Lost = distribution(lost)
+	res.NsPerOp = distribution(nsPerOp)
+	res.Contentions = distribution(contentions)
+	return res
+}
+
+// hammer starts every worker behind a barrier, so they really run at the
+// same time, and returns how long the adds took.
+func hammer(c Counter, goroutines, increments int) time.Duration {
+	var ready, done sync.WaitGroup
+	start := make(chan struct{})
+	ready.Add(goroutines)
+	done.Add(goroutines)
+	for w := range goroutines {
+		go func() {
+			defer done.Done()
+			ready.Done()
+			<-start
+			for range increments {
+				c.Add(w)
+			}
+		}()
+	}
+
+	ready.Wait()
+	began := time.Now()
+	close(start)
+	done.Wait()
+	return time.Since(began)
+}
+
+// mutexContentions returns the total number of contention events in the
+// mutex profile so far.
+func mutexContentions() int64 {
+	records := make([]runtime.BlockProfileRecord, 64)
+	for {
+		n, ok := runtime.MutexProfile(records)
+		if ok {
+			var total int64
+			for _, r := range records[:n] {
+				total += r.Count
+			}
+			return total
+		}
+		records = make([]runtime.BlockProfileRecord, n+64)
+	}
+}
+
+// WriteTable prints the report as an aligned table.
+func WriteTable(w io.Writer, r Report) error {
+	fmt.Fprintf(w, "%d goroutines x %d increments, GOMAXPROCS=%d\n\n", r.Goroutines, r.Increments, r.GOMAXPROCS)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "strategy\truns\tlossy\tlost min\tlost median\tlost max\tns/op\tcontentions/run\t")
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.0f\t%.0f\t%.0f\t%.1f\t%.0f\t\n",
+			res.Strategy, res.Runs, res.LossyRuns, res.Lost.Min, res.Lost.Median, res.Lost.Max,
+			res.NsPerOp.Median, res.Contentions.Median)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,100 @@
+package race
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStrategiesDoNotLoseUpdates(t *testing.T) {
+	for _, s := range Strategies() {
+		if s.Racy {
+			continue
+		}
+		t.Run(s.Name, func(t *testing.T) {
+			report := Run(Config{Runs: 3, Goroutines: 50, Increments: 200, Strategies: []Strategy{s}})
+			res := report.Results[0]
+
+			if res.Expected != 50*200 {
+				t.Errorf("Expected = %d, want %d", res.Expected, 50*200)
+			}
+			if res.LossyRuns != 0 || res.Lost.Max != 0 {
+				t.Errorf("lost updates: %+v in %d runs", res.Lost, res.LossyRuns)
+			}
+			if res.NsPerOp.Min <= 0 {
+				t.Errorf("ns/op = %+v, want positive", res.NsPerOp)
+			}
+		})
+	}
+}
+
+func TestRacyStrategyLosesUpdates(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the unsynchronized counter is a data race by design")
+	}
+	var racy Strategy
+	for _, s := range Strategies() {
+		if s.Racy {
+			racy = s
+		}
+	}
+
+	// A lost update needs two workers running at once; with many runs of
+	// a large workload at least one should show it
+	report := Run(Config{Runs: 20, Goroutines: 100, Increments: 10_000, Strategies: []Strategy{racy}})
+	res := report.Results[0]
+	if res.Lost.Min < 0 {
+		t.Errorf("negative loss: %+v", res.Lost)
+	}
+	// On one CPU the workers only interleave at preemption points, and
+	// losing an update becomes too rare to assert on
+	if runtime.NumCPU() > 1 && report.GOMAXPROCS > 1 && res.LossyRuns == 0 {
+		t.Errorf("no updates lost in %d runs with GOMAXPROCS=%d", res.Runs, report.GOMAXPROCS)
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	tests := []struct {
+		name string
+		xs   []float64
+		want Distribution
+	}{
+		{"empty", nil, Distribution{}},
+		{"odd", []float64{5, 1, 3}, Distribution{Min: 1, Median: 3, Mean: 3, Max: 5}},
+		{"even", []float64{4, 1, 3, 0}, Distribution{Min: 0, Median: 2, Mean: 2, Max: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := distribution(tt.xs); got != tt.want {
+				t.Errorf("distribution = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutput(t *testing.T) {
+	report := Run(Config{Runs: 2, Goroutines: 10, Increments: 10, Strategies: []Strategy{Strategies()[1]}})
+
+	var table bytes.Buffer
+	if err := WriteTable(&table, report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "mutex") || !strings.Contains(table.String(), "contentions/run") {
+		t.Errorf("table:\n%s", table.String())
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Results) != 1 || decoded.Results[0].Expected != 100 {
+		t.Errorf("decoded = %+v", decoded)
+	}
+}
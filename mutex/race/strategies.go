@@ -0,0 +1,170 @@
+// Package race measures how synchronization strategies for a shared counter
+// compare: how many updates each loses, what an update costs, and how much
+// lock contention it causes.
+package race
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a shared counter under test. Add is called concurrently with
+// the index of the calling worker; Value is called once all Adds returned.
+type Counter interface {
+	Add(worker int)
+	Value() int64
+	// Close releases goroutines the counter owns.
+	Close()
+}
+
+// Strategy is a named way of building a Counter.
+type Strategy struct {
+	Name string
+	New  func() Counter
+	// Racy marks strategies that lose updates on purpose. The race
+	// detector reports them, so tests skip them under -race.
+	Racy bool
+}
+
+// Strategies returns every built-in strategy, unsynchronized first.
+func Strategies() []Strategy {
+	return []Strategy{
+		{Name: "none", New: func() Counter { return &plain{} }, Racy: true},
+		{Name: "mutex", New: func() Counter { return &mutexCounter{} }},
+		{Name: "rwmutex", New: func() Counter { return &rwmutexCounter{} }},
+		{Name: "atomic", New: func() Counter { return &atomicCounter{} }},
+		{Name: "channel", New: newChannelCounter},
+		{Name: "sharded", New: func() Counter { return &shardedCounter{} }},
+	}
+}
+
+// plain has no synchronization, like counterWithoutMutex in the mutex demos.
+type plain struct{ n int64 }
+
+func (c *plain) Add(int)      { c.n++ }
+func (c *plain) Value() int64 { return c.n }
+func (c *plain) Close()       {}
+
+type mutexCounter struct {
+	mu sync.Mutex
+	n  int64
+}
+
+func (c *mutexCounter) Add(int) {
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+}
+
+func (c *mutexCounter) Value() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+func (c *mutexCounter) Close() {}
+
+// rwmutexCounter writes under Lock; with only writers it shows what the
+// RWMutex bookkeeping costs over a plain Mutex.
+type rwmutexCounter struct {
+	mu sync.RWMutex
+	n  int64
+}
+
+func (c *rwmutexCounter) Add(int) {
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+}
+
+func (c *rwmutexCounter) Value() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.n
+}
+
+func (c *rwmutexCounter) Close() {}
+
+type atomicCounter struct{ n atomic.Int64 }
+
+func (c *atomicCounter) Add(int)      { c.n.Add(1) }
+func (c *atomicCounter) Value() int64 { return c.n.Load() }
+func (c *atomicCounter) Close()       {}
+
+// channelCounter is owned by one goroutine; everybody else sends it
+// requests instead of touching the state.
+type channelCounter struct {
+	inc   chan struct{}
+	value chan chan int64
+	done  chan struct{}
+}
+
+func newChannelCounter() Counter {
+	c := &channelCounter{
+		inc:   make(chan struct{}, 64),
+		value: make(chan chan int64),
+		done:  make(chan struct{}),
+	}
+	go c.own()
+	return c
+}
+
+func (c *channelCounter) own() {
+	var n int64
+	for {
+		select {
+		case <-c.inc:
+			n++
+		case reply := <-c.value:
+			// Drain increments sent before the request
+			for len(c.inc) > 0 {
+				<-c.inc
+				n++
+			}
+			reply <- n
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *channelCounter) Add(int) { c.inc <- struct{}{} }
+
+func (c *channelCounter) Value() int64 {
+	reply := make(chan int64)
+	c.value <- reply
+	return <-reply
+}
+
+func (c *channelCounter) Close() { close(c.done) }
+
+const shards = 16
+
+// shardedCounter spreads workers over mutex-guarded shards, each on its own
+// cache line, and sums them on read.
+type shardedCounter struct {
+	shards [shards]struct {
+		mu sync.Mutex
+		n  int64
+		_  [48]byte // Pad to 64 bytes against false sharing
+	}
+}
+
+func (c *shardedCounter) Add(worker int) {
+	s := &c.shards[worker%shards]
+	s.mu.Lock()
+	s.n++
+	s.mu.Unlock()
+}
+
+func (c *shardedCounter) Value() int64 {
+	var total int64
+	for i := range c.shards {
+		c.shards[i].mu.Lock()
+		total += c.shards[i].n
+		c.shards[i].mu.Unlock()
+	}
+	return total
+}
+
+func (c *shardedCounter) Close() {}
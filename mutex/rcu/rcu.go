@@ -0,0 +1,107 @@
+// Package rcu is a read-copy-update map for read-dominated workloads.
+//
+// Readers load an immutable snapshot through an atomic.Pointer and never
+// wait, however many of them there are. Writers copy the current snapshot,
+// change the copy and publish it. Writers that arrive while a copy is being
+// made queue their changes, and the next writer applies all of them with one
+// copy, so a burst of writes costs one copy instead of one per write.
+//
+// Every write still copies the whole map, so Map suits small to medium maps
+// that are read far more often than they are written.
+package rcu
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Map is safe for concurrent use. The zero value is an empty map.
+type Map[K comparable, V any] struct {
+	snapshot atomic.Pointer[map[K]V]
+
+	mu      sync.Mutex // Guards pending
+	pending []func(map[K]V)
+	writing sync.Mutex // Serializes copy and publish
+}
+
+// Load returns the value stored under key. It never blocks.
+func (m *Map[K, V]) Load(key K) (V, bool) {
+	if p := m.snapshot.Load(); p != nil {
+		v, ok := (*p)[key]
+		return v, ok
+	}
+	var zero V
+	return zero, false
+}
+
+// Len returns the number of keys in the current snapshot.
+func (m *Map[K, V]) Len() int {
+	if p := m.snapshot.Load(); p != nil {
+		return len(*p)
+	}
+	return 0
+}
+
+// Range calls fn for every key of one snapshot, until fn returns false.
+// Writes made while Range runs are not seen.
+func (m *Map[K, V]) Range(fn func(key K, value V) bool) {
+	p := m.snapshot.Load()
+	if p == nil {
+		return
+	}
+	for k, v := range *p {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Snapshot returns the current snapshot. It must not be modified.
+func (m *Map[K, V]) Snapshot() map[K]V {
+	if p := m.snapshot.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Store sets key to value.
+func (m *Map[K, V]) Store(key K, value V) {
+	m.Update(func(data map[K]V) { data[key] = value })
+}
+
+// Delete removes key.
+func (m *Map[K, V]) Delete(key K) {
+	m.Update(func(data map[K]V) { delete(data, key) })
+}
+
+// Update applies fn to a private copy of the map, together with any other
+// writes waiting at the same time, and publishes the result. Readers see
+// all of fn's changes or none of them. When Update returns, the change is
+// visible to every later Load.
+func (m *Map[K, V]) Update(fn func(data map[K]V)) {
+	m.mu.Lock()
+	m.pending = append(m.pending, fn)
+	m.mu.Unlock()
+
+	m.writing.Lock()
+	defer m.writing.Unlock()
+
+	// The writer before us may already have applied fn in its batch
+	m.mu.Lock()
+	batch := m.pending
+	m.pending = nil
+	m.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	old := m.Snapshot()
+	next := make(map[K]V, len(old)+len(batch))
+	for k, v := range old {
+		next[k] = v
+	}
+	for _, f := range batch {
+		f(next)
+	}
+	m.snapshot.Store(&next)
+}
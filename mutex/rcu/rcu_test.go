@@ -0,0 +1,111 @@
+package rcu
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	var m Map[string, int]
+	if _, ok := m.Load("a"); ok || m.Len() != 0 {
+		t.Fatal("zero Map is not empty")
+	}
+
+	tests := []struct {
+		name  string
+		write func()
+		key   string
+		want  int
+		ok    bool
+	}{
+		{"store", func() { m.Store("a", 1) }, "a", 1, true},
+		{"overwrite", func() { m.Store("a", 2) }, "a", 2, true},
+		{"delete", func() { m.Delete("a") }, "a", 0, false},
+		{"update", func() { m.Update(func(d map[string]int) { d["b"] = 3; d["c"] = 4 }) }, "c", 4, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.write()
+			got, ok := m.Load(tt.key)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Load(%q) = %d, %v; want %d, %v", tt.key, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSnapshotIsImmutable(t *testing.T) {
+	var m Map[string, int]
+	m.Store("a", 1)
+	snap := m.Snapshot()
+
+	m.Store("a", 2)
+	m.Store("b", 3)
+	if snap["a"] != 1 || len(snap) != 1 {
+		t.Errorf("old snapshot changed: %v", snap)
+	}
+
+	seen := 0
+	m.Range(func(k string, v int) bool {
+		m.Store("during range", 0) // Not visible to this Range
+		seen++
+		return true
+	})
+	if seen != 2 {
+		t.Errorf("Range saw %d keys, want 2", seen)
+	}
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	var m Map[string, int]
+	var wg sync.WaitGroup
+
+	// 300 writers and 700 readers, as in mutex4.withRWMutexFull
+	for w := range 300 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Store(fmt.Sprint("key", w), w)
+			if v, ok := m.Load(fmt.Sprint("key", w)); !ok || v != w {
+				t.Errorf("own write not visible: %d, %v", v, ok)
+			}
+		}()
+	}
+	for range 700 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// A snapshot never shows a key without its value
+			m.Range(func(k string, v int) bool {
+				if k != fmt.Sprint("key", v) {
+					t.Errorf("inconsistent entry %s=%d", k, v)
+				}
+				return true
+			})
+		}()
+	}
+	wg.Wait()
+
+	if m.Len() != 300 {
+		t.Errorf("Len = %d, want 300", m.Len())
+	}
+}
+
+func TestConcurrentUpdatesAreNotLost(t *testing.T) {
+	var m Map[string, int]
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Update(func(d map[string]int) { d["n"]++ })
+		}()
+	}
+	wg.Wait()
+
+	if n, _ := m.Load("n"); n != 100 {
+		t.Errorf("n = %d, want 100", n)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"go-projects/actor"
+	"mutex/rcu"
+)
+
+// kv is what the benchmarked maps have in common.
+type kv interface {
+	Set(key, value string)
+	Get(key string) string
+}
+
+type syncMap struct{ m sync.Map }
+
+func (s *syncMap) Set(key, value string) { s.m.Store(key, value) }
+func (s *syncMap) Get(key string) string {
+	v, _ := s.m.Load(key)
+	str, _ := v.(string)
+	return str
+}
+
+type rcuMap struct{ m rcu.Map[string, string] }
+
+func (r *rcuMap) Set(key, value string) { r.m.Store(key, value) }
+func (r *rcuMap) Get(key string) string {
+	v, _ := r.m.Load(key)
+	return v
+}
+
+// actorMap owns the map in an actor: Set is a Send, Get an Ask.
+type actorMap struct {
+	a *actor.Actor[map[string]string]
+}
+
+func newActorMap() *actorMap {
+	return &actorMap{a: actor.New(func() map[string]string { return make(map[string]string) }, actor.Options{})}
+}
+
+func (m *actorMap) Set(key, value string) {
+	_ = m.a.Send(context.Background(), func(data *map[string]string) { (*data)[key] = value })
+}
+
+func (m *actorMap) Get(key string) string {
+	v, _ := actor.Ask(context.Background(), m.a, func(data *map[string]string) string { return (*data)[key] })
+	return v
+}
+
+func (m *actorMap) Stop() { m.a.Stop() }
+
+// BenchmarkMaps compares SafeMap, sync.Map, rcu.Map and an actor for read shares from
+// write-heavy to read-only, on 1000 keys. Run with -cpu 1,4,16 to see how
+// each scales with readers.
+func BenchmarkMaps(b *testing.B) {
+	const size = 1000
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = fmt.Sprint("key", i)
+	}
+
+	impls := []struct {
+		name string
+		new  func() kv
+	}{
+		{"SafeMap", func() kv { return NewSafeMap() }},
+		{"sync.Map", func() kv { return &syncMap{} }},
+		{"rcu.Map", func() kv { return &rcuMap{} }},
+		{"actor", func() kv { return newActorMap() }},
+	}
+
+	// Reads per thousand operations
+	for _, reads := range []int{500, 900, 990, 999, 1000} {
+		for _, impl := range impls {
+			b.Run(fmt.Sprintf("reads=%.1f%%/%s", float64(reads)/10, impl.name), func(b *testing.B) {
+				m := impl.new()
+				if s, ok := m.(interface{ Stop() }); ok {
+					defer s.Stop()
+				}
+				for _, k := range keys {
+					m.Set(k, "value")
+				}
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					i := 0
+					for pb.Next() {
+						key := keys[i%size]
+						if i%1000 < reads {
+							_ = m.Get(key)
+						} else {
+							m.Set(key, "value")
+						}
+						i++
+					}
+				})
+			})
+		}
+	}
+}
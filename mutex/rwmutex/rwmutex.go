@@ -22,15 +22,12 @@ func (sm *SafeMap) Set(key, value string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	sm.data[key] = value
-	fmt.Printf("Set: Key '%s' implement into '%s'\n", key, value)
 }
 
 func (sm *SafeMap) Get(key string) string {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
-	value, ok := sm.data[key]
-	fmt.Printf("Get: Reading key '%s', got '%s' (sucseed: %t)\n", key, value, ok)
-	return value
+	return sm.data[key]
 }
 
 func readWorker(id int, sm *SafeMap, wg *sync.WaitGroup) {
@@ -50,6 +47,7 @@ func writeWorker(id int, sm *SafeMap, wg *sync.WaitGroup) {
 		key := fmt.Sprintf("key%d", rand.Intn(5))
 		value := fmt.Sprintf("value%d-%d", id, i)
 		sm.Set(key, value)
+		fmt.Printf("Worker %d: set %s => %v\n", id, key, value)
 		time.Sleep(time.Duration(rand.Intn(200)) * time.Millisecond)
 	}
 }
@@ -62,9 +60,11 @@ func main() {
 	safeMap := NewSafeMap()
 	var wg = sync.WaitGroup{}
 
+	// SafeMap itself does not print, so the benchmarks measure only locking
 	safeMap.Set("keyA", "valueA")
 	safeMap.Set("keyB", "valueB")
 	safeMap.Set("keyC", "valueC")
+	fmt.Println("Set keyA, keyB and keyC")
 
 	numReaders := 10
 	for i := 1; i <= numReaders; i++ {
@@ -1,51 +1,86 @@
 package main
 
 import (
-	"github.com/cooler-SAI/go-Tools/stoper"
-	"github.com/cooler-SAI/go-Tools/zerolog"
-	"github.com/rs/zerolog/log"
+	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"go-projects/lazy"
+	"go-projects/lifecycle"
 )
 
-var config string
-var once sync.Once
+var attempts atomic.Int32
 
-func initializeConfig() {
-	log.Info().Msg("Initializing configuration...")
-	time.Sleep(500 * time.Millisecond)
-	config = "Application Configuration Loaded"
+// initializeConfig fails on its first attempt, as if the config server
+// was still starting. With sync.Once that failure would stick forever.
+func initializeConfig(ctx context.Context) (string, error) {
+	attempt := attempts.Add(1)
+	log.Info().Int32("attempt", attempt).Msg("Initializing configuration...")
+	select {
+	case <-time.After(500 * time.Millisecond):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if attempt == 1 {
+		return "", errors.New("config server not ready")
+	}
 	log.Info().Msg("Configuration initialized!")
+	return "Application Configuration Loaded", nil
 }
 
-func worker(id int, wg *sync.WaitGroup) {
+var config = lazy.New(initializeConfig, lazy.Options{
+	OnError: func(attempt int, err error) {
+		log.Warn().Err(err).Int("attempt", attempt).Msg("Config initialization failed, retrying")
+	},
+})
+
+func worker(ctx context.Context, id int, wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	if ctx.Err() != nil {
+		log.Warn().
+			Int("worker_id", id).
+			Msg("Worker skipped, shutting down")
+		return
+	}
+
 	log.Info().
 		Int("worker_id", id).
 		Msg("Worker attempting to load config...")
 
-	once.Do(initializeConfig)
+	cfg, err := config.Get(ctx)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int("worker_id", id).
+			Msg("Worker could not load config")
+		return
+	}
 
 	log.Info().
 		Int("worker_id", id).
-		Str("config", config).
+		Str("config", cfg).
 		Msg("Worker accessed config")
 }
 
 func main() {
-	stoper.ListenForGracefulShutdown()
+	app := lifecycle.New(lifecycle.Options{})
 
 	zerolog.Init()
 
-	log.Info().Msg("Starting sync.Once demonstration...")
+	log.Info().Msg("Starting lazy initialization demonstration...")
 
 	var wg sync.WaitGroup
 	const numWorkers = 5
 
 	for i := 1; i <= numWorkers; i++ {
 		wg.Add(1)
-		go worker(i, &wg)
+		go worker(app.Context(), i, &wg)
 	}
 
 	wg.Wait()
@@ -54,5 +89,10 @@ func main() {
 		Int("workers_count", numWorkers).
 		Msg("All workers completed")
 
+	app.Shutdown()
+	if err := app.Wait(); err != nil {
+		log.Error().Err(err).Msg("Shutdown finished with errors")
+	}
+
 	log.Info().Msg("Demonstration finished")
 }
@@ -0,0 +1,109 @@
+// Package pipeline builds channel pipelines out of generic stages.
+//
+// channels/channels.go wires one producer to one consumer by hand. Here the
+// same shape is assembled from stages that all follow the same rules:
+//
+//   - every stage runs in its own goroutine and closes its output channel
+//     when its input is exhausted or the pipeline is canceled;
+//   - output channels have a fixed capacity (Options.Buffer), so a slow stage
+//     blocks the stages before it instead of letting memory grow;
+//   - the first error returned by any stage cancels the whole pipeline and is
+//     returned by Wait or Sink.
+//
+// A typical pipeline:
+//
+//	p := pipeline.New(ctx, pipeline.Options{Buffer: 8})
+//	nums := pipeline.From(p, 1, 2, 3, 4, 5)
+//	squares := pipeline.Map(p, nums, func(ctx context.Context, n int) (int, error) { return n * n, nil })
+//	err := pipeline.Sink(p, squares, func(ctx context.Context, n int) error { fmt.Println(n); return nil })
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Options configures a Pipeline.
+type Options struct {
+	// Buffer is the capacity of every channel created by a stage. Zero means
+	// unbuffered: each item is handed over only when the next stage takes it.
+	Buffer int
+}
+
+// Pipeline owns the context and goroutines of a set of connected stages.
+type Pipeline struct {
+	opts   Options
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+}
+
+// New creates a Pipeline. Canceling ctx stops all stages.
+func New(ctx context.Context, opts Options) *Pipeline {
+	stageCtx, cancel := context.WithCancel(ctx)
+	return &Pipeline{opts: opts, parent: ctx, ctx: stageCtx, cancel: cancel}
+}
+
+// Context is canceled when the pipeline stops, on error or cancellation.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Wait waits for all stages to return. It returns the first stage error, or
+// the context error if the parent context was canceled first.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	if p.err != nil {
+		return p.err
+	}
+	return p.parent.Err()
+}
+
+// fail records the first error and stops the pipeline.
+func (p *Pipeline) fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// spawn runs a stage in a goroutine tracked by Wait.
+func (p *Pipeline) spawn(stage func() error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := stage(); err != nil {
+			p.fail(err)
+		}
+	}()
+}
+
+func makeChan[T any](p *Pipeline) chan T {
+	return make(chan T, p.opts.Buffer)
+}
+
+// send delivers v unless the pipeline is stopped first.
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// receive takes the next item; ok is false when in is closed or the
+// pipeline is stopped.
+func receive[T any](ctx context.Context, in <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-in:
+		return v, ok
+	case <-ctx.Done():
+		return v, false
+	}
+}
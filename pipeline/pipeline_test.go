@@ -0,0 +1,254 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"go-projects/leakcheck"
+)
+
+var errTest = errors.New("test error")
+
+func square(ctx context.Context, n int) (int, error) { return n * n, nil }
+
+func collect[T any](p *Pipeline, in <-chan T) ([]T, error) {
+	var got []T
+	err := Sink(p, in, func(ctx context.Context, v T) error {
+		got = append(got, v)
+		return nil
+	})
+	return got, err
+}
+
+func TestStages(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(p *Pipeline) <-chan int
+		want  []int
+		// sorted compares the output ignoring order, for fan-out stages
+		sorted bool
+	}{
+		{
+			name:  "map",
+			build: func(p *Pipeline) <-chan int { return Map(p, From(p, 1, 2, 3), square) },
+			want:  []int{1, 4, 9},
+		},
+		{
+			name: "filter",
+			build: func(p *Pipeline) <-chan int {
+				return Filter(p, From(p, 1, 2, 3, 4, 5), func(n int) bool { return n%2 == 1 })
+			},
+			want: []int{1, 3, 5},
+		},
+		{
+			name: "fan out and merge",
+			build: func(p *Pipeline) <-chan int {
+				outs := FanOut(p, From(p, 1, 2, 3, 4, 5, 6), 3)
+				squared := make([]<-chan int, len(outs))
+				for i, out := range outs {
+					squared[i] = Map(p, out, square)
+				}
+				return Merge(p, squared...)
+			},
+			want:   []int{1, 4, 9, 16, 25, 36},
+			sorted: true,
+		},
+		{
+			name:  "empty source",
+			build: func(p *Pipeline) <-chan int { return Map(p, From[int](p), square) },
+		},
+	}
+
+	for _, tt := range tests {
+		for _, buffer := range []int{0, 4} {
+			t.Run(fmt.Sprintf("%s/buffer=%d", tt.name, buffer), func(t *testing.T) {
+				leakcheck.Check(t)
+
+				p := New(context.Background(), Options{Buffer: buffer})
+				got, err := collect(p, tt.build(p))
+				if err != nil {
+					t.Fatalf("Sink: %v", err)
+				}
+				if tt.sorted {
+					slices.Sort(got)
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		maxWait time.Duration
+		gen     func(ctx context.Context, emit func(int) error) error
+		want    [][]int
+	}{
+		{
+			name: "full batches and a partial one at close",
+			n:    2,
+			gen:  emitAll(1, 2, 3, 4, 5),
+			want: [][]int{{1, 2}, {3, 4}, {5}},
+		},
+		{
+			name:    "max wait flushes a partial batch",
+			n:       10,
+			maxWait: 20 * time.Millisecond,
+			gen: func(ctx context.Context, emit func(int) error) error {
+				_ = emit(1)
+				_ = emit(2)
+				time.Sleep(100 * time.Millisecond)
+				return emit(3)
+			},
+			want: [][]int{{1, 2}, {3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+
+			p := New(context.Background(), Options{})
+			got, err := collect(p, Batch(p, Source(p, tt.gen), tt.n, tt.maxWait))
+			if err != nil {
+				t.Fatalf("Sink: %v", err)
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorsStopThePipeline(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(p *Pipeline) <-chan int
+		sink  func(ctx context.Context, v int) error
+	}{
+		{
+			name: "source error",
+			build: func(p *Pipeline) <-chan int {
+				return Source(p, func(ctx context.Context, emit func(int) error) error {
+					_ = emit(1)
+					return errTest
+				})
+			},
+		},
+		{
+			name: "map error with endless source",
+			build: func(p *Pipeline) <-chan int {
+				return Map(p, counter(p), func(ctx context.Context, n int) (int, error) {
+					if n == 3 {
+						return 0, errTest
+					}
+					return n, nil
+				})
+			},
+		},
+		{
+			name:  "sink error with endless source",
+			build: func(p *Pipeline) <-chan int { return Map(p, counter(p), square) },
+			sink: func(ctx context.Context, v int) error {
+				if v > 10 {
+					return errTest
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+
+			sink := tt.sink
+			if sink == nil {
+				sink = func(ctx context.Context, v int) error { return nil }
+			}
+			p := New(context.Background(), Options{Buffer: 2})
+			if err := Sink(p, tt.build(p), sink); !errors.Is(err, errTest) {
+				t.Fatalf("Sink: err = %v, want %v", err, errTest)
+			}
+		})
+	}
+}
+
+func TestCancelStopsEveryStage(t *testing.T) {
+	leakcheck.Check(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx, Options{})
+	outs := FanOut(p, counter(p), 4)
+	merged := Merge(p, outs...)
+	batches := Batch(p, merged, 5, time.Second)
+
+	// Read a little, then walk away without draining
+	<-batches
+	cancel()
+
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait: err = %v, want context.Canceled", err)
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	leakcheck.Check(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	emitted := make(chan int, 100)
+	p := New(ctx, Options{Buffer: 2})
+	out := Source(p, func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return nil
+			}
+			emitted <- i
+		}
+	})
+
+	// Nobody reads out: the source must stop after filling the buffer
+	time.Sleep(50 * time.Millisecond)
+	if n := len(emitted); n > 2 {
+		t.Errorf("source emitted %d items into a buffer of 2 without a reader", n)
+	}
+
+	cancel()
+	for range out {
+	}
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait: err = %v, want context.Canceled", err)
+	}
+}
+
+func emitAll(items ...int) func(ctx context.Context, emit func(int) error) error {
+	return func(ctx context.Context, emit func(int) error) error {
+		for _, v := range items {
+			if err := emit(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// counter is an endless source: it only stops when the pipeline does.
+func counter(p *Pipeline) <-chan int {
+	return Source(p, func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+)
+
+// Source starts a stage that produces items with gen. emit blocks while the
+// next stage is busy and returns the context error once the pipeline stops;
+// gen should return as soon as emit fails.
+func Source[T any](p *Pipeline, gen func(ctx context.Context, emit func(T) error) error) <-chan T {
+	out := makeChan[T](p)
+	p.spawn(func() error {
+		defer close(out)
+		return gen(p.ctx, func(v T) error {
+			if !send(p.ctx, out, v) {
+				return p.ctx.Err()
+			}
+			return nil
+		})
+	})
+	return out
+}
+
+// From is a Source emitting the given items in order.
+func From[T any](p *Pipeline, items ...T) <-chan T {
+	return Source(p, func(ctx context.Context, emit func(T) error) error {
+		for _, v := range items {
+			if err := emit(v); err != nil {
+				return nil // Stopped by someone else, nothing to report
+			}
+		}
+		return nil
+	})
+}
+
+// Map applies fn to every item. An error from fn stops the pipeline.
+func Map[T, U any](p *Pipeline, in <-chan T, fn func(ctx context.Context, v T) (U, error)) <-chan U {
+	out := makeChan[U](p)
+	p.spawn(func() error {
+		defer close(out)
+		for {
+			v, ok := receive(p.ctx, in)
+			if !ok {
+				return nil
+			}
+			u, err := fn(p.ctx, v)
+			if err != nil {
+				return err
+			}
+			if !send(p.ctx, out, u) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Filter passes on the items for which keep returns true.
+func Filter[T any](p *Pipeline, in <-chan T, keep func(v T) bool) <-chan T {
+	out := makeChan[T](p)
+	p.spawn(func() error {
+		defer close(out)
+		for {
+			v, ok := receive(p.ctx, in)
+			if !ok {
+				return nil
+			}
+			if keep(v) && !send(p.ctx, out, v) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Batch groups items into slices of up to n. A batch is also emitted when
+// maxWait has passed since its first item arrived, and the last partial batch
+// is emitted when in is closed. maxWait <= 0 only emits full batches.
+func Batch[T any](p *Pipeline, in <-chan T, n int, maxWait time.Duration) <-chan []T {
+	if n < 1 {
+		n = 1
+	}
+	out := makeChan[[]T](p)
+	p.spawn(func() error {
+		defer close(out)
+
+		var (
+			batch   []T
+			timer   *time.Timer
+			expired <-chan time.Time // nil while the batch is empty
+		)
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+			}
+			expired = nil
+			if len(batch) == 0 {
+				return true
+			}
+			ok := send(p.ctx, out, batch)
+			batch = nil
+			return ok
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return nil
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					expired = timer.C
+				}
+				if len(batch) == n && !flush() {
+					return nil
+				}
+			case <-expired:
+				if !flush() {
+					return nil
+				}
+			case <-p.ctx.Done():
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// FanOut distributes the items of in over n output channels, each item
+// going to whichever output is ready first. Give every output its own worker
+// to process items in parallel, then Merge the results.
+func FanOut[T any](p *Pipeline, in <-chan T, n int) []<-chan T {
+	if n < 1 {
+		n = 1
+	}
+	outs := make([]<-chan T, n)
+	for i := range outs {
+		out := makeChan[T](p)
+		outs[i] = out
+		p.spawn(func() error {
+			defer close(out)
+			for {
+				v, ok := receive(p.ctx, in)
+				if !ok {
+					return nil
+				}
+				if !send(p.ctx, out, v) {
+					return nil
+				}
+			}
+		})
+	}
+	return outs
+}
+
+// Merge combines several channels into one. Items keep their order within
+// each input but are interleaved across inputs.
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := makeChan[T](p)
+	done := make(chan struct{}, len(ins))
+	for _, in := range ins {
+		p.spawn(func() error {
+			defer func() { done <- struct{}{} }()
+			for {
+				v, ok := receive(p.ctx, in)
+				if !ok {
+					return nil
+				}
+				if !send(p.ctx, out, v) {
+					return nil
+				}
+			}
+		})
+	}
+	p.spawn(func() error {
+		for range ins {
+			<-done
+		}
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// Sink consumes in with fn in the calling goroutine, then waits for all
+// stages and returns the first error, like Wait.
+func Sink[T any](p *Pipeline, in <-chan T, fn func(ctx context.Context, v T) error) error {
+	for {
+		v, ok := receive(p.ctx, in)
+		if !ok {
+			break
+		}
+		if err := fn(p.ctx, v); err != nil {
+			p.fail(err)
+			break
+		}
+	}
+	return p.Wait()
+}
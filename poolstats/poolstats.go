@@ -0,0 +1,206 @@
+// Package poolstats samples connection pool statistics periodically and
+// exports them through expvar, so they show up on /debug/vars.
+//
+// database/sql and go-redis both keep cumulative counters (how many times
+// a caller had to wait for a connection, and for how long) next to gauges
+// (open, in use, idle). Stats puts them in one shape; the Exporter keeps
+// the latest sample of every pool together with the change of the
+// counters over the last interval, which is what shows a pool that is too
+// small: callers waiting, more every interval.
+package poolstats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"expvar"
+	"sync"
+	"time"
+
+	"go-projects/clock"
+)
+
+// Stats is a snapshot of one connection pool. Counters are cumulative
+// since the pool was created.
+type Stats struct {
+	// MaxOpen is the pool size limit, 0 for unlimited.
+	MaxOpen int `json:"max_open"`
+	Open    int `json:"open"`
+	InUse   int `json:"in_use"`
+	Idle    int `json:"idle"`
+
+	// WaitCount is how many times a caller waited for a free connection.
+	WaitCount    int64         `json:"wait_count"`
+	WaitDuration time.Duration `json:"wait_duration_ns"`
+	// Timeouts counts waits that gave up. database/sql does not track
+	// them: there a wait ends with the caller's context error.
+	Timeouts int64 `json:"timeouts"`
+	// Closed counts connections closed for being idle or too old.
+	Closed int64 `json:"closed"`
+}
+
+// Sub returns the change of the counters from prev to s, with the gauges
+// of s.
+func (s Stats) Sub(prev Stats) Stats {
+	s.WaitCount -= prev.WaitCount
+	s.WaitDuration -= prev.WaitDuration
+	s.Timeouts -= prev.Timeouts
+	s.Closed -= prev.Closed
+	return s
+}
+
+// FromDB converts database/sql statistics.
+func FromDB(s sql.DBStats) Stats {
+	return Stats{
+		MaxOpen:      s.MaxOpenConnections,
+		Open:         s.OpenConnections,
+		InUse:        s.InUse,
+		Idle:         s.Idle,
+		WaitCount:    s.WaitCount,
+		WaitDuration: s.WaitDuration,
+		Closed:       s.MaxIdleClosed + s.MaxIdleTimeClosed + s.MaxLifetimeClosed,
+	}
+}
+
+// Source returns the current statistics of a pool.
+type Source func() Stats
+
+// DB returns a Source for a database/sql pool.
+func DB(db *sql.DB) Source {
+	return func() Stats { return FromDB(db.Stats()) }
+}
+
+// Sample is what the Exporter recorded for one pool.
+type Sample struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	// Stats is the latest snapshot.
+	Stats Stats `json:"stats"`
+	// Interval holds the change of the counters since the previous sample.
+	Interval Stats `json:"interval"`
+}
+
+// Options configures an Exporter. Zero values are replaced by defaults.
+type Options struct {
+	// Interval is the time between two samples. Default: 10s.
+	Interval time.Duration
+	// Clock drives the interval. Default: clock.Real{}.
+	Clock clock.Clock
+	// OnSample is called for every pool after each round of sampling,
+	// in registration order.
+	OnSample func(Sample)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = 10 * time.Second
+	}
+	if o.Clock == nil {
+		o.Clock = clock.Real{}
+	}
+	return o
+}
+
+type pool struct {
+	name   string
+	source Source
+	last   Sample
+}
+
+// Exporter samples registered pools. Create it with New.
+type Exporter struct {
+	opts Options
+
+	mu    sync.Mutex
+	pools []*pool
+}
+
+// New creates an Exporter without pools.
+func New(opts Options) *Exporter {
+	return &Exporter{opts: opts.withDefaults()}
+}
+
+// Register adds a pool and takes its first sample. It panics if name is
+// already registered.
+func (e *Exporter) Register(name string, source Source) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, p := range e.pools {
+		if p.name == name {
+			panic("poolstats: duplicate pool " + name)
+		}
+	}
+	stats := source()
+	e.pools = append(e.pools, &pool{
+		name:   name,
+		source: source,
+		last:   Sample{Name: name, Time: e.opts.Clock.Now(), Stats: stats, Interval: stats.Sub(stats)},
+	})
+}
+
+// Run samples every Interval until ctx is done. It returns nil.
+func (e *Exporter) Run(ctx context.Context) error {
+	ticker := e.opts.Clock.NewTicker(e.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			e.SampleAll()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// SampleAll takes one sample of every pool.
+func (e *Exporter) SampleAll() []Sample {
+	e.mu.Lock()
+	now := e.opts.Clock.Now()
+	samples := make([]Sample, len(e.pools))
+	for i, p := range e.pools {
+		stats := p.source()
+		p.last = Sample{Name: p.name, Time: now, Stats: stats, Interval: stats.Sub(p.last.Stats)}
+		samples[i] = p.last
+	}
+	e.mu.Unlock()
+
+	if e.opts.OnSample != nil {
+		for _, s := range samples {
+			e.opts.OnSample(s)
+		}
+	}
+	return samples
+}
+
+// Samples returns the latest sample of every pool in registration order.
+func (e *Exporter) Samples() []Sample {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	samples := make([]Sample, len(e.pools))
+	for i, p := range e.pools {
+		samples[i] = p.last
+	}
+	return samples
+}
+
+// String returns the latest samples as a JSON object keyed by pool name,
+// which makes the Exporter an expvar.Var.
+func (e *Exporter) String() string {
+	byName := make(map[string]Sample)
+	for _, s := range e.Samples() {
+		byName[s.Name] = s
+	}
+	b, err := json.Marshal(byName)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// Publish exports the samples under name in expvar. Like expvar.Publish it
+// panics if name is already in use.
+func (e *Exporter) Publish(name string) {
+	expvar.Publish(name, e)
+}
+
+var _ expvar.Var = (*Exporter)(nil)
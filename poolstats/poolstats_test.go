@@ -0,0 +1,125 @@
+package poolstats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"go-projects/clock"
+	"go-projects/leakcheck"
+)
+
+// fakePool is a Source the test drives.
+type fakePool struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+func (p *fakePool) wait(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.WaitCount++
+	p.stats.WaitDuration += d
+}
+
+func (p *fakePool) source() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+func TestFromDB(t *testing.T) {
+	got := FromDB(sql.DBStats{
+		MaxOpenConnections: 10,
+		OpenConnections:    7,
+		InUse:              5,
+		Idle:               2,
+		WaitCount:          3,
+		WaitDuration:       time.Second,
+		MaxIdleClosed:      1,
+		MaxIdleTimeClosed:  2,
+		MaxLifetimeClosed:  4,
+	})
+	want := Stats{MaxOpen: 10, Open: 7, InUse: 5, Idle: 2, WaitCount: 3, WaitDuration: time.Second, Closed: 7}
+	if got != want {
+		t.Errorf("FromDB = %+v, want %+v", got, want)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	p := &fakePool{stats: Stats{MaxOpen: 2, Open: 2, InUse: 2}}
+	p.wait(time.Second)
+
+	e := New(Options{})
+	e.Register("db", p.source)
+
+	tests := []struct {
+		name      string
+		waits     int
+		wantCount int64
+		wantTotal int64
+	}{
+		{"no waits", 0, 0, 1},
+		{"three waits", 3, 3, 4},
+		{"one wait", 1, 1, 5},
+	}
+	for _, tt := range tests {
+		for range tt.waits {
+			p.wait(10 * time.Millisecond)
+		}
+		s := e.SampleAll()[0]
+		if s.Interval.WaitCount != tt.wantCount || s.Stats.WaitCount != tt.wantTotal {
+			t.Errorf("%s: waits %d in the interval, %d in total; want %d, %d",
+				tt.name, s.Interval.WaitCount, s.Stats.WaitCount, tt.wantCount, tt.wantTotal)
+		}
+		if s.Interval.InUse != 2 {
+			t.Errorf("%s: interval in use = %d, want the gauge 2", tt.name, s.Interval.InUse)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	leakcheck.Check(t)
+
+	c := clock.NewFake(time.Time{})
+	p := &fakePool{}
+	sampled := make(chan Sample)
+	e := New(Options{Interval: time.Minute, Clock: c, OnSample: func(s Sample) { sampled <- s }})
+	e.Register("redis", p.source)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- e.Run(ctx) }()
+
+	for i := range 3 {
+		p.wait(time.Millisecond)
+		c.BlockUntil(1)
+		c.Advance(time.Minute)
+		s := <-sampled
+		if s.Stats.WaitCount != int64(i+1) || s.Interval.WaitCount != 1 {
+			t.Errorf("sample %d = %+v", i, s)
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	e := New(Options{})
+	e.Register("postgres", func() Stats { return Stats{MaxOpen: 10, Open: 1, Idle: 1} })
+	e.Register("redis", func() Stats { return Stats{Open: 3} })
+
+	var got map[string]Sample
+	if err := json.Unmarshal([]byte(e.String()), &got); err != nil {
+		t.Fatalf("String is not JSON: %v", err)
+	}
+	if got["postgres"].Stats.MaxOpen != 10 || got["redis"].Stats.Open != 3 {
+		t.Errorf("String = %s", e.String())
+	}
+}
@@ -0,0 +1,53 @@
+package pqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func BenchmarkPushPop(b *testing.B) {
+	q := New[int](Options{})
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		_ = q.Push(i, i%10)
+		_, _ = q.Pop(ctx)
+	}
+}
+
+// BenchmarkPushPopBacklog keeps 1000 items queued, so every operation pays
+// for the heap depth.
+func BenchmarkPushPopBacklog(b *testing.B) {
+	q := New[int](Options{Aging: time.Second})
+	for i := 0; i < 1000; i++ {
+		_ = q.Push(i, i%10)
+	}
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = q.Push(i, i%10)
+		_, _ = q.Pop(ctx)
+	}
+}
+
+func BenchmarkPushPopParallel(b *testing.B) {
+	q := New[int](Options{})
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_ = q.Push(i, i%10)
+			_, _ = q.Pop(ctx)
+			i++
+		}
+	})
+}
+
+// BenchmarkChannel is the FIFO buffered channel the queue replaces.
+func BenchmarkChannel(b *testing.B) {
+	ch := make(chan int, 1)
+	for i := 0; i < b.N; i++ {
+		ch <- i
+		<-ch
+	}
+}
@@ -0,0 +1,49 @@
+package pqueue
+
+// readyHeap implements heap.Interface, most urgent item first.
+type readyHeap[T any] []*item[T]
+
+func (h readyHeap[T]) Len() int { return len(h) }
+
+func (h readyHeap[T]) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank > h[j].rank
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h readyHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *readyHeap[T]) Push(x any) { *h = append(*h, x.(*item[T])) }
+
+func (h *readyHeap[T]) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	old[len(old)-1] = nil // Let the item be garbage collected
+	*h = old[:len(old)-1]
+	return it
+}
+
+// delayedHeap implements heap.Interface, earliest ready time first.
+type delayedHeap[T any] []*item[T]
+
+func (h delayedHeap[T]) Len() int { return len(h) }
+
+func (h delayedHeap[T]) Less(i, j int) bool {
+	if !h[i].readyAt.Equal(h[j].readyAt) {
+		return h[i].readyAt.Before(h[j].readyAt)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h delayedHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *delayedHeap[T]) Push(x any) { *h = append(*h, x.(*item[T])) }
+
+func (h *delayedHeap[T]) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return it
+}
@@ -0,0 +1,197 @@
+// Package pqueue is a priority queue for tasks, with a blocking consumer,
+// delayed delivery and aging.
+//
+// Higher priorities are popped first; equal priorities keep FIFO order. An
+// item pushed with PushAt or PushAfter is invisible to consumers until its
+// time has come. With Options.Aging set, an item gains one priority level for
+// every Aging it has been waiting, so a steady stream of urgent work cannot
+// starve low-priority items forever.
+package pqueue
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-projects/clock"
+)
+
+// ErrClosed is returned by Push on a closed queue, and by Pop once a closed
+// queue has no ready items left.
+var ErrClosed = errors.New("pqueue: queue closed")
+
+// Options configures a Queue.
+type Options struct {
+	// Aging is the waiting time after which an item's priority has grown by
+	// one level. Zero disables aging.
+	Aging time.Duration
+	// Clock decides when delayed items are due. Default: clock.Real{}.
+	Clock clock.Clock
+}
+
+type item[T any] struct {
+	value    T
+	priority int
+	readyAt  time.Time
+	seq      uint64 // Push order, breaks ties
+	rank     int64  // Ordering key in the ready heap, see Queue.rank
+}
+
+// Queue is safe for concurrent use by any number of producers and consumers.
+type Queue[T any] struct {
+	opts  Options
+	epoch time.Time
+
+	mu      sync.Mutex
+	ready   readyHeap[T]
+	delayed delayedHeap[T]
+	seq     uint64
+	closed  bool
+	changed chan struct{} // Closed and replaced on every push or Close
+}
+
+// New creates an empty queue.
+func New[T any](opts Options) *Queue[T] {
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	return &Queue[T]{opts: opts, epoch: opts.Clock.Now(), changed: make(chan struct{})}
+}
+
+// Push adds v with the given priority, ready immediately.
+func (q *Queue[T]) Push(v T, priority int) error {
+	return q.PushAt(v, priority, q.opts.Clock.Now())
+}
+
+// PushAfter adds v to become ready after delay.
+func (q *Queue[T]) PushAfter(v T, priority int, delay time.Duration) error {
+	return q.PushAt(v, priority, q.opts.Clock.Now().Add(delay))
+}
+
+// PushAt adds v to become ready at the given time. Aging starts then.
+func (q *Queue[T]) PushAt(v T, priority int, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	q.seq++
+	it := &item[T]{value: v, priority: priority, readyAt: at, seq: q.seq}
+	if at.After(q.opts.Clock.Now()) {
+		heap.Push(&q.delayed, it)
+	} else {
+		q.pushReadyLocked(it)
+	}
+	q.notifyLocked()
+	return nil
+}
+
+// Pop removes and returns the most urgent ready item, waiting until one is
+// ready, ctx is done, or the queue is closed with no ready items left. Items
+// still delayed when the queue is closed are dropped.
+func (q *Queue[T]) Pop(ctx context.Context) (T, error) {
+	var timer clock.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		q.mu.Lock()
+		q.promoteLocked(q.opts.Clock.Now())
+		if q.ready.Len() > 0 {
+			it := heap.Pop(&q.ready).(*item[T])
+			q.mu.Unlock()
+			return it.value, nil
+		}
+		if q.closed {
+			q.mu.Unlock()
+			var zero T
+			return zero, ErrClosed
+		}
+
+		changed := q.changed
+		var due <-chan time.Time
+		if q.delayed.Len() > 0 {
+			if timer == nil {
+				timer = q.opts.Clock.NewTimer(q.opts.Clock.Until(q.delayed[0].readyAt))
+			} else {
+				timer.Reset(q.opts.Clock.Until(q.delayed[0].readyAt))
+			}
+			due = timer.C()
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-changed:
+		case <-due:
+		}
+	}
+}
+
+// TryPop is Pop without waiting. ok is false if no item is ready.
+func (q *Queue[T]) TryPop() (v T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.promoteLocked(q.opts.Clock.Now())
+	if q.ready.Len() == 0 {
+		return v, false
+	}
+	return heap.Pop(&q.ready).(*item[T]).value, true
+}
+
+// Len returns the number of ready and delayed items.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.ready.Len() + q.delayed.Len()
+}
+
+// Close stops accepting items and wakes up blocked consumers. Ready items
+// can still be popped.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		q.delayed = nil
+		q.notifyLocked()
+	}
+}
+
+// promoteLocked moves delayed items whose time has come to the ready heap.
+func (q *Queue[T]) promoteLocked(now time.Time) {
+	for q.delayed.Len() > 0 && !q.delayed[0].readyAt.After(now) {
+		q.pushReadyLocked(heap.Pop(&q.delayed).(*item[T]))
+	}
+}
+
+func (q *Queue[T]) pushReadyLocked(it *item[T]) {
+	it.rank = q.rank(it)
+	heap.Push(&q.ready, it)
+}
+
+// rank orders the ready heap. With aging the effective priority of an item
+// at time now is priority + (now - readyAt) / Aging. Multiplied by Aging and
+// with the now term dropped, as it is the same for every item, this gives a
+// key that does not change over time, so the heap never needs rebuilding.
+func (q *Queue[T]) rank(it *item[T]) int64 {
+	if q.opts.Aging <= 0 {
+		return int64(it.priority)
+	}
+	return int64(it.priority)*int64(q.opts.Aging) - int64(it.readyAt.Sub(q.epoch))
+}
+
+func (q *Queue[T]) notifyLocked() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
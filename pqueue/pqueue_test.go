@@ -0,0 +1,214 @@
+package pqueue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go-projects/clock"
+	"go-projects/leakcheck"
+)
+
+type push struct {
+	value    string
+	priority int
+	delay    time.Duration
+	// readyAgo backdates a ready item, to test aging without waiting
+	readyAgo time.Duration
+}
+
+func TestPopOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		aging  time.Duration
+		pushes []push
+		want   []string
+	}{
+		{
+			name:   "higher priority first",
+			pushes: []push{{value: "low", priority: 1}, {value: "high", priority: 5}, {value: "mid", priority: 3}},
+			want:   []string{"high", "mid", "low"},
+		},
+		{
+			name:   "fifo within a priority",
+			pushes: []push{{value: "a"}, {value: "b"}, {value: "c"}},
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name: "delayed item waits for its time",
+			pushes: []push{
+				{value: "later", priority: 10, delay: 50 * time.Millisecond},
+				{value: "now", priority: 1},
+			},
+			want: []string{"now", "later"},
+		},
+		{
+			name:  "old low priority item overtakes new urgent one",
+			aging: time.Second,
+			pushes: []push{
+				{value: "urgent", priority: 3},
+				{value: "starving", priority: 0, readyAgo: 5 * time.Second},
+			},
+			want: []string{"starving", "urgent"},
+		},
+		{
+			name:  "aging too short to overtake",
+			aging: time.Second,
+			pushes: []push{
+				{value: "urgent", priority: 3},
+				{value: "waiting", priority: 0, readyAgo: 2 * time.Second},
+			},
+			want: []string{"urgent", "waiting"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+
+			q := New[string](Options{Aging: tt.aging})
+			now := time.Now()
+			for _, p := range tt.pushes {
+				if err := q.PushAt(p.value, p.priority, now.Add(p.delay-p.readyAgo)); err != nil {
+					t.Fatalf("PushAt: %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			var got []string
+			for range tt.pushes {
+				v, err := q.Pop(ctx)
+				if err != nil {
+					t.Fatalf("Pop: %v", err)
+				}
+				got = append(got, v)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPopBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		act     func(q *Queue[string])
+		want    string
+		wantErr error
+	}{
+		{"push wakes consumer", func(q *Queue[string]) { _ = q.Push("task", 0) }, "task", nil},
+		{"delayed push wakes consumer", func(q *Queue[string]) { _ = q.PushAfter("task", 0, 20*time.Millisecond) }, "task", nil},
+		{"close wakes consumer", func(q *Queue[string]) { q.Close() }, "", ErrClosed},
+		{"context ends wait", func(q *Queue[string]) {}, "", context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+
+			q := New[string](Options{})
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				tt.act(q)
+			}()
+
+			got, err := q.Pop(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Pop: err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Pop = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	leakcheck.Check(t)
+
+	c := clock.NewFake(time.Time{})
+	q := New[string](Options{Aging: time.Minute, Clock: c})
+	_ = q.PushAfter("in an hour", 5, time.Hour)
+	_ = q.PushAfter("tomorrow", 9, 24*time.Hour)
+	_ = q.Push("low", 0)
+
+	ctx := context.Background()
+	popped := make(chan string)
+	go func() {
+		defer close(popped)
+		for range 3 {
+			v, err := q.Pop(ctx)
+			if err != nil {
+				t.Errorf("Pop: %v", err)
+				return
+			}
+			popped <- v
+		}
+	}()
+
+	if got := <-popped; got != "low" {
+		t.Fatalf("first Pop = %q, want low", got)
+	}
+
+	// Pop is now waiting for the first delayed item
+	c.BlockUntil(1)
+	c.Advance(59 * time.Minute)
+	select {
+	case v := <-popped:
+		t.Fatalf("%q popped before its time", v)
+	default:
+	}
+
+	c.Advance(time.Minute)
+	if got := <-popped; got != "in an hour" {
+		t.Fatalf("second Pop = %q, want in an hour", got)
+	}
+
+	c.BlockUntil(1)
+	c.Advance(23 * time.Hour)
+	if got := <-popped; got != "tomorrow" {
+		t.Fatalf("third Pop = %q, want tomorrow", got)
+	}
+	<-popped
+}
+
+func TestFakeClockAging(t *testing.T) {
+	c := clock.NewFake(time.Time{})
+	q := New[string](Options{Aging: time.Second, Clock: c})
+
+	_ = q.Push("starving", 0)
+	c.Advance(5 * time.Second)
+	_ = q.Push("urgent", 3)
+
+	if v, _ := q.TryPop(); v != "starving" {
+		t.Errorf("TryPop = %q, want starving", v)
+	}
+}
+
+func TestClose(t *testing.T) {
+	q := New[int](Options{})
+	_ = q.Push(1, 0)
+	_ = q.PushAfter(2, 0, time.Hour)
+	q.Close()
+
+	if err := q.Push(3, 0); !errors.Is(err, ErrClosed) {
+		t.Errorf("Push after Close: err = %v, want ErrClosed", err)
+	}
+	if v, err := q.Pop(context.Background()); err != nil || v != 1 {
+		t.Errorf("Pop = %d, %v; want the ready item 1", v, err)
+	}
+	if _, err := q.Pop(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Pop on drained queue: err = %v, want ErrClosed", err)
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop on drained queue returned an item")
+	}
+}
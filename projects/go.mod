@@ -1,3 +1,7 @@
 module projects
 
 go 1.25
+
+require go-projects v0.0.0-00010101000000-000000000000
+
+replace go-projects => ..
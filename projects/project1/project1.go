@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"math/rand"
 	"sync"
 	"time"
+
+	"go-projects/clock"
+	"go-projects/errs"
 )
 
 type Task struct {
@@ -21,23 +23,25 @@ type TaskResult struct {
 	Err    error
 }
 
-func processTask(ctx context.Context, task Task, results chan<- TaskResult, wg *sync.WaitGroup) {
+// processTask runs task for its Duration on clk and sends the outcome to
+// results, or ctx.Err() if ctx ends first.
+func processTask(ctx context.Context, clk clock.Clock, task Task, results chan<- TaskResult, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	fmt.Printf("Task %d: started (%s)\n", task.ID, task.Duration)
 
 	select {
-	case <-time.After(task.Duration):
+	case <-clk.After(task.Duration):
 		if task.willFail {
-			err := fmt.Errorf("task %d failed", task.ID)
-			results <- TaskResult{TaskID: task.ID, Err: err}
+			err := errs.MarkPermanent(fmt.Errorf("task %d failed", task.ID))
+			results <- TaskResult{TaskID: task.ID, Err: errs.WithTask(err, task.ID, 1)}
 			fmt.Printf("Task %d: failed\n", task.ID)
 		} else {
 			results <- TaskResult{TaskID: task.ID, Result: fmt.Sprintf("task %d success", task.ID)}
 			fmt.Printf("Task %d: completed\n", task.ID)
 		}
 	case <-ctx.Done():
-		results <- TaskResult{TaskID: task.ID, Err: ctx.Err()}
+		results <- TaskResult{TaskID: task.ID, Err: errs.WithTask(ctx.Err(), task.ID, 1)}
 		fmt.Printf("Task %d: cancelled (%v)\n", task.ID, ctx.Err())
 	}
 }
@@ -61,7 +65,7 @@ func main() {
 			Duration: time.Duration(rand.Intn(4)+1) * time.Second,
 			willFail: rand.Intn(5) == 0, // 20% chance to fail
 		}
-		go processTask(ctx, task, results, &wg)
+		go processTask(ctx, clock.Real{}, task, results, &wg)
 	}
 
 	// Close results channel when all tasks done
@@ -73,14 +77,13 @@ func main() {
 	// Collect results
 	var success, failed, cancelled int
 	for res := range results {
-		if res.Err != nil {
-			if errors.Is(res.Err, context.DeadlineExceeded) {
-				cancelled++
-			} else {
-				failed++
-			}
-		} else {
+		switch {
+		case res.Err == nil:
 			success++
+		case errs.IsTimeout(res.Err) || errs.IsCanceled(res.Err):
+			cancelled++
+		default:
+			failed++
 		}
 	}
 
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-projects/clock"
+	"go-projects/leakcheck"
+)
+
+func TestProcessTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    Task
+		timeout time.Duration
+		// advance moves the fake clock once the task and the deadline are
+		// both waiting on it
+		advance    time.Duration
+		wantResult bool
+		wantErr    error
+	}{
+		{"completes", Task{ID: 1, Duration: time.Second}, time.Minute, time.Second, true, nil},
+		{"fails", Task{ID: 2, Duration: time.Second, willFail: true}, time.Minute, time.Second, false, nil},
+		{"times out", Task{ID: 3, Duration: 5 * time.Second}, 3 * time.Second, 3 * time.Second, false, context.DeadlineExceeded},
+		{"already expired", Task{ID: 4, Duration: 5 * time.Second}, 0, 0, false, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+			fake := clock.NewFake(time.Time{})
+			ctx, cancel := clock.WithTimeout(context.Background(), fake, tt.timeout)
+			defer cancel()
+
+			// One slot per task, as in main, so processTask never blocks on send
+			results := make(chan TaskResult, 1)
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go processTask(ctx, fake, tt.task, results, &wg)
+
+			if tt.advance > 0 {
+				fake.BlockUntil(2)
+				fake.Advance(tt.advance)
+			}
+			wg.Wait()
+
+			res := <-results
+			if res.TaskID != tt.task.ID {
+				t.Errorf("TaskID = %d, want %d", res.TaskID, tt.task.ID)
+			}
+			if (res.Result != "") != tt.wantResult {
+				t.Errorf("Result = %q, want result: %v", res.Result, tt.wantResult)
+			}
+			switch {
+			case tt.wantErr != nil && !errors.Is(res.Err, tt.wantErr):
+				t.Errorf("Err = %v, want %v", res.Err, tt.wantErr)
+			case tt.wantErr == nil && tt.task.willFail && res.Err == nil:
+				t.Error("Err = nil, want task failure")
+			}
+		})
+	}
+}
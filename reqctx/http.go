@@ -0,0 +1,86 @@
+package reqctx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Header names used to pass metadata between services. Kafka messages use
+// the same names as message headers.
+const (
+	HeaderRequestID   = "X-Request-ID"
+	HeaderUserID      = "X-User-ID"
+	HeaderTraceID     = "X-Trace-ID"
+	HeaderTraceParent = "traceparent"
+)
+
+// Middleware puts the request ID and trace ID of every request into its
+// context. The request ID comes from X-Request-ID or is generated, and is
+// echoed in the response. The trace ID comes from X-Trace-ID or from a W3C
+// traceparent header.
+//
+// The user ID is not read from the request: an authentication middleware
+// running after this one should call WithUserID.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if id == "" {
+			id = NewID()
+		}
+		w.Header().Set(HeaderRequestID, id)
+
+		ctx := WithRequestID(r.Context(), id)
+		ctx = WithTraceID(ctx, traceIDFromRequest(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Headers returns the metadata in ctx as headers for an outgoing message.
+// Empty fields are left out.
+func Headers(ctx context.Context) map[string]string {
+	md := FromContext(ctx)
+	h := make(map[string]string, 3)
+	for name, v := range map[string]string{
+		HeaderRequestID: md.RequestID,
+		HeaderUserID:    md.UserID,
+		HeaderTraceID:   md.TraceID,
+	} {
+		if v != "" {
+			h[name] = v
+		}
+	}
+	return h
+}
+
+// FromHeaders stores the metadata found in the headers of an incoming
+// message in ctx. It is the counterpart of Headers for worker code.
+func FromHeaders(ctx context.Context, h map[string]string) context.Context {
+	return With(ctx, Metadata{
+		RequestID: h[HeaderRequestID],
+		UserID:    h[HeaderUserID],
+		TraceID:   h[HeaderTraceID],
+	})
+}
+
+// NewID returns a random 16-character hex ID.
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) // crypto/rand.Read never fails
+	return hex.EncodeToString(b)
+}
+
+// traceIDFromRequest reads X-Trace-ID, falling back to the trace-id part of
+// a traceparent header ("00-<trace-id>-<parent-id>-<flags>").
+func traceIDFromRequest(r *http.Request) string {
+	if id := r.Header.Get(HeaderTraceID); id != "" {
+		return id
+	}
+	parts := strings.Split(r.Header.Get(HeaderTraceParent), "-")
+	if len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+	return ""
+}
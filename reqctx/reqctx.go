@@ -0,0 +1,81 @@
+// Package reqctx carries request-scoped metadata (request ID, user ID and
+// trace ID) through a context and into the zerolog logger stored in it.
+//
+// Every With* function stores the value and adds it as a field to the logger
+// returned by Logger, so code deeper in the call chain only needs the context:
+//
+//	reqctx.Logger(ctx).Info().Msg("Order created")
+//	// ... request_id=3f2a... user_id=42 message="Order created"
+package reqctx
+
+import (
+	"context"
+
+	tools "github.com/cooler-SAI/go-Tools/zerolog"
+	"github.com/rs/zerolog"
+
+	"go-projects/ctxkey"
+)
+
+// Context keys of the metadata. Their names are also the log field names.
+var (
+	RequestID = ctxkey.New[string]("request_id")
+	UserID    = ctxkey.New[string]("user_id")
+	TraceID   = ctxkey.New[string]("trace_id")
+)
+
+// Metadata is the request-scoped data tracked by this package.
+type Metadata struct {
+	RequestID string
+	UserID    string
+	TraceID   string
+}
+
+// WithRequestID stores the request ID in ctx and its logger.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return with(ctx, RequestID, id)
+}
+
+// WithUserID stores the user ID in ctx and its logger. Call it once the user
+// is authenticated, not with an ID taken from an untrusted header.
+func WithUserID(ctx context.Context, id string) context.Context {
+	return with(ctx, UserID, id)
+}
+
+// WithTraceID stores the trace ID in ctx and its logger.
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return with(ctx, TraceID, id)
+}
+
+// With stores all non-empty fields of md.
+func With(ctx context.Context, md Metadata) context.Context {
+	ctx = with(ctx, RequestID, md.RequestID)
+	ctx = with(ctx, UserID, md.UserID)
+	return with(ctx, TraceID, md.TraceID)
+}
+
+// FromContext returns the metadata stored in ctx; missing fields are empty.
+func FromContext(ctx context.Context) Metadata {
+	return Metadata{
+		RequestID: ctxkey.FromOr(ctx, RequestID, ""),
+		UserID:    ctxkey.FromOr(ctx, UserID, ""),
+		TraceID:   ctxkey.FromOr(ctx, TraceID, ""),
+	}
+}
+
+// Logger returns the logger stored in ctx (see zerolog.Ctx), or the global
+// go-Tools logger if there is none.
+func Logger(ctx context.Context) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return &tools.Log
+}
+
+func with(ctx context.Context, key *ctxkey.Key[string], v string) context.Context {
+	if v == "" {
+		return ctx
+	}
+	l := Logger(ctx).With().Str(key.String(), v).Logger()
+	return l.WithContext(ctxkey.WithValue(ctx, key, v))
+}
@@ -0,0 +1,86 @@
+package reqctx
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		headers       map[string]string
+		wantRequestID string // empty: a generated ID is expected
+		wantTraceID   string
+	}{
+		{"generates request id", nil, "", ""},
+		{"keeps request id", map[string]string{HeaderRequestID: "req-1"}, "req-1", ""},
+		{"trace id header", map[string]string{HeaderTraceID: "trace-1"}, "", "trace-1"},
+		{
+			"traceparent",
+			map[string]string{HeaderTraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			"", "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{"malformed traceparent", map[string]string{HeaderTraceParent: "garbage"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Metadata
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.wantRequestID != "" && got.RequestID != tt.wantRequestID {
+				t.Errorf("RequestID = %q, want %q", got.RequestID, tt.wantRequestID)
+			}
+			if got.RequestID == "" {
+				t.Error("RequestID is empty")
+			}
+			if echoed := rec.Header().Get(HeaderRequestID); echoed != got.RequestID {
+				t.Errorf("response %s = %q, want %q", HeaderRequestID, echoed, got.RequestID)
+			}
+			if got.TraceID != tt.wantTraceID {
+				t.Errorf("TraceID = %q, want %q", got.TraceID, tt.wantTraceID)
+			}
+		})
+	}
+}
+
+func TestLoggerCarriesMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	base := zerolog.New(&buf)
+	ctx := base.WithContext(context.Background())
+
+	ctx = With(ctx, Metadata{RequestID: "req-1", TraceID: "trace-1"})
+	ctx = WithUserID(ctx, "42")
+	Logger(ctx).Info().Msg("hello")
+
+	for _, want := range []string{`"request_id":"req-1"`, `"trace_id":"trace-1"`, `"user_id":"42"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log line %s does not contain %s", buf.String(), want)
+		}
+	}
+}
+
+func TestHeadersRoundTrip(t *testing.T) {
+	md := Metadata{RequestID: "req-1", UserID: "42", TraceID: "trace-1"}
+	ctx := With(context.Background(), md)
+
+	got := FromContext(FromHeaders(context.Background(), Headers(ctx)))
+	if got != md {
+		t.Errorf("round trip = %+v, want %+v", got, md)
+	}
+}
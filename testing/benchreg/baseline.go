@@ -0,0 +1,135 @@
+package benchreg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Env is the toolchain and machine samples were taken on. Samples from
+// different environments are not comparable: a different CPU alone moves
+// every benchmark.
+type Env struct {
+	GoVersion  string `json:"go_version"`
+	GOOS       string `json:"goos"`
+	GOARCH     string `json:"goarch"`
+	CPU        string `json:"cpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+}
+
+// CurrentEnv describes this process, with the CPU go test reported in
+// results. go test inherits GOMAXPROCS from the same machine and
+// environment, so it runs with the same value.
+func CurrentEnv(results []Result) Env {
+	env := Env{
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+	for _, r := range results {
+		if r.CPU != "" {
+			env.CPU = r.CPU
+			break
+		}
+	}
+	return env
+}
+
+// Mismatches lists every field in which e differs from current, as
+// "field: baseline value, now value". A field missing from an older
+// baseline counts as different.
+func (e Env) Mismatches(current Env) []string {
+	var out []string
+	check := func(field, base, now string) {
+		if base != now {
+			if base == "" {
+				base = "unknown"
+			}
+			out = append(out, fmt.Sprintf("%s: baseline %s, now %s", field, base, now))
+		}
+	}
+	check("go version", e.GoVersion, current.GoVersion)
+	check("goos", e.GOOS, current.GOOS)
+	check("goarch", e.GOARCH, current.GOARCH)
+	check("cpu", e.CPU, current.CPU)
+	gomaxprocs := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	check("GOMAXPROCS", gomaxprocs(e.GOMAXPROCS), gomaxprocs(current.GOMAXPROCS))
+	return out
+}
+
+// Baseline is the stored set of samples results are compared against.
+type Baseline struct {
+	Created time.Time `json:"created"`
+	Env
+	// Count and Benchtime are the go test flags the samples were taken
+	// with; comparisons should use the same.
+	Count     int    `json:"count"`
+	Benchtime string `json:"benchtime,omitempty"`
+	// Benchmarks maps a Result key to unit to samples.
+	Benchmarks map[string]map[string][]float64 `json:"benchmarks"`
+}
+
+// Samples groups results by benchmark key and unit.
+func Samples(results []Result) map[string]map[string][]float64 {
+	out := make(map[string]map[string][]float64)
+	for _, r := range results {
+		units, ok := out[r.Key()]
+		if !ok {
+			units = make(map[string][]float64)
+			out[r.Key()] = units
+		}
+		for unit, v := range r.Values {
+			units[unit] = append(units[unit], v)
+		}
+	}
+	return out
+}
+
+// Merge replaces the baseline samples of every benchmark in results and
+// keeps the others, so a partial run only updates what it ran.
+func (b *Baseline) Merge(results []Result, count int, benchtime string) {
+	if b.Benchmarks == nil {
+		b.Benchmarks = make(map[string]map[string][]float64)
+	}
+	for key, units := range Samples(results) {
+		b.Benchmarks[key] = units
+	}
+	b.Created = time.Now().UTC().Truncate(time.Second)
+	b.Env = CurrentEnv(results)
+	b.Count = count
+	b.Benchtime = benchtime
+}
+
+// Load reads a baseline. A missing file gives an empty baseline.
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Baseline{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Save writes the baseline as indented JSON.
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,186 @@
+package benchreg
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const output = `goos: linux
+goarch: amd64
+pkg: testing/test1
+cpu: Intel(R) Xeon(R) Processor
+BenchmarkSum-8              	100000000	        10.5 ns/op	       0 B/op	       0 allocs/op
+BenchmarkSum-8              	100000000	        10.7 ns/op	       0 B/op	       0 allocs/op
+BenchmarkSumLargeSlice      	  1000000	      1021 ns/op
+PASS
+ok  	testing/test1	3.021s
+pkg: go-projects/map
+BenchmarkSyncMapStore/sub-4 	  547573	       207.5 ns/op	      72 B/op	       3 allocs/op
+--- BENCH: BenchmarkSkipped
+PASS
+`
+
+func TestParse(t *testing.T) {
+	results, err := Parse(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key   string
+		unit  string
+		value float64
+	}{
+		{"testing/test1.BenchmarkSum", "ns/op", 10.5},
+		{"testing/test1.BenchmarkSum", "ns/op", 10.7},
+		{"testing/test1.BenchmarkSumLargeSlice", "ns/op", 1021},
+		{"go-projects/map.BenchmarkSyncMapStore/sub", "allocs/op", 3},
+	}
+	if len(results) != len(tests) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(tests), results)
+	}
+	for i, tt := range tests {
+		if got := results[i].Key(); got != tt.key {
+			t.Errorf("result %d key = %q, want %q", i, got, tt.key)
+		}
+		if got := results[i].Values[tt.unit]; got != tt.value {
+			t.Errorf("result %d %s = %v, want %v", i, tt.unit, got, tt.value)
+		}
+	}
+	if got := CurrentEnv(results).CPU; got != "Intel(R) Xeon(R) Processor" {
+		t.Errorf("CPU = %q, want the cpu line", got)
+	}
+}
+
+func TestEnvMismatches(t *testing.T) {
+	base := Env{GoVersion: "go1.25.0", GOOS: "linux", GOARCH: "amd64", CPU: "Intel(R) Xeon(R) Processor", GOMAXPROCS: 8}
+
+	tests := []struct {
+		name    string
+		base    Env
+		current func(e *Env)
+		want    []string
+	}{
+		{"same", base, func(*Env) {}, nil},
+		{"cpu", base, func(e *Env) { e.CPU = "Apple M2" },
+			[]string{"cpu: baseline Intel(R) Xeon(R) Processor, now Apple M2"}},
+		{"gomaxprocs and go version", base, func(e *Env) { e.GOMAXPROCS, e.GoVersion = 2, "go1.26.0" },
+			[]string{"go version: baseline go1.25.0, now go1.26.0", "GOMAXPROCS: baseline 8, now 2"}},
+		{"older baseline", Env{GoVersion: "go1.25.0", GOOS: "linux", GOARCH: "amd64"}, func(*Env) {},
+			[]string{"cpu: baseline unknown, now Intel(R) Xeon(R) Processor", "GOMAXPROCS: baseline unknown, now 8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := base
+			tt.current(&current)
+			if got := tt.base.Mismatches(current); !slices.Equal(got, tt.want) {
+				t.Errorf("Mismatches = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name      string
+		samples   []float64
+		median    float64
+		low, high float64
+	}{
+		{"odd", []float64{3, 1, 2}, 2, 1, 3},
+		{"even", []float64{4, 1, 3, 2}, 2.5, 1, 4},
+		// With ten samples the 95% interval drops the extremes
+		{"ten", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 100}, 5.5, 2, 9},
+		{"empty", nil, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Summarize(tt.samples)
+			if s.Median != tt.median || s.Low != tt.low || s.High != tt.high {
+				t.Errorf("Summarize = %+v, want median %v in [%v, %v]", s, tt.median, tt.low, tt.high)
+			}
+		})
+	}
+}
+
+func TestMannWhitney(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		maxP float64
+		minP float64
+	}{
+		{"separated", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []float64{11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, 0.001, 0},
+		{"interleaved", []float64{1, 3, 5, 7, 9, 11}, []float64{2, 4, 6, 8, 10, 12}, 1, 0.5},
+		{"all equal", []float64{5, 5, 5}, []float64{5, 5, 5}, 1, 1},
+		{"constant shift", []float64{0, 0, 0, 0, 0}, []float64{1, 1, 1, 1, 1}, 0.01, 0},
+		{"too few", []float64{1}, []float64{2}, 1, 0.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := MannWhitney(tt.a, tt.b)
+			if p < tt.minP || p > tt.maxP {
+				t.Errorf("p = %.4f, want in [%v, %v]", p, tt.minP, tt.maxP)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := map[string]map[string][]float64{
+		"pkg.BenchmarkSlower": {"ns/op": {100, 101, 99, 100, 102, 98, 100, 101, 99, 100}},
+		"pkg.BenchmarkFaster": {"ns/op": {100, 101, 99, 100, 102, 98, 100, 101, 99, 100}},
+		"pkg.BenchmarkNoisy":  {"ns/op": {100, 150, 80, 120, 90, 140, 70, 130, 110, 95}},
+		"pkg.BenchmarkAllocs": {"allocs/op": {2, 2, 2, 2, 2}},
+		"pkg.BenchmarkTiny":   {"ns/op": {100, 100, 100, 100, 100}},
+		"pkg.BenchmarkGone":   {"ns/op": {1}},
+	}
+	new := map[string]map[string][]float64{
+		"pkg.BenchmarkSlower": {"ns/op": {120, 121, 119, 120, 122, 118, 120, 121, 119, 120}},
+		"pkg.BenchmarkFaster": {"ns/op": {80, 81, 79, 80, 82, 78, 80, 81, 79, 80}},
+		"pkg.BenchmarkNoisy":  {"ns/op": {105, 155, 85, 125, 95, 145, 75, 135, 115, 100}},
+		"pkg.BenchmarkAllocs": {"allocs/op": {3, 3, 3, 3, 3}},
+		"pkg.BenchmarkTiny":   {"ns/op": {101, 101, 101, 101, 101}},
+		"pkg.BenchmarkNew":    {"ns/op": {1}},
+	}
+
+	want := map[string]bool{
+		"pkg.BenchmarkSlower": true,
+		"pkg.BenchmarkFaster": false,
+		"pkg.BenchmarkNoisy":  false,
+		"pkg.BenchmarkAllocs": true,
+		"pkg.BenchmarkTiny":   false, // Significant but below the threshold
+	}
+
+	deltas := Compare(old, new, Options{})
+	if len(deltas) != len(want) {
+		t.Fatalf("got %d deltas, want %d", len(deltas), len(want))
+	}
+	for _, d := range deltas {
+		if got := d.Regression(); got != want[d.Name] {
+			t.Errorf("%s: regression = %v, want %v (change %+.2f, p %.4f)", d.Name, got, want[d.Name], d.Change, d.P)
+		}
+	}
+
+	var table strings.Builder
+	if err := WriteTable(&table, deltas); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(table.String(), "REGRESSION"); got != 2 {
+		t.Errorf("table has %d regressions, want 2:\n%s", got, table.String())
+	}
+}
+
+func TestCompareThroughput(t *testing.T) {
+	old := map[string]map[string][]float64{"pkg.B": {"MB/s": {100, 100, 100, 100, 100}}}
+	new := map[string]map[string][]float64{"pkg.B": {"MB/s": {50, 50, 50, 50, 50}}}
+
+	d := Compare(old, new, Options{})[0]
+	if !d.Regression() || math.Abs(d.Change+0.5) > 1e-9 {
+		t.Errorf("lower throughput: regression = %v, change = %v", d.Regression(), d.Change)
+	}
+}
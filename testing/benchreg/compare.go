@@ -0,0 +1,149 @@
+package benchreg
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Delta is the change of one metric of one benchmark.
+type Delta struct {
+	Name     string
+	Unit     string
+	Old, New Summary
+	// Change is (new-old)/old of the medians; +0.1 means 10% more.
+	Change float64
+	P      float64
+	// Significant is set when P < alpha and |Change| >= threshold.
+	Significant bool
+}
+
+// Regression reports a significant change for the worse. ns/op, B/op and
+// allocs/op are lower-is-better; throughput units such as MB/s are not.
+func (d Delta) Regression() bool {
+	if !d.Significant {
+		return false
+	}
+	if strings.HasSuffix(d.Unit, "/s") {
+		return d.Change < 0
+	}
+	return d.Change > 0
+}
+
+// Options controls when a change counts as significant.
+type Options struct {
+	// Alpha is the p-value below which a change is not noise. Default: 0.05.
+	Alpha float64
+	// Threshold is the smallest relative change reported as significant,
+	// so tiny but consistent shifts do not fail a build. Default: 0.1.
+	Threshold float64
+}
+
+func (o Options) withDefaults() Options {
+	if o.Alpha <= 0 {
+		o.Alpha = 0.05
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = 0.1
+	}
+	return o
+}
+
+// Compare compares the benchmarks present in both old and new, sorted by
+// name and unit. Benchmarks only in one of them are ignored.
+func Compare(old, new map[string]map[string][]float64, opts Options) []Delta {
+	opts = opts.withDefaults()
+
+	var deltas []Delta
+	for name, newUnits := range new {
+		oldUnits, ok := old[name]
+		if !ok {
+			continue
+		}
+		for unit, newSamples := range newUnits {
+			oldSamples, ok := oldUnits[unit]
+			if !ok {
+				continue
+			}
+			d := Delta{
+				Name: name,
+				Unit: unit,
+				Old:  Summarize(oldSamples),
+				New:  Summarize(newSamples),
+				P:    MannWhitney(oldSamples, newSamples),
+			}
+			switch {
+			case d.Old.Median != 0:
+				d.Change = (d.New.Median - d.Old.Median) / d.Old.Median
+			case d.New.Median != 0:
+				d.Change = math.Inf(1)
+			}
+			d.Significant = d.P < opts.Alpha && math.Abs(d.Change) >= opts.Threshold
+			deltas = append(deltas, d)
+		}
+	}
+
+	slices.SortFunc(deltas, func(a, b Delta) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(unitOrder(a.Unit), unitOrder(b.Unit))
+	})
+	return deltas
+}
+
+// unitOrder sorts time first, then bytes, then allocations, like go test.
+func unitOrder(unit string) string {
+	switch unit {
+	case "ns/op":
+		return "0"
+	case "B/op":
+		return "1"
+	case "allocs/op":
+		return "2"
+	}
+	return "3" + unit
+}
+
+// WriteTable prints deltas as a benchstat-style table. Changes that are not
+// significant are shown as "~", metrics that are zero in both are omitted.
+func WriteTable(w io.Writer, deltas []Delta) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "name\tunit\told\tnew\tdelta\t")
+	for _, d := range deltas {
+		if d.Old.High == 0 && d.New.High == 0 {
+			continue
+		}
+		change := "~"
+		if d.Significant {
+			change = fmt.Sprintf("%+.2f%%", 100*d.Change)
+			if d.Regression() {
+				change += " REGRESSION"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s (p=%.3f n=%d+%d)\t\n",
+			d.Name, d.Unit, formatSummary(d.Old), formatSummary(d.New), change, d.P, d.Old.N, d.New.N)
+	}
+	return tw.Flush()
+}
+
+func formatSummary(s Summary) string {
+	return fmt.Sprintf("%s ± %.0f%%", formatValue(s.Median), 100*s.Spread())
+}
+
+func formatValue(v float64) string {
+	switch {
+	case v >= 1e9:
+		return fmt.Sprintf("%.2fG", v/1e9)
+	case v >= 1e6:
+		return fmt.Sprintf("%.2fM", v/1e6)
+	case v >= 1e4:
+		return fmt.Sprintf("%.2fk", v/1e3)
+	case v >= 100 || v == math.Trunc(v):
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.3g", v)
+}
@@ -0,0 +1,85 @@
+// Package benchreg runs Go benchmarks, keeps their results as a baseline and
+// reports statistically significant regressions against it.
+//
+// Results are compared the way benchstat does: every benchmark is run
+// several times, the median of each metric is reported with a confidence
+// interval, and a Mann-Whitney U test decides whether a change is real or
+// noise.
+package benchreg
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Result is one line of `go test -bench` output.
+type Result struct {
+	Package string
+	Name    string             // Without the -GOMAXPROCS suffix
+	Iters   int                // b.N
+	Values  map[string]float64 // Unit (ns/op, B/op, allocs/op, ...) to value
+	CPU     string             // The "cpu:" line go test printed, if any
+}
+
+// Key identifies a benchmark across runs: "<package>.<name>".
+func (r Result) Key() string {
+	return r.Package + "." + r.Name
+}
+
+var procsSuffix = regexp.MustCompile(`-\d+$`)
+
+// Parse reads benchmark results from go test output. Lines that are not
+// results, such as PASS or log output, are skipped.
+func Parse(r io.Reader) ([]Result, error) {
+	var (
+		results []Result
+		pkg     string
+		cpu     string
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+		if c, ok := strings.CutPrefix(line, "cpu: "); ok {
+			cpu = strings.TrimSpace(c)
+			continue
+		}
+		if res, ok := parseLine(line); ok {
+			res.Package, res.CPU = pkg, cpu
+			results = append(results, res)
+		}
+	}
+	return results, sc.Err()
+}
+
+// parseLine parses "BenchmarkName-8  1000  123 ns/op  0 B/op  0 allocs/op".
+func parseLine(line string) (Result, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || len(fields)%2 != 0 || !strings.HasPrefix(fields[0], "Benchmark") {
+		return Result{}, false
+	}
+	iters, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Result{}, false
+	}
+
+	res := Result{
+		Name:   procsSuffix.ReplaceAllString(fields[0], ""),
+		Iters:  iters,
+		Values: make(map[string]float64),
+	}
+	for i := 2; i+1 < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return Result{}, false
+		}
+		res.Values[fields[i+1]] = v
+	}
+	return res, true
+}
@@ -0,0 +1,55 @@
+package benchreg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+)
+
+// Suite is a set of packages benchmarked from one module directory.
+type Suite struct {
+	Dir      string   // Module directory go test runs in
+	Packages []string // Package patterns relative to Dir, e.g. "./counter"
+}
+
+// RunOptions are the go test flags of a run.
+type RunOptions struct {
+	Bench     string // -bench regexp. Default: "."
+	Count     int    // -count. Default: 10
+	Benchtime string // -benchtime, empty for the go test default
+	// Output, if set, receives the raw go test output as it is produced.
+	Output io.Writer
+}
+
+// Run benchmarks the suite with -benchmem and returns the parsed results.
+func Run(ctx context.Context, s Suite, opts RunOptions) ([]Result, error) {
+	if opts.Bench == "" {
+		opts.Bench = "."
+	}
+	if opts.Count <= 0 {
+		opts.Count = 10
+	}
+
+	args := []string{"test", "-run", "^$", "-bench", opts.Bench, "-benchmem", "-count", strconv.Itoa(opts.Count)}
+	if opts.Benchtime != "" {
+		args = append(args, "-benchtime", opts.Benchtime)
+	}
+	args = append(args, s.Packages...)
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = s.Dir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if opts.Output != nil {
+		cmd.Stdout = io.MultiWriter(&out, opts.Output)
+		cmd.Stderr = cmd.Stdout
+	}
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("benchreg: go test in %s: %w\n%s", s.Dir, err, out.String())
+	}
+	return Parse(&out)
+}
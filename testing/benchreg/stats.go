@@ -0,0 +1,128 @@
+package benchreg
+
+import (
+	"math"
+	"slices"
+)
+
+// Summary describes the samples of one metric.
+type Summary struct {
+	N      int
+	Median float64
+	// Low and High bound the 95% confidence interval of the median. With
+	// fewer than six samples they are the minimum and maximum.
+	Low, High float64
+}
+
+// Spread is the confidence interval as a fraction of the median, the "± x%"
+// benchstat prints.
+func (s Summary) Spread() float64 {
+	if s.Median == 0 {
+		return 0
+	}
+	return math.Max(s.Median-s.Low, s.High-s.Median) / s.Median
+}
+
+// Summarize computes the median and its confidence interval.
+func Summarize(samples []float64) Summary {
+	if len(samples) == 0 {
+		return Summary{}
+	}
+	xs := slices.Clone(samples)
+	slices.Sort(xs)
+	n := len(xs)
+
+	s := Summary{N: n, Median: median(xs), Low: xs[0], High: xs[n-1]}
+	// The interval [x(k), x(n-1-k)] covers the median with probability
+	// 1 - 2*P(Binomial(n, 1/2) <= k); take the largest k keeping that >= 95%
+	for k := 0; k < n/2; k++ {
+		if 2*binomialCDF(n, k) > 0.05 {
+			break
+		}
+		s.Low, s.High = xs[k], xs[n-1-k]
+	}
+	return s
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// binomialCDF returns P(X <= k) for X ~ Binomial(n, 1/2).
+func binomialCDF(n, k int) float64 {
+	var sum float64
+	c := 1.0 // C(n, i)
+	for i := 0; i <= k; i++ {
+		sum += c
+		c = c * float64(n-i) / float64(i+1)
+	}
+	return sum / math.Pow(2, float64(n))
+}
+
+// MannWhitney returns the two-sided p-value of the Mann-Whitney U test that
+// a and b come from the same distribution, using the normal approximation
+// with tie and continuity corrections.
+func MannWhitney(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type obs struct {
+		v     float64
+		first bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range b {
+		all = append(all, obs{v, false})
+	}
+	slices.SortFunc(all, func(x, y obs) int {
+		switch {
+		case x.v < y.v:
+			return -1
+		case x.v > y.v:
+			return 1
+		}
+		return 0
+	})
+
+	// Rank with ties sharing their average rank
+	var rankSum1, tieTerm float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // Ranks i+1..j averaged
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum1 += rank
+			}
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+
+	fn1, fn2 := float64(n1), float64(n2)
+	n := fn1 + fn2
+	u := rankSum1 - fn1*(fn1+1)/2
+	mean := fn1 * fn2 / 2
+	variance := fn1 * fn2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1 // Every observation is equal
+	}
+
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
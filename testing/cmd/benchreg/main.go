@@ -0,0 +1,167 @@
+// Command benchreg runs the repository benchmarks and compares them with the
+// stored baseline, exiting with status 1 on a significant regression.
+//
+// The baseline records the Go version, OS, architecture, CPU and GOMAXPROCS
+// it was taken with. When the current run differs in any of them, the
+// comparison is still printed but only as a warning: the exit status is 0,
+// since a different machine is not a regression. Record a baseline on the
+// machine that runs the check.
+//
+// Run it from the testing module:
+//
+//	go run ./cmd/benchreg                 # compare with the baseline
+//	go run ./cmd/benchreg -bench Sum      # only benchmarks matching Sum
+//	go run ./cmd/benchreg -update         # record a new baseline
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"testing/benchreg"
+)
+
+// suites lists the benchmarked packages per module, relative to the
+// repository root.
+var suites = []benchreg.Suite{
+	{Dir: "testing", Packages: []string{"./test1", "./numeric"}},
+	{Dir: ".", Packages: []string{"./counter", "./map", "./pqueue"}},
+}
+
+func main() {
+	var (
+		baselinePath = flag.String("baseline", "", "baseline file (default <repo>/testing/benchreg/baseline.json)")
+		bench        = flag.String("bench", ".", "run only benchmarks matching this regexp")
+		count        = flag.Int("count", 0, "runs per benchmark (default the baseline's, or 10)")
+		benchtime    = flag.String("benchtime", "", "go test -benchtime (default the baseline's)")
+		update       = flag.Bool("update", false, "store the results as the new baseline instead of comparing")
+		alpha        = flag.Float64("alpha", 0.05, "p-value below which a change is significant")
+		threshold    = flag.Float64("threshold", 0.1, "smallest relative change that fails the run")
+		verbose      = flag.Bool("v", false, "print go test output")
+	)
+	flag.Parse()
+
+	root, err := findRoot()
+	if err != nil {
+		fatal(err)
+	}
+	if *baselinePath == "" {
+		*baselinePath = filepath.Join(root, "testing", "benchreg", "baseline.json")
+	}
+
+	base, err := benchreg.Load(*baselinePath)
+	if err != nil {
+		fatal(err)
+	}
+	// Samples are only comparable when taken with the same flags
+	if *count == 0 {
+		*count = base.Count
+	}
+	if *count == 0 {
+		*count = 10
+	}
+	if *benchtime == "" {
+		*benchtime = base.Benchtime
+	}
+
+	opts := benchreg.RunOptions{Bench: *bench, Count: *count, Benchtime: *benchtime}
+	if *verbose {
+		opts.Output = os.Stderr
+	}
+
+	var results []benchreg.Result
+	for _, s := range suites {
+		s.Dir = filepath.Join(root, s.Dir)
+		fmt.Fprintf(os.Stderr, "Running %s in %s...\n", strings.Join(s.Packages, " "), s.Dir)
+		res, err := benchreg.Run(context.Background(), s, opts)
+		if err != nil {
+			fatal(err)
+		}
+		results = append(results, res...)
+	}
+	if len(results) == 0 {
+		fatal(fmt.Errorf("no benchmarks match %q", *bench))
+	}
+
+	if *update {
+		base.Merge(results, *count, *benchtime)
+		if err := base.Save(*baselinePath); err != nil {
+			fatal(err)
+		}
+		fmt.Printf("Baseline %s updated with %d benchmarks\n", *baselinePath, len(benchreg.Samples(results)))
+		return
+	}
+
+	mismatches := base.Mismatches(benchreg.CurrentEnv(results))
+	for _, m := range mismatches {
+		fmt.Printf("warning: %s\n", m)
+	}
+	if len(mismatches) > 0 {
+		fmt.Print("warning: the baseline was recorded elsewhere, changes below are not regressions\n\n")
+	}
+
+	deltas := benchreg.Compare(base.Benchmarks, benchreg.Samples(results), benchreg.Options{Alpha: *alpha, Threshold: *threshold})
+	if err := benchreg.WriteTable(os.Stdout, deltas); err != nil {
+		fatal(err)
+	}
+	reportMissing(os.Stdout, base, results)
+
+	regressions := 0
+	for _, d := range deltas {
+		if d.Regression() {
+			regressions++
+		}
+	}
+	switch {
+	case regressions > 0 && len(mismatches) > 0:
+		fmt.Printf("\n%d significant change(s), ignored: different environment\n", regressions)
+	case regressions > 0:
+		fmt.Printf("\n%d significant regression(s)\n", regressions)
+		os.Exit(1)
+	}
+}
+
+// reportMissing lists benchmarks that ran but have no baseline yet.
+func reportMissing(w io.Writer, base *benchreg.Baseline, results []benchreg.Result) {
+	var missing []string
+	for key := range benchreg.Samples(results) {
+		if _, ok := base.Benchmarks[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	slices.Sort(missing)
+	for _, key := range missing {
+		fmt.Fprintf(w, "%s: no baseline, run with -update\n", key)
+	}
+}
+
+// findRoot walks up from the working directory to the go-projects module.
+func findRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil && strings.HasPrefix(string(data), "module go-projects\n") {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("benchreg: go-projects module not found above the working directory")
+		}
+		dir = parent
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
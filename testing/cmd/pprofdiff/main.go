@@ -0,0 +1,62 @@
+// Command pprofdiff compares two pprof profiles and prints the functions
+// that got slower or faster, and where allocation counts changed.
+//
+// From the testing module:
+//
+//	go run ./cmd/pprofdiff pprof/cpu_profile.prof pprof/cpu_optimized.prof
+//	go run ./cmd/pprofdiff -sample alloc_space -json before.pprof after.pprof
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"testing/profdiff"
+)
+
+func main() {
+	var opts profdiff.Options
+	flag.StringVar(&opts.SampleType, "sample", "", "sample type to compare (default the profile's, e.g. cpu or inuse_space)")
+	flag.IntVar(&opts.Top, "top", 10, "number of regressions and improvements to show")
+	flag.BoolVar(&opts.Normalize, "normalize", false, "scale the after profile to the before total")
+	asJSON := flag.Bool("json", false, "print JSON instead of text")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] before.pprof after.pprof\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	before, err := profdiff.Load(flag.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	after, err := profdiff.Load(flag.Arg(1))
+	if err != nil {
+		fatal(err)
+	}
+
+	report, err := profdiff.Diff(before, after, opts)
+	if err != nil {
+		fatal(err)
+	}
+
+	if *asJSON {
+		err = profdiff.WriteJSON(os.Stdout, report)
+	} else {
+		err = profdiff.WriteText(os.Stdout, report)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
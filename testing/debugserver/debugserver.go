@@ -0,0 +1,209 @@
+// Package debugserver serves pprof, expvar and runtime information on a
+// dedicated HTTP server.
+//
+// Importing net/http/pprof for its side effect puts the profiling handlers on
+// http.DefaultServeMux, next to every handler the program registers there,
+// and serves them wherever that mux is served. This package builds the
+// profiling handlers on runtime/pprof instead, so importing it registers
+// nothing there, and serves them on its own ServeMux and listener. It can
+// require basic auth or a bearer token, and refuses to listen on a
+// non-loopback address without either.
+//
+// One exception: the expvar package, which /debug/vars needs, registers
+// itself on http.DefaultServeMux when imported. It only exposes the
+// command line, memory statistics and published variables, not profiles.
+//
+// Endpoints:
+//
+//	/debug/pprof/      pprof index, profiles and traces
+//	/debug/vars        expvar
+//	/debug/buildinfo   module and build settings
+//	/debug/goroutines  full goroutine dump
+//	/debug/gc          memory and GC statistics as JSON
+package debugserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+)
+
+// DefaultAddr is the address used by the old pprof3 program.
+const DefaultAddr = "localhost:6060"
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvAddr     = "DEBUG_ADDR" // "off" disables the server
+	EnvToken    = "DEBUG_TOKEN"
+	EnvUser     = "DEBUG_USER"
+	EnvPassword = "DEBUG_PASSWORD"
+)
+
+// ErrInsecure is returned by Run for a non-loopback address without auth.
+var ErrInsecure = errors.New("debugserver: refusing to serve on a non-loopback address without auth")
+
+// Config configures a Server.
+type Config struct {
+	// Addr is the listen address. Empty disables the server: Run returns
+	// nil when ctx is done without listening.
+	Addr string
+
+	// Username and Password enable basic auth when both are set.
+	Username, Password string
+	// Token enables bearer-token auth ("Authorization: Bearer <token>").
+	// With both kinds configured either one is accepted.
+	Token string
+
+	// ShutdownTimeout bounds the graceful shutdown. Default: 5s.
+	ShutdownTimeout time.Duration
+}
+
+// ConfigFromEnv returns a Config with Addr defaulting to DefaultAddr, then
+// overridden by the DEBUG_* environment variables.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Addr:     DefaultAddr,
+		Token:    os.Getenv(EnvToken),
+		Username: os.Getenv(EnvUser),
+		Password: os.Getenv(EnvPassword),
+	}
+	if addr, ok := os.LookupEnv(EnvAddr); ok {
+		cfg.Addr = addr
+		if addr == "off" {
+			cfg.Addr = ""
+		}
+	}
+	return cfg
+}
+
+func (c Config) hasAuth() bool {
+	return c.Token != "" || (c.Username != "" && c.Password != "")
+}
+
+// Server is the debug server. Create it with New.
+type Server struct {
+	cfg     Config
+	handler http.Handler
+}
+
+// New creates a Server.
+func New(cfg Config) *Server {
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 5 * time.Second
+	}
+	s := &Server{cfg: cfg}
+	s.handler = s.authenticate(newMux())
+	return s
+}
+
+// Handler returns the debug endpoints with authentication applied, for
+// mounting in a server of the caller's choice.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Run serves until ctx is done, then shuts down gracefully. It returns nil
+// after a clean shutdown.
+func (s *Server) Run(ctx context.Context) error {
+	if s.cfg.Addr == "" {
+		<-ctx.Done()
+		return nil
+	}
+	if !s.cfg.hasAuth() && !isLoopback(s.cfg.Addr) {
+		return fmt.Errorf("%w: %s", ErrInsecure, s.cfg.Addr)
+	}
+
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("debugserver: %w", err)
+	}
+	return s.serve(ctx, ln)
+}
+
+func (s *Server) serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ln) }()
+	zerolog.Log.Info().Str("addr", ln.Addr().String()).Bool("auth", s.cfg.hasAuth()).Msg("Debug server listening")
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("debugserver: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("debugserver: shutdown: %w", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("debugserver: %w", err)
+	}
+	zerolog.Log.Info().Msg("Debug server stopped")
+	return nil
+}
+
+// authenticate requires a valid token or basic-auth pair if any is configured.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if !s.cfg.hasAuth() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.cfg.Username != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="debug"`)
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.cfg.Token != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && equal(token, s.cfg.Token) {
+			return true
+		}
+	}
+	if s.cfg.Username != "" && s.cfg.Password != "" {
+		user, pass, ok := r.BasicAuth()
+		// Evaluate both comparisons so timing does not reveal which one failed
+		userOK, passOK := equal(user, s.cfg.Username), equal(pass, s.cfg.Password)
+		if ok && userOK && passOK {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// isLoopback reports whether addr only accepts local connections. An empty
+// host (":6060") listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,173 @@
+package debugserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-projects/leakcheck"
+)
+
+func TestEndpoints(t *testing.T) {
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/", http.StatusOK, "/debug/pprof/"},
+		{"/debug/pprof/", http.StatusOK, "goroutine"},
+		{"/debug/pprof/heap?debug=1", http.StatusOK, "heap profile"},
+		{"/debug/vars", http.StatusOK, "memstats"},
+		{"/debug/goroutines", http.StatusOK, "goroutines"},
+		{"/debug/gc", http.StatusOK, "heap_alloc_bytes"},
+		{"/debug/pprof/cmdline", http.StatusOK, "debugserver"},
+		{"/debug/pprof/profile?seconds=0.05", http.StatusOK, ""},
+		{"/debug/pprof/trace?seconds=0.05", http.StatusOK, "go 1."},
+		{"/debug/pprof/nope", http.StatusNotFound, "unknown profile"},
+	}
+
+	handler := New(Config{}).Handler()
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d", rec.Code, tt.wantCode)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body does not contain %q", tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestOwnMux checks both directions of the separation: the debug server
+// routes only to its own handlers, and importing the package puts no
+// profiling handler on http.DefaultServeMux.
+func TestOwnMux(t *testing.T) {
+	handler := New(Config{}).Handler()
+	for _, path := range []string{"/secret", "/api/users", "/debug/unknown"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, rec.Code)
+		}
+	}
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/profile"} {
+		_, pattern := http.DefaultServeMux.Handler(httptest.NewRequest(http.MethodGet, path, nil))
+		if pattern != "" {
+			t.Errorf("DefaultServeMux routes %s to %q", path, pattern)
+		}
+	}
+}
+
+func TestSymbol(t *testing.T) {
+	pc := reflect.ValueOf(TestSymbol).Pointer()
+	rec := httptest.NewRecorder()
+	body := strings.NewReader(fmt.Sprintf("%#x", pc))
+	New(Config{}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/pprof/symbol", body))
+
+	if !strings.Contains(rec.Body.String(), "debugserver.TestSymbol") {
+		t.Errorf("symbol lookup = %q", rec.Body.String())
+	}
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		setup    func(r *http.Request)
+		wantCode int
+	}{
+		{"no auth configured", Config{}, func(r *http.Request) {}, http.StatusOK},
+		{"token ok", Config{Token: "s3cret"}, func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") }, http.StatusOK},
+		{"token wrong", Config{Token: "s3cret"}, func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, http.StatusUnauthorized},
+		{"token missing", Config{Token: "s3cret"}, func(r *http.Request) {}, http.StatusUnauthorized},
+		{"basic ok", Config{Username: "ops", Password: "pw"}, func(r *http.Request) { r.SetBasicAuth("ops", "pw") }, http.StatusOK},
+		{"basic wrong password", Config{Username: "ops", Password: "pw"}, func(r *http.Request) { r.SetBasicAuth("ops", "x") }, http.StatusUnauthorized},
+		{
+			"basic accepted when token also configured",
+			Config{Token: "s3cret", Username: "ops", Password: "pw"},
+			func(r *http.Request) { r.SetBasicAuth("ops", "pw") },
+			http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/gc", nil)
+			tt.setup(req)
+			rec := httptest.NewRecorder()
+			New(tt.cfg).Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status %d, want %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"localhost:6060", true},
+		{"127.0.0.1:6060", true},
+		{"[::1]:6060", true},
+		{":6060", false},
+		{"0.0.0.0:6060", false},
+		{"10.0.0.5:6060", false},
+		{"garbage", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isLoopback(tt.addr); got != tt.want {
+				t.Errorf("isLoopback(%q) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr error
+	}{
+		{"loopback without auth", Config{Addr: "127.0.0.1:0"}, nil},
+		{"all interfaces with token", Config{Addr: ":0", Token: "s3cret"}, nil},
+		{"all interfaces without auth", Config{Addr: ":0"}, ErrInsecure},
+		{"disabled", Config{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- New(tt.cfg).Run(ctx) }()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Run: err = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("Run did not return after the context was done")
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package debugserver
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// pprofIndex serves the named profiles (heap, goroutine, block, ...)
+	// under /debug/pprof/<name>
+	mux.HandleFunc("/debug/pprof/", pprofIndex)
+	mux.HandleFunc("/debug/pprof/cmdline", cmdline)
+	mux.HandleFunc("/debug/pprof/profile", cpuProfile)
+	mux.HandleFunc("/debug/pprof/symbol", symbol)
+	mux.HandleFunc("/debug/pprof/trace", traceProfile)
+
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/buildinfo", buildInfo)
+	mux.HandleFunc("/debug/goroutines", goroutines)
+	mux.HandleFunc("/debug/gc", gcStats)
+	mux.HandleFunc("/{$}", index)
+	return mux
+}
+
+func index(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprint(w, `<html><body><h1>Debug</h1><ul>
+<li><a href="/debug/pprof/">pprof</a></li>
+<li><a href="/debug/vars">expvar</a></li>
+<li><a href="/debug/buildinfo">build info</a></li>
+<li><a href="/debug/goroutines">goroutines</a></li>
+<li><a href="/debug/gc">GC stats</a></li>
+</ul></body></html>`)
+}
+
+func buildInfo(w http.ResponseWriter, _ *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		http.Error(w, "build info not available", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprint(w, info.String())
+}
+
+// goroutines writes the stacks of all goroutines in the same format as an
+// unrecovered panic.
+func goroutines(w http.ResponseWriter, _ *http.Request) {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprintf(w, "%d goroutines\n\n", runtime.NumGoroutine())
+	_, _ = w.Write(buf)
+}
+
+// GCStats is the body of /debug/gc.
+type GCStats struct {
+	Goroutines    int             `json:"goroutines"`
+	HeapAlloc     uint64          `json:"heap_alloc_bytes"`
+	HeapInuse     uint64          `json:"heap_inuse_bytes"`
+	HeapObjects   uint64          `json:"heap_objects"`
+	Sys           uint64          `json:"sys_bytes"`
+	NextGC        uint64          `json:"next_gc_bytes"`
+	NumGC         int64           `json:"num_gc"`
+	LastGC        time.Time       `json:"last_gc"`
+	PauseTotal    time.Duration   `json:"pause_total_ns"`
+	RecentPauses  []time.Duration `json:"recent_pauses_ns"`
+	GCCPUFraction float64         `json:"gc_cpu_fraction"`
+}
+
+func gcStats(w http.ResponseWriter, _ *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+
+	pauses := gc.Pause
+	if len(pauses) > 10 {
+		pauses = pauses[:10] // Most recent first
+	}
+	stats := GCStats{
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapInuse:     mem.HeapInuse,
+		HeapObjects:   mem.HeapObjects,
+		Sys:           mem.Sys,
+		NextGC:        mem.NextGC,
+		NumGC:         gc.NumGC,
+		LastGC:        gc.LastGC,
+		PauseTotal:    gc.PauseTotal,
+		RecentPauses:  pauses,
+		GCCPUFraction: mem.GCCPUFraction,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(stats)
+}
@@ -0,0 +1,134 @@
+package debugserver
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The handlers of net/http/pprof, rebuilt on runtime/pprof: importing
+// net/http/pprof registers them on http.DefaultServeMux, where any program
+// serving that mux would expose them without auth. Delta profiles
+// (?seconds= on a named profile) are not supported.
+
+// pprofIndex lists the profiles, or serves the one named in the path.
+func pprofIndex(w http.ResponseWriter, r *http.Request) {
+	if name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/"); name != "" {
+		namedProfile(w, r, name)
+		return
+	}
+
+	profiles := pprof.Profiles()
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name() < profiles[j].Name() })
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprint(w, "<html><body><h1>/debug/pprof/</h1><table>\n")
+	for _, p := range profiles {
+		name := html.EscapeString(p.Name())
+		_, _ = fmt.Fprintf(w, "<tr><td>%d</td><td><a href=\"%s?debug=1\">%s</a></td></tr>\n", p.Count(), name, name)
+	}
+	_, _ = fmt.Fprint(w, `</table><ul>
+<li><a href="profile?seconds=30">profile</a>: 30s CPU profile</li>
+<li><a href="trace?seconds=1">trace</a>: 1s execution trace</li>
+<li><a href="cmdline">cmdline</a></li>
+</ul></body></html>`)
+}
+
+// namedProfile writes a runtime/pprof profile, as text with ?debug=1 or 2.
+func namedProfile(w http.ResponseWriter, r *http.Request, name string) {
+	p := pprof.Lookup(name)
+	if p == nil {
+		http.Error(w, "unknown profile "+strconv.Quote(name), http.StatusNotFound)
+		return
+	}
+	debug, _ := strconv.Atoi(r.FormValue("debug"))
+	if name == "heap" && r.FormValue("gc") != "" {
+		runtime.GC()
+	}
+
+	if debug != 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	}
+	_ = p.WriteTo(w, debug)
+}
+
+// seconds reads the ?seconds= duration of a profile or trace.
+func seconds(r *http.Request, def time.Duration) time.Duration {
+	if n, err := strconv.ParseFloat(r.FormValue("seconds"), 64); err == nil && n > 0 {
+		return time.Duration(n * float64(time.Second))
+	}
+	return def
+}
+
+// record runs start, waits for d or the client to go away, then runs stop.
+func record(w http.ResponseWriter, r *http.Request, d time.Duration, start func(io.Writer) error, stop func()) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if err := start(w); err != nil {
+		w.Header().Del("Content-Type")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	select {
+	case <-time.After(d):
+	case <-r.Context().Done():
+	}
+	stop()
+}
+
+func cpuProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Disposition", `attachment; filename="profile"`)
+	record(w, r, seconds(r, 30*time.Second), pprof.StartCPUProfile, pprof.StopCPUProfile)
+}
+
+func traceProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Disposition", `attachment; filename="trace"`)
+	record(w, r, seconds(r, time.Second), trace.Start, trace.Stop)
+}
+
+func cmdline(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprint(w, strings.Join(os.Args, "\x00"))
+}
+
+// symbol maps program counters to function names for go tool pprof. The
+// addresses come '+'-separated in the query or the POST body.
+func symbol(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	var buf bytes.Buffer
+	buf.WriteString("num_symbols: 1\n")
+
+	var in *bufio.Reader
+	if r.Method == http.MethodPost {
+		in = bufio.NewReader(io.LimitReader(r.Body, 1<<20))
+	} else {
+		in = bufio.NewReader(strings.NewReader(r.URL.RawQuery))
+	}
+	for {
+		word, err := in.ReadString('+')
+		word = strings.TrimSuffix(word, "+")
+		if pc, perr := strconv.ParseUint(word, 0, 64); perr == nil && pc != 0 {
+			if fn := runtime.FuncForPC(uintptr(pc)); fn != nil {
+				fmt.Fprintf(&buf, "%#x %s\n", pc, fn.Name())
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	_, _ = w.Write(buf.Bytes())
+}
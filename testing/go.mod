@@ -1,3 +1,18 @@
 module testing
 
-go 1.25
+go 1.25.0
+
+require (
+	github.com/cooler-SAI/go-Tools v0.0.8
+	github.com/google/pprof v0.0.0-20260906184651-6331bc6350fe
+	go-projects v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/rs/zerolog v1.34.0 // indirect
+	golang.org/x/sys v0.36.0 // indirect
+)
+
+replace go-projects => ..
@@ -0,0 +1,289 @@
+// Package leakwatch detects memory and goroutine leaks in long-running
+// programs while they run.
+//
+// A Watchdog samples runtime/metrics at a fixed interval and fits a straight
+// line through the last samples with least squares. When the live heap or
+// the goroutine count keeps growing faster than the configured rate, and the
+// line fits well enough to be a trend rather than noise, it raises an Alert
+// and writes a heap profile showing where the memory is held.
+package leakwatch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+)
+
+// Metrics sampled by the watchdog. The live heap is measured by the GC, so
+// it only changes when a GC cycle completes.
+const (
+	metricHeapLive   = "/gc/heap/live:bytes"
+	metricGoroutines = "/sched/goroutines:goroutines"
+	metricGCCycles   = "/gc/cycles/total:gc-cycles"
+)
+
+// Config configures a Watchdog. Zero values are replaced by defaults.
+type Config struct {
+	// Interval between samples. Default: 1s.
+	Interval time.Duration
+	// Window is the number of recent samples the trend is fitted to.
+	// Default: 30.
+	Window int
+	// MinSamples is how many samples are needed before checking. Default:
+	// Window/2, at least 3.
+	MinSamples int
+
+	// HeapMBPerMinute is the live-heap growth that raises an alert.
+	// Default: 10. Negative disables the heap check.
+	HeapMBPerMinute float64
+	// GoroutinesPerMinute is the goroutine growth that raises an alert.
+	// Default: 60. Negative disables the goroutine check.
+	GoroutinesPerMinute float64
+	// MinFit is the least R² (0..1) of the fitted line for an alert, so a
+	// single spike does not count as a trend. Default: 0.8.
+	MinFit float64
+
+	// DumpDir receives a heap profile for every heap alert. Empty: no dump.
+	DumpDir string
+	// Cooldown is the least time between two alerts of the same metric.
+	// Default: 5m.
+	Cooldown time.Duration
+	// OnAlert is called for every alert, in the watchdog's goroutine.
+	OnAlert func(Alert)
+}
+
+func (c Config) withDefaults() Config {
+	if c.Interval <= 0 {
+		c.Interval = time.Second
+	}
+	if c.Window < 3 {
+		c.Window = 30
+	}
+	if c.MinSamples <= 0 {
+		c.MinSamples = c.Window / 2
+	}
+	if c.MinSamples < 3 {
+		c.MinSamples = 3
+	}
+	if c.HeapMBPerMinute == 0 {
+		c.HeapMBPerMinute = 10
+	}
+	if c.GoroutinesPerMinute == 0 {
+		c.GoroutinesPerMinute = 60
+	}
+	if c.MinFit <= 0 {
+		c.MinFit = 0.8
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 5 * time.Minute
+	}
+	return c
+}
+
+// Sample is one reading of the runtime metrics.
+type Sample struct {
+	Time       time.Time
+	HeapLive   uint64
+	Goroutines uint64
+	GCCycles   uint64
+}
+
+// Alert reports a metric growing faster than allowed.
+type Alert struct {
+	Metric string // "heap" or "goroutines"
+	// PerMinute is the fitted growth in MB (heap) or goroutines per minute.
+	PerMinute float64
+	Fit       float64 // R² of the fitted line
+	Samples   int
+	Latest    Sample
+	// Profile is the path of the heap profile written for the alert.
+	Profile string
+}
+
+func (a Alert) String() string {
+	unit := "goroutines"
+	if a.Metric == "heap" {
+		unit = "MB"
+	}
+	return fmt.Sprintf("%s growing by %.1f %s/min (R²=%.2f over %d samples)", a.Metric, a.PerMinute, unit, a.Fit, a.Samples)
+}
+
+// Watchdog samples metrics and raises alerts. Create it with New.
+type Watchdog struct {
+	cfg Config
+
+	mu      sync.Mutex
+	samples []Sample // Ring of the last Window samples, oldest first
+	alerts  []Alert
+	last    map[string]time.Time
+}
+
+// New creates a Watchdog.
+func New(cfg Config) *Watchdog {
+	return &Watchdog{cfg: cfg.withDefaults(), last: make(map[string]time.Time)}
+}
+
+// Run samples every Interval until ctx is done. It returns nil.
+func (w *Watchdog) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.Check(ReadSample())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Alerts returns the alerts raised so far.
+func (w *Watchdog) Alerts() []Alert {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Alert(nil), w.alerts...)
+}
+
+// ReadSample reads the current metrics.
+func ReadSample() Sample {
+	s := []metrics.Sample{{Name: metricHeapLive}, {Name: metricGoroutines}, {Name: metricGCCycles}}
+	metrics.Read(s)
+	return Sample{
+		Time:       time.Now(),
+		HeapLive:   s[0].Value.Uint64(),
+		Goroutines: s[1].Value.Uint64(),
+		GCCycles:   s[2].Value.Uint64(),
+	}
+}
+
+// Check adds a sample and returns the alerts it raised. Run calls it on
+// every tick; tests can feed samples directly.
+func (w *Watchdog) Check(s Sample) []Alert {
+	w.mu.Lock()
+	w.samples = append(w.samples, s)
+	if len(w.samples) > w.cfg.Window {
+		w.samples = w.samples[len(w.samples)-w.cfg.Window:]
+	}
+	window := append([]Sample(nil), w.samples...)
+	w.mu.Unlock()
+
+	if len(window) < w.cfg.MinSamples {
+		return nil
+	}
+
+	var raised []Alert
+	// The live heap is only updated by GC; without two cycles in the
+	// window the flat line says nothing
+	if w.cfg.HeapMBPerMinute > 0 && window[len(window)-1].GCCycles-window[0].GCCycles >= 2 {
+		slope, fit := trend(window, func(s Sample) float64 { return float64(s.HeapLive) / (1 << 20) })
+		if perMin := slope * 60; perMin > w.cfg.HeapMBPerMinute && fit >= w.cfg.MinFit {
+			if a, ok := w.raise("heap", perMin, fit, window); ok {
+				raised = append(raised, a)
+			}
+		}
+	}
+	if w.cfg.GoroutinesPerMinute > 0 {
+		slope, fit := trend(window, func(s Sample) float64 { return float64(s.Goroutines) })
+		if perMin := slope * 60; perMin > w.cfg.GoroutinesPerMinute && fit >= w.cfg.MinFit {
+			if a, ok := w.raise("goroutines", perMin, fit, window); ok {
+				raised = append(raised, a)
+			}
+		}
+	}
+	return raised
+}
+
+func (w *Watchdog) raise(metric string, perMin, fit float64, window []Sample) (Alert, bool) {
+	latest := window[len(window)-1]
+
+	w.mu.Lock()
+	if last, ok := w.last[metric]; ok && latest.Time.Sub(last) < w.cfg.Cooldown {
+		w.mu.Unlock()
+		return Alert{}, false
+	}
+	w.last[metric] = latest.Time
+	w.mu.Unlock()
+
+	a := Alert{Metric: metric, PerMinute: perMin, Fit: fit, Samples: len(window), Latest: latest}
+	if metric == "heap" && w.cfg.DumpDir != "" {
+		path, err := dumpHeap(w.cfg.DumpDir)
+		if err != nil {
+			zerolog.Log.Error().Err(err).Msg("Could not write heap profile for leak alert")
+		}
+		a.Profile = path
+	}
+
+	zerolog.Log.Warn().
+		Str("metric", metric).
+		Float64("per_minute", perMin).
+		Float64("fit", fit).
+		Uint64("heap_live_mb", latest.HeapLive>>20).
+		Uint64("goroutines", latest.Goroutines).
+		Str("profile", a.Profile).
+		Msg("Possible leak detected")
+
+	w.mu.Lock()
+	w.alerts = append(w.alerts, a)
+	w.mu.Unlock()
+	if w.cfg.OnAlert != nil {
+		w.cfg.OnAlert(a)
+	}
+	return a, true
+}
+
+// trend fits value(t) = a + slope*t by least squares, t in seconds since the
+// first sample, and returns the slope per second and the R² of the fit.
+func trend(samples []Sample, value func(Sample) float64) (slope, fit float64) {
+	n := float64(len(samples))
+	var sumX, sumY, sumXY, sumXX, sumYY float64
+	for _, s := range samples {
+		x := s.Time.Sub(samples[0].Time).Seconds()
+		y := value(s)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+		sumYY += y * y
+	}
+
+	varX := n*sumXX - sumX*sumX
+	varY := n*sumYY - sumY*sumY
+	if varX == 0 {
+		return 0, 0
+	}
+	cov := n*sumXY - sumX*sumY
+	slope = cov / varX
+	if varY == 0 {
+		return slope, 1 // A flat line fits perfectly
+	}
+	return slope, cov * cov / (varX * varY)
+}
+
+func dumpHeap(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "heap-"+time.Now().Format("20060102-150405.000")+"-leak.pprof")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	runtime.GC()
+	err = pprof.Lookup("heap").WriteTo(f, 0)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,141 @@
+package leakwatch
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// series builds samples one second apart from the given heap sizes in MB,
+// with a GC cycle between every two samples.
+func series(heapMB []float64, goroutines []uint64) []Sample {
+	start := time.Unix(0, 0)
+	out := make([]Sample, len(heapMB))
+	for i, mb := range heapMB {
+		out[i] = Sample{
+			Time:     start.Add(time.Duration(i) * time.Second),
+			HeapLive: uint64(mb * (1 << 20)),
+			GCCycles: uint64(i),
+		}
+		if goroutines != nil {
+			out[i].Goroutines = goroutines[i]
+		}
+	}
+	return out
+}
+
+func linear(n int, start, step float64) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = start + step*float64(i)
+	}
+	return out
+}
+
+func TestTrend(t *testing.T) {
+	tests := []struct {
+		name      string
+		heapMB    []float64
+		wantSlope float64 // MB per second
+		minFit    float64
+		maxFit    float64
+	}{
+		{"steady growth", linear(10, 100, 2), 2, 0.999, 1},
+		{"flat", linear(10, 50, 0), 0, 1, 1},
+		{"single spike", []float64{50, 50, 50, 50, 90, 50, 50, 50, 50, 50}, 0, 0, 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slope, fit := trend(series(tt.heapMB, nil), func(s Sample) float64 { return float64(s.HeapLive) / (1 << 20) })
+			if math.Abs(slope-tt.wantSlope) > 0.5 {
+				t.Errorf("slope = %.3f, want %.3f", slope, tt.wantSlope)
+			}
+			if fit < tt.minFit || fit > tt.maxFit {
+				t.Errorf("fit = %.3f, want in [%.3f, %.3f]", fit, tt.minFit, tt.maxFit)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	flat := make([]uint64, 10)
+	for i := range flat {
+		flat[i] = 5
+	}
+	growing := make([]uint64, 10)
+	for i := range growing {
+		growing[i] = 5 + 3*uint64(i) // 180 per minute
+	}
+
+	tests := []struct {
+		name    string
+		samples []Sample
+		want    []string
+	}{
+		{"heap leak", series(linear(10, 100, 1), flat), []string{"heap"}}, // 60 MB/min
+		{"slow growth", series(linear(10, 100, 0.1), flat), nil},          // 6 MB/min
+		{"goroutine leak", series(linear(10, 100, 0), growing), []string{"goroutines"}},
+		{"noisy heap", series([]float64{100, 140, 90, 150, 95, 130, 100, 160, 105, 120}, flat), nil},
+		{
+			name: "no GC in window",
+			samples: func() []Sample {
+				s := series(linear(10, 100, 1), flat)
+				for i := range s {
+					s[i].GCCycles = 7
+				}
+				return s
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called []string
+			w := New(Config{
+				Window:  10,
+				OnAlert: func(a Alert) { called = append(called, a.Metric) },
+			})
+			for _, s := range tt.samples {
+				w.Check(s)
+			}
+
+			if len(called) != len(tt.want) {
+				t.Fatalf("alerts = %v, want %v", called, tt.want)
+			}
+			for i := range called {
+				if called[i] != tt.want[i] {
+					t.Errorf("alert %d = %q, want %q", i, called[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	w := New(Config{Window: 5, MinSamples: 5, Cooldown: time.Minute})
+
+	// 100 samples, one second apart, all growing: one alert per minute
+	for _, s := range series(linear(100, 100, 1), nil) {
+		w.Check(s)
+	}
+	if got := len(w.Alerts()); got != 2 {
+		t.Fatalf("alerts = %d, want 2", got)
+	}
+}
+
+func TestDumpHeap(t *testing.T) {
+	dir := t.TempDir()
+	w := New(Config{Window: 5, DumpDir: dir})
+
+	var alerts []Alert
+	for _, s := range series(linear(5, 100, 1), nil) {
+		alerts = append(alerts, w.Check(s)...)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("alerts = %d, want 1", len(alerts))
+	}
+	if alerts[0].Profile == "" {
+		t.Fatal("no heap profile written")
+	}
+}
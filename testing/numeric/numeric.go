@@ -0,0 +1,180 @@
+// Package numeric computes sums and summary statistics over large numeric
+// slices of any integer or float type.
+//
+// Small inputs are summed in an unrolled loop with independent accumulators,
+// which the CPU can pipeline; inputs of ParallelThreshold elements or more
+// are split across GOMAXPROCS goroutines. Floats use Kahan summation, so long
+// slices do not accumulate rounding error, and SumChecked reports integer
+// overflow instead of wrapping around.
+package numeric
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// Integer matches every integer type, like golang.org/x/exp/constraints.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float matches every floating-point type.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number matches every integer and float type.
+type Number interface {
+	Integer | Float
+}
+
+// ParallelThreshold is the slice length from which work is split across
+// goroutines. Below it starting goroutines costs more than it saves; see
+// BenchmarkSum for the crossover on the current machine.
+var ParallelThreshold = 1 << 16
+
+// ErrOverflow is returned by SumChecked when the sum does not fit in T.
+var ErrOverflow = errors.New("numeric: integer overflow")
+
+// Sum returns the sum of xs. Integer sums wrap around on overflow like the
+// + operator; use SumChecked to detect it.
+func Sum[T Number](xs []T) T {
+	if isFloat[T]() {
+		s := parallel(xs, kahan[T], func(a, b compensated[T]) compensated[T] { return a.add(b.sum).add(b.c) })
+		return s.sum + s.c
+	}
+	return parallel(xs, sumUnrolled[T], func(a, b T) T { return a + b })
+}
+
+// SumChecked returns the sum of xs, or ErrOverflow if it does not fit in T.
+// Intermediate wrap-arounds that cancel out, as in {MaxInt, 1, -1}, are not
+// an error, so the result does not depend on how the slice was split.
+func SumChecked[T Integer](xs []T) (T, error) {
+	s := parallel(xs,
+		func(chunk []T) checked[T] {
+			var c checked[T]
+			for _, x := range chunk {
+				c = c.add(x)
+			}
+			return c
+		},
+		func(a, b checked[T]) checked[T] {
+			a = a.add(b.sum)
+			a.wraps += b.wraps
+			return a
+		})
+	if s.wraps != 0 {
+		return 0, ErrOverflow
+	}
+	return s.sum, nil
+}
+
+// checked is a wrapping sum that counts how often it wrapped: +1 past the
+// maximum, -1 past the minimum. The true sum is sum + wraps*2^bits.
+type checked[T Integer] struct {
+	sum   T
+	wraps int
+}
+
+func (c checked[T]) add(x T) checked[T] {
+	s := c.sum + x
+	switch {
+	case x > 0 && s < c.sum:
+		c.wraps++
+	case x < 0 && s > c.sum:
+		c.wraps--
+	}
+	c.sum = s
+	return c
+}
+
+// sumUnrolled adds four elements per iteration into independent
+// accumulators, so the additions do not wait on each other.
+func sumUnrolled[T Number](xs []T) T {
+	var s0, s1, s2, s3 T
+	i := 0
+	for ; i+4 <= len(xs); i += 4 {
+		s0 += xs[i]
+		s1 += xs[i+1]
+		s2 += xs[i+2]
+		s3 += xs[i+3]
+	}
+	for ; i < len(xs); i++ {
+		s0 += xs[i]
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// compensated is a Kahan sum: c holds the low-order bits lost from sum.
+type compensated[T Number] struct {
+	sum, c T
+}
+
+// add is Neumaier's variant of Kahan summation, which stays exact when x is
+// larger than the running sum.
+func (k compensated[T]) add(x T) compensated[T] {
+	t := k.sum + x
+	if abs(k.sum) >= abs(x) {
+		k.c += (k.sum - t) + x
+	} else {
+		k.c += (x - t) + k.sum
+	}
+	k.sum = t
+	return k
+}
+
+func kahan[T Number](xs []T) compensated[T] {
+	var k compensated[T]
+	for _, x := range xs {
+		k = k.add(x)
+	}
+	// Keep the compensation apart: folding it into sum here would round it
+	// away before the chunks are combined
+	return k
+}
+
+// parallel applies fn to xs directly below ParallelThreshold, and otherwise
+// to one chunk per CPU, combining the results in order.
+func parallel[T Number, R any](xs []T, fn func([]T) R, combine func(R, R) R) R {
+	workers := min(runtime.GOMAXPROCS(0), len(xs)/max(ParallelThreshold/4, 1))
+	if len(xs) < ParallelThreshold || workers < 2 {
+		return fn(xs)
+	}
+
+	// Rounding size up can leave fewer non-empty chunks than workers
+	size := (len(xs) + workers - 1) / workers
+	workers = (len(xs) + size - 1) / size
+	results := make([]R, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		chunk := xs[w*size : min((w+1)*size, len(xs))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[w] = fn(chunk)
+		}()
+	}
+	wg.Wait()
+
+	out := results[0]
+	for _, r := range results[1:] {
+		out = combine(out, r)
+	}
+	return out
+}
+
+// isFloat reports whether T is a float type: only there is 1/2 not zero.
+func isFloat[T Number]() bool {
+	var x T = 1
+	x /= 2
+	return x != 0
+}
+
+func abs[T Number](x T) T {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
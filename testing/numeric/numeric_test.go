@@ -0,0 +1,280 @@
+package numeric
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"runtime"
+	"testing"
+
+	"testing/test1"
+)
+
+// withThreshold runs fn with ParallelThreshold set to n.
+func withThreshold(t testing.TB, n int) {
+	old := ParallelThreshold
+	ParallelThreshold = n
+	t.Cleanup(func() { ParallelThreshold = old })
+}
+
+func seq(n int) []int {
+	xs := make([]int, n)
+	for i := range xs {
+		xs[i] = i
+	}
+	return xs
+}
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		name      string
+		xs        []int
+		threshold int
+		want      int
+	}{
+		{"empty", nil, 1 << 16, 0},
+		{"fewer than unroll", []int{1, 2, 3}, 1 << 16, 6},
+		{"unrolled with tail", seq(1003), 1 << 16, 1003 * 1002 / 2},
+		{"negative", []int{-1, -2, -3, 10}, 1 << 16, 4},
+		{"parallel", seq(100_000), 1000, 100_000 * 99_999 / 2},
+		{"parallel uneven", seq(100_003), 7, 100_003 * 100_002 / 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withThreshold(t, tt.threshold)
+			if got := Sum(tt.xs); got != tt.want {
+				t.Errorf("Sum = %d, want %d", got, tt.want)
+			}
+			if got := test1.Sum(tt.xs); got != tt.want {
+				t.Errorf("test1.Sum = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+type celsius float32
+
+func TestSumTypes(t *testing.T) {
+	if got := Sum([]uint8{200, 50, 10}); got != 4 {
+		t.Errorf("uint8 sum = %d, want 4 (wrapped)", got)
+	}
+	if got := Sum([]int64{math.MaxInt32, math.MaxInt32}); got != 2*math.MaxInt32 {
+		t.Errorf("int64 sum = %d", got)
+	}
+	if got := Sum([]celsius{20.5, 1.25}); got != 21.75 {
+		t.Errorf("celsius sum = %v, want 21.75", got)
+	}
+	if !isFloat[celsius]() || isFloat[uint]() {
+		t.Error("isFloat does not tell floats from integers")
+	}
+}
+
+func TestSumKahan(t *testing.T) {
+	// One large value and many small ones: naive summation drops every 1
+	xs := []float64{1e16}
+	for range 10_000 {
+		xs = append(xs, 1)
+	}
+	xs = append(xs, -1e16)
+
+	for _, threshold := range []int{1 << 16, 100} {
+		t.Run(fmt.Sprint("threshold ", threshold), func(t *testing.T) {
+			withThreshold(t, threshold)
+			if got := Sum(xs); got != 10_000 {
+				t.Errorf("Sum = %v, want 10000", got)
+			}
+		})
+	}
+
+	var naive float64
+	for _, x := range xs {
+		naive += x
+	}
+	if naive == 10_000 {
+		t.Error("naive sum is exact; the test does not exercise compensation")
+	}
+}
+
+func TestSumChecked(t *testing.T) {
+	tests := []struct {
+		name string
+		xs   []int8
+		want int8
+		err  error
+	}{
+		{"fits", []int8{100, 20, 7}, 127, nil},
+		{"overflow", []int8{100, 20, 8}, 0, ErrOverflow},
+		{"underflow", []int8{-100, -20, -9}, 0, ErrOverflow},
+		{"wraps cancel out", []int8{127, 1, -1}, 127, nil},
+		{"twice around", []int8{127, 127, 127, 127, -1}, 0, ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		for _, threshold := range []int{1 << 16, 1} {
+			t.Run(fmt.Sprintf("%s/threshold %d", tt.name, threshold), func(t *testing.T) {
+				withThreshold(t, threshold)
+				got, err := SumChecked(tt.xs)
+				if got != tt.want || err != tt.err {
+					t.Errorf("SumChecked = %d, %v; want %d, %v", got, err, tt.want, tt.err)
+				}
+			})
+		}
+	}
+
+	if _, err := SumChecked([]uint16{math.MaxUint16, 1}); err != ErrOverflow {
+		t.Errorf("unsigned overflow: err = %v", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+	}{
+		{"serial", 1 << 16},
+		{"parallel", 4},
+	}
+
+	xs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withThreshold(t, tt.threshold)
+
+			lo, hi, ok := MinMax(xs)
+			if !ok || lo != 2 || hi != 9 {
+				t.Errorf("MinMax = %v, %v, %v", lo, hi, ok)
+			}
+			if got := Mean(xs); got != 5 {
+				t.Errorf("Mean = %v, want 5", got)
+			}
+			if got := Variance(xs); math.Abs(got-32.0/7) > 1e-12 {
+				t.Errorf("Variance = %v, want %v", got, 32.0/7)
+			}
+			if got := StdDev(xs); math.Abs(got-math.Sqrt(32.0/7)) > 1e-12 {
+				t.Errorf("StdDev = %v", got)
+			}
+		})
+	}
+}
+
+// TestChunking runs the parallel paths with CPU counts that don't divide
+// the input evenly, which once left empty chunks behind.
+func TestChunking(t *testing.T) {
+	withThreshold(t, 4)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	xs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	for procs := 1; procs <= 9; procs++ {
+		t.Run(fmt.Sprintf("GOMAXPROCS=%d", procs), func(t *testing.T) {
+			runtime.GOMAXPROCS(procs)
+
+			if lo, hi, ok := MinMax(xs); !ok || lo != 2 || hi != 9 {
+				t.Errorf("MinMax = %v, %v, %v", lo, hi, ok)
+			}
+			if got := Sum(xs); got != 40 {
+				t.Errorf("Sum = %v, want 40", got)
+			}
+			if got := Mean(xs); got != 5 {
+				t.Errorf("Mean = %v, want 5", got)
+			}
+			if got := Variance(xs); math.Abs(got-32.0/7) > 1e-12 {
+				t.Errorf("Variance = %v, want %v", got, 32.0/7)
+			}
+		})
+	}
+}
+
+func TestStatsEdgeCases(t *testing.T) {
+	if _, _, ok := MinMax([]int(nil)); ok {
+		t.Error("MinMax(nil) ok")
+	}
+	if !math.IsNaN(Mean([]int(nil))) {
+		t.Error("Mean(nil) is not NaN")
+	}
+	if !math.IsNaN(Variance([]int{1})) {
+		t.Error("Variance of one element is not NaN")
+	}
+	// The mean of ints is computed in float64, so it does not overflow
+	if got := Mean([]int8{127, 127}); got != 127 {
+		t.Errorf("Mean = %v, want 127", got)
+	}
+	// Large offset, small spread: the naive sum-of-squares formula
+	// cancels catastrophically here
+	if got := Variance([]float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16}); math.Abs(got-30) > 1e-6 {
+		t.Errorf("Variance = %v, want 30", got)
+	}
+}
+
+// FuzzSum checks Sum and SumChecked against the naive test1.Sum, serially
+// and split across goroutines.
+func FuzzSum(f *testing.F) {
+	f.Add([]byte{}, uint16(0))
+	f.Add([]byte{1, 2, 3, 4, 5, 6, 7, 8}, uint16(1))
+	f.Add(binary.LittleEndian.AppendUint64(binary.LittleEndian.AppendUint64(nil, math.MaxInt64), 1), uint16(1))
+
+	f.Fuzz(func(t *testing.T, data []byte, threshold uint16) {
+		withThreshold(t, int(threshold))
+
+		xs := make([]int, 0, len(data)/8)
+		for ; len(data) >= 8; data = data[8:] {
+			xs = append(xs, int(binary.LittleEndian.Uint64(data)))
+		}
+
+		want := test1.Sum(xs)
+		if got := Sum(xs); got != want {
+			t.Fatalf("Sum = %d, test1.Sum = %d", got, want)
+		}
+
+		exact := new(big.Int)
+		for _, x := range xs {
+			exact.Add(exact, big.NewInt(int64(x)))
+		}
+		got, err := SumChecked(xs)
+		switch {
+		case exact.IsInt64() && err != nil:
+			t.Fatalf("SumChecked: %v, exact sum %s fits", err, exact)
+		case exact.IsInt64() && got != want:
+			t.Fatalf("SumChecked = %d, want %d", got, want)
+		case !exact.IsInt64() && err != ErrOverflow:
+			t.Fatalf("SumChecked = %d, %v; exact sum %s overflows", got, err, exact)
+		}
+	})
+}
+
+// BenchmarkSum compares the naive loop with the unrolled and parallel
+// versions around ParallelThreshold.
+func BenchmarkSum(b *testing.B) {
+	for _, n := range []int{1 << 10, 1 << 14, 1 << 16, 1 << 18, 1 << 20} {
+		xs := seq(n)
+		b.Run(fmt.Sprintf("n=%d/naive", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				test1.Sum(xs)
+			}
+		})
+		b.Run(fmt.Sprintf("n=%d/unrolled", n), func(b *testing.B) {
+			withThreshold(b, math.MaxInt)
+			for i := 0; i < b.N; i++ {
+				Sum(xs)
+			}
+		})
+		b.Run(fmt.Sprintf("n=%d/parallel", n), func(b *testing.B) {
+			withThreshold(b, 0)
+			for i := 0; i < b.N; i++ {
+				Sum(xs)
+			}
+		})
+	}
+}
+
+func BenchmarkSumFloat(b *testing.B) {
+	xs := make([]float64, 1<<20)
+	for i := range xs {
+		xs[i] = float64(i) / 3
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Sum(xs)
+	}
+}
@@ -0,0 +1,97 @@
+package numeric
+
+import "math"
+
+// MinMax returns the smallest and largest element of xs, or ok false for an
+// empty slice. For floats, NaN in xs makes both results NaN.
+func MinMax[T Number](xs []T) (lo, hi T, ok bool) {
+	if len(xs) == 0 {
+		return lo, hi, false
+	}
+	type bounds struct {
+		lo, hi T
+		ok     bool // False for an empty chunk
+	}
+	b := parallel(xs,
+		func(chunk []T) bounds {
+			if len(chunk) == 0 {
+				return bounds{}
+			}
+			b := bounds{chunk[0], chunk[0], true}
+			for _, x := range chunk[1:] {
+				b.lo = min(b.lo, x)
+				b.hi = max(b.hi, x)
+			}
+			return b
+		},
+		func(a, b bounds) bounds {
+			if !a.ok || !b.ok {
+				if a.ok {
+					return a
+				}
+				return b
+			}
+			return bounds{min(a.lo, b.lo), max(a.hi, b.hi), true}
+		})
+	return b.lo, b.hi, true
+}
+
+// Mean returns the arithmetic mean of xs as float64, NaN for an empty slice.
+// Integers are converted before summing, so the mean of an int slice never
+// overflows.
+func Mean[T Number](xs []T) float64 {
+	if len(xs) == 0 {
+		return math.NaN()
+	}
+	k := parallel(xs,
+		func(chunk []T) compensated[float64] {
+			var k compensated[float64]
+			for _, x := range chunk {
+				k = k.add(float64(x))
+			}
+			return k
+		},
+		func(a, b compensated[float64]) compensated[float64] { return a.add(b.sum).add(b.c) })
+	return (k.sum + k.c) / float64(len(xs))
+}
+
+// Variance returns the sample variance of xs (divided by n-1), NaN for
+// fewer than two elements. It uses Welford's single-pass algorithm and
+// merges chunks with Chan's formula, so it stays accurate for large values
+// with a small spread.
+func Variance[T Number](xs []T) float64 {
+	if len(xs) < 2 {
+		return math.NaN()
+	}
+	type moments struct {
+		n    float64
+		mean float64
+		m2   float64 // Sum of squared deviations from mean
+	}
+	m := parallel(xs,
+		func(chunk []T) moments {
+			var m moments
+			for _, x := range chunk {
+				m.n++
+				d := float64(x) - m.mean
+				m.mean += d / m.n
+				m.m2 += d * (float64(x) - m.mean)
+			}
+			return m
+		},
+		func(a, b moments) moments {
+			n := a.n + b.n
+			d := b.mean - a.mean
+			return moments{
+				n:    n,
+				mean: a.mean + d*b.n/n,
+				m2:   a.m2 + b.m2 + d*d*a.n*b.n/n,
+			}
+		})
+	return m.m2 / (m.n - 1)
+}
+
+// StdDev returns the sample standard deviation of xs.
+func StdDev[T Number](xs []T) float64 {
+	return math.Sqrt(Variance(xs))
+}
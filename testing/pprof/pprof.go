@@ -1,10 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"os"
-	"runtime/pprof"
 	"strings"
+
+	"testing/profiling"
 )
 
 func processStringConcatenationsWithBuilder(count int) string {
@@ -17,28 +18,29 @@ func processStringConcatenationsWithBuilder(count int) string {
 }
 
 func main() {
-	cpuProfileFile, err := os.Create("./pprof/cpu_optimized.prof")
-	if err != nil {
-		panic(err)
+	// CPU by default; -profile=all or PROFILE=cpu,heap,trace selects more
+	cfg := profiling.Config{Kinds: []profiling.Kind{profiling.CPU}}
+	if err := profiling.RegisterFlags(flag.CommandLine, &cfg); err != nil {
+		fmt.Println("Invalid profiling settings:", err)
+		return
 	}
-	defer func(cpuProfileFile *os.File) {
-		err := cpuProfileFile.Close()
-		if err != nil {
-			fmt.Println("Could not close CPU profile file:", err)
-		}
-	}(cpuProfileFile)
+	flag.Parse()
 
-	err2 := pprof.StartCPUProfile(cpuProfileFile)
-	if err2 != nil {
-		fmt.Println("Could not start CPU profile:", err2)
+	prof, err := profiling.Start(cfg)
+	if err != nil {
+		fmt.Println("Could not start profiling:", err)
 		return
 	}
-	defer pprof.StopCPUProfile()
+	defer func(prof *profiling.Profiler) {
+		err := prof.Stop()
+		if err != nil {
+			fmt.Println("Could not stop profiling:", err)
+		}
+	}(prof)
 
 	fmt.Println("Starting performance-intensive task (optimized)...")
 
 	processStringConcatenationsWithBuilder(1000000)
 
-	fmt.Println("Task finished. Profile data written to cpu_optimized.prof")
-
+	fmt.Println("Task finished. Profile data written to", cfg.Dir)
 }
@@ -1,10 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"os"
-	"runtime/pprof"
 	"strings"
+
+	"testing/profiling"
 )
 
 func processStringConcatenationsWithBuilder(count int) string {
@@ -18,27 +19,29 @@ func processStringConcatenationsWithBuilder(count int) string {
 }
 
 func main() {
+	// CPU by default; -profile=all or PROFILE=cpu,heap,trace selects more
+	cfg := profiling.Config{Kinds: []profiling.Kind{profiling.CPU}}
+	if err := profiling.RegisterFlags(flag.CommandLine, &cfg); err != nil {
+		fmt.Println("Invalid profiling settings:", err)
+		return
+	}
+	flag.Parse()
 
-	cpuProfileFile, err := os.Create("./pprof/pprof2/cpu_optimized.prof")
+	prof, err := profiling.Start(cfg)
 	if err != nil {
-		panic(err)
+		fmt.Println("Could not start profiling:", err)
+		return
 	}
-	defer func(cpuProfileFile *os.File) {
-		err := cpuProfileFile.Close()
+	defer func(prof *profiling.Profiler) {
+		err := prof.Stop()
 		if err != nil {
-			fmt.Println("Could not close CPU profile file:", err)
+			fmt.Println("Could not stop profiling:", err)
 		}
-	}(cpuProfileFile)
+	}(prof)
 
-	err = pprof.StartCPUProfile(cpuProfileFile)
-	if err != nil {
-		panic(err)
-	}
-	defer pprof.StopCPUProfile()
 	fmt.Println("Starting performance-intensive task (optimized)...")
 
 	processStringConcatenationsWithBuilder(50000000)
 
-	fmt.Println("Task finished. Profile data written to cpu_optimized.prof")
-
+	fmt.Println("Task finished. Profile data written to", cfg.Dir)
 }
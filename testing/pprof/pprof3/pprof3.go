@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
-	_ "net/http/pprof" // Automatically registers pprof handlers with http.DefaultServeMux
 	"time"
+
+	"go-projects/lifecycle"
+	"testing/debugserver"
+	"testing/leakwatch"
 )
 
 // CPU-intensive function that performs useless calculations to consume CPU time
@@ -20,19 +25,30 @@ func memoryIntensiveTask() []byte {
 }
 
 func main() {
-	// Start pprof server in a separate goroutine
-	// This allows profiling the application without blocking the main thread
-	go func() {
-		// pprof will be available at http://localhost:6060/debug/pprof/
-		log.Println(http.ListenAndServe("localhost:6060", nil))
-	}()
+	// DEBUG_ADDR, DEBUG_TOKEN, DEBUG_USER and DEBUG_PASSWORD configure the
+	// debug server; the flags override them
+	cfg := debugserver.ConfigFromEnv()
+	flag.StringVar(&cfg.Addr, "debug-addr", cfg.Addr, `debug server address, "" to disable`)
+	flag.StringVar(&cfg.Token, "debug-token", cfg.Token, "bearer token required by the debug server")
+	flag.Parse()
+
+	app := lifecycle.New(lifecycle.Options{})
+
+	// The debug server has its own ServeMux and listener, so nothing else
+	// registered on http.DefaultServeMux is exposed with pprof
+	// pprof will be available at http://localhost:6060/debug/pprof/
+	app.Go("debug server", debugserver.New(cfg).Run)
+
+	// The load below leaks on purpose; the watchdog reports it and writes
+	// a heap profile to ./profiles
+	app.Go("leak watchdog", leakwatch.New(leakwatch.Config{DumpDir: "profiles"}).Run)
 
 	// Give the server time to start before beginning profiling
 	time.Sleep(1 * time.Second)
 
 	// Infinite loop to create controlled load
 	// Runs in a separate goroutine to avoid blocking main
-	go func() {
+	app.Go("load", func(ctx context.Context) error {
 		var data [][]byte // Slice to store allocated memory (simulating leak)
 
 		for {
@@ -44,24 +60,40 @@ func main() {
 			data = append(data, memoryIntensiveTask())
 
 			// Pause between iterations to control load intensity
-			time.Sleep(100 * time.Millisecond)
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-ctx.Done():
+				log.Printf("Load stopped after allocating %d MB", len(data))
+				return nil
+			}
 		}
-	}()
+	})
 	// Informational message about profiling server startup
-	log.Println("Pprof server started on http://localhost:6060")
-	log.Println("Available profiles:")
-	log.Println("  http://localhost:6060/debug/pprof/")
-	log.Println("  http://localhost:6060/debug/pprof/heap")
-	log.Println("  http://localhost:6060/debug/pprof/profile")
-	log.Println("  http://localhost:6060/debug/pprof/goroutine")
-	log.Println("  http://localhost:6060/debug/pprof/block")
-	log.Println("  http://localhost:6060/debug/pprof/mutex")
-	log.Println("  http://localhost:6060/debug/pprof/trace?seconds=5")
+	base := "http://" + cfg.Addr
+	log.Printf("Debug server started on %s", base)
+	log.Println("Available endpoints:")
+	log.Printf("  %s/debug/pprof/", base)
+	log.Printf("  %s/debug/pprof/heap", base)
+	log.Printf("  %s/debug/pprof/profile", base)
+	log.Printf("  %s/debug/pprof/goroutine", base)
+	log.Printf("  %s/debug/pprof/trace?seconds=5", base)
+	log.Printf("  %s/debug/vars", base)
+	log.Printf("  %s/debug/buildinfo", base)
+	log.Printf("  %s/debug/goroutines", base)
+	log.Printf("  %s/debug/gc", base)
 
 	// Verify that the server is responding
 	go func() {
 		time.Sleep(2 * time.Second)
-		resp, err := http.Get("http://localhost:6060/debug/pprof/")
+		req, err := http.NewRequest(http.MethodGet, base+"/debug/pprof/", nil)
+		if err != nil {
+			log.Printf("Error checking pprof: %v", err)
+			return
+		}
+		if cfg.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+cfg.Token)
+		}
+		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			log.Printf("Error checking pprof: %v", err)
 		} else {
@@ -74,6 +106,8 @@ func main() {
 		}
 	}()
 
-	// Infinite blocking to keep the program running
-	select {} // Blocks forever without consuming CPU
+	// Block until Ctrl+C, then stop the load and the server
+	if err := app.Wait(); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
 }
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"flag"
 	"log"
-	"os"
-	"runtime"
-	"runtime/pprof"
 	"time"
+
+	"testing/leakwatch"
+	"testing/profiling"
 )
 
 func cpuIntensiveTask() {
@@ -19,51 +22,55 @@ func memoryIntensiveTask() []byte {
 }
 
 func main() {
-	cpuFile, err := os.Create("./pprof/pprof4/cpu.prof")
-	if err != nil {
-		panic(err)
+	// CPU and heap by default; a heap snapshot is also taken once the heap
+	// grows above 16 MB. -profile, -profile-dir etc. override these
+	cfg := profiling.Config{
+		Kinds:           []profiling.Kind{profiling.CPU, profiling.Heap},
+		Interval:        10 * time.Second,
+		HeapThresholdMB: 16,
 	}
-	defer func(cpuFile *os.File) {
-		err := cpuFile.Close()
-		if err != nil {
-			log.Println("Could not close CPU profile file:", err)
-		}
-	}(cpuFile)
+	if err := profiling.RegisterFlags(flag.CommandLine, &cfg); err != nil {
+		log.Fatal(err)
+	}
+	flag.Parse()
 
-	err2 := pprof.StartCPUProfile(cpuFile)
-	if err2 != nil {
-		return
+	prof, err := profiling.Start(cfg)
+	if err != nil {
+		log.Fatal(err)
 	}
-	defer pprof.StopCPUProfile()
+
+	// The watchdog notices the leak below and dumps a heap profile next to
+	// the other profiles
+	ctx, stopWatch := context.WithCancel(context.Background())
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		_ = leakwatch.New(leakwatch.Config{DumpDir: cmp.Or(cfg.Dir, "profiles")}).Run(ctx)
+	}()
 
 	log.Println("Starting workload for 30 seconds...")
+	data := workload(30*time.Second, 100*time.Millisecond)
+	stopWatch()
+	<-watchDone
+
+	log.Printf("Workload completed with %d MB allocated. Saving profiles...", len(data))
 
+	if err := prof.Stop(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Profiles saved successfully: %v", prof.Files())
+}
+
+// workload runs both tasks every pause for d and keeps every allocation,
+// leaking 1 MB per iteration.
+func workload(d, pause time.Duration) [][]byte {
 	start := time.Now()
 	var data [][]byte
 
-	for time.Since(start) < 30*time.Second {
+	for time.Since(start) < d {
 		cpuIntensiveTask()
 		data = append(data, memoryIntensiveTask())
-		time.Sleep(100 * time.Millisecond)
-	}
-	log.Println("Workload completed. Saving memory profile...")
-	memFile, err := os.Create("./pprof/pprof4/mem.prof")
-	if err != nil {
-		log.Fatal(err)
+		time.Sleep(pause)
 	}
-	defer func(memFile *os.File) {
-		err := memFile.Close()
-		if err != nil {
-			log.Println("Could not close memory profile file:", err)
-		}
-	}(memFile)
-
-	runtime.GC()
-	err3 := pprof.WriteHeapProfile(memFile)
-	if err3 != nil {
-		log.Fatal(err)
-		return
-	}
-
-	log.Println("Profiles saved successfully")
+	return data
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"os"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"go-projects/leakcheck"
+	"testing/leakwatch"
+)
+
+func TestWatchdogDetectsWorkloadLeak(t *testing.T) {
+	tests := []struct {
+		name  string
+		run   func()
+		alert bool
+	}{
+		{
+			name:  "leaking workload",
+			run:   func() { _ = workload(1500*time.Millisecond, 10*time.Millisecond) },
+			alert: true,
+		},
+		{
+			name: "same allocations released",
+			run: func() {
+				for start := time.Now(); time.Since(start) < 1500*time.Millisecond; {
+					cpuIntensiveTask()
+					_ = memoryIntensiveTask()
+					time.Sleep(10 * time.Millisecond)
+				}
+			},
+			alert: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leakcheck.Check(t)
+			// Frequent GC keeps the live heap close to what is really held,
+			// so the trend shows up within a second
+			defer debug.SetGCPercent(debug.SetGCPercent(10))
+
+			dir := t.TempDir()
+			w := leakwatch.New(leakwatch.Config{
+				Interval:            50 * time.Millisecond,
+				Window:              20,
+				HeapMBPerMinute:     60,
+				GoroutinesPerMinute: -1,
+				DumpDir:             dir,
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_ = w.Run(ctx)
+			}()
+			tt.run()
+			cancel()
+			<-done
+
+			alerts := w.Alerts()
+			if !tt.alert {
+				if len(alerts) != 0 {
+					t.Fatalf("alerts = %v, want none", alerts)
+				}
+				return
+			}
+			if len(alerts) == 0 {
+				t.Fatal("leak not detected")
+			}
+			a := alerts[0]
+			if a.Metric != "heap" {
+				t.Errorf("metric = %q, want heap", a.Metric)
+			}
+			if _, err := os.Stat(a.Profile); err != nil {
+				t.Errorf("heap profile not written: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package profdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes the report as tables, like go tool pprof -top.
+func WriteText(w io.Writer, r *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "Type: %s (%s)\n", r.SampleType, r.Unit)
+	fmt.Fprintf(tw, "Total: %s -> %s (%s)\n", format(r.TotalBefore, r.Unit), format(r.TotalAfter, r.Unit), percent(r.TotalAfter-r.TotalBefore, r.TotalBefore))
+
+	writeFunctions(tw, "Top regressions", r.Regressions, r.Unit)
+	writeFunctions(tw, "Top improvements", r.Improvements, r.Unit)
+
+	if len(r.AllocChanges) > 0 {
+		fmt.Fprintf(tw, "\nLargest allocation count changes:\n")
+		fmt.Fprintf(tw, "before\tafter\tdelta\t \tfunction\n")
+		for _, a := range r.AllocChanges {
+			fmt.Fprintf(tw, "%d\t%d\t%+d\t \t%s\n", a.Before, a.After, a.After-a.Before, a.Name)
+		}
+	}
+	return tw.Flush()
+}
+
+func writeFunctions(tw *tabwriter.Writer, title string, fns []Function, unit string) {
+	fmt.Fprintf(tw, "\n%s:\n", title)
+	if len(fns) == 0 {
+		fmt.Fprintf(tw, "(none)\n")
+		return
+	}
+	fmt.Fprintf(tw, "flat before\tflat after\tdelta\tshare\tcum before\tcum after\t \tfunction\n")
+	for _, f := range fns {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%+.2f%%\t%s\t%s\t \t%s\n",
+			format(f.FlatBefore, unit), format(f.FlatAfter, unit), formatDelta(f.Delta, unit), 100*f.Share,
+			format(f.CumBefore, unit), format(f.CumAfter, unit), f.Name)
+	}
+}
+
+func percent(delta, base int64) string {
+	if base == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.2f%%", 100*float64(delta)/float64(base))
+}
+
+func formatDelta(v int64, unit string) string {
+	if v >= 0 {
+		return "+" + format(v, unit)
+	}
+	return "-" + format(-v, unit)
+}
+
+// format prints nanoseconds as durations and bytes with binary prefixes.
+func format(v int64, unit string) string {
+	switch unit {
+	case "nanoseconds":
+		return time.Duration(v).Round(time.Millisecond / 10).String()
+	case "bytes":
+		switch {
+		case v >= 1<<30:
+			return fmt.Sprintf("%.2fGB", float64(v)/(1<<30))
+		case v >= 1<<20:
+			return fmt.Sprintf("%.2fMB", float64(v)/(1<<20))
+		case v >= 1<<10:
+			return fmt.Sprintf("%.2fkB", float64(v)/(1<<10))
+		}
+		return fmt.Sprintf("%dB", v)
+	}
+	return fmt.Sprint(v)
+}
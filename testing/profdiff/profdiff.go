@@ -0,0 +1,275 @@
+// Package profdiff compares two pprof profiles function by function, to see
+// what an optimization actually changed.
+package profdiff
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/google/pprof/profile"
+)
+
+// Options controls Diff. Zero values are replaced by defaults.
+type Options struct {
+	// SampleType is compared, e.g. "cpu", "inuse_space" or "alloc_space".
+	// Default: the profile's default type, as chosen by go tool pprof.
+	SampleType string
+	// Top limits the regressions, improvements and allocation count changes
+	// reported. Default: 10.
+	Top int
+	// Normalize scales the after profile to the before total, to compare
+	// shares of the work instead of absolute values, e.g. for CPU profiles
+	// of different durations.
+	Normalize bool
+}
+
+func (o Options) withDefaults() Options {
+	if o.Top <= 0 {
+		o.Top = 10
+	}
+	return o
+}
+
+// Function is the change of one function between the profiles.
+type Function struct {
+	Name string `json:"name"`
+	File string `json:"file,omitempty"`
+	// Flat is the value in the function itself, Cum includes its callees.
+	FlatBefore int64 `json:"flat_before"`
+	FlatAfter  int64 `json:"flat_after"`
+	CumBefore  int64 `json:"cum_before"`
+	CumAfter   int64 `json:"cum_after"`
+	// Delta is FlatAfter - FlatBefore.
+	Delta int64 `json:"delta"`
+	// Share is Delta as a fraction of the before total.
+	Share float64 `json:"share"`
+}
+
+// Allocs is a change in the number of allocations made by a function.
+type Allocs struct {
+	Name   string `json:"name"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+}
+
+// Report is the result of Diff.
+type Report struct {
+	SampleType  string `json:"sample_type"`
+	Unit        string `json:"unit"`
+	TotalBefore int64  `json:"total_before"`
+	TotalAfter  int64  `json:"total_after"`
+	// Regressions grew the most, largest first.
+	Regressions []Function `json:"regressions"`
+	// Improvements shrank the most, largest first.
+	Improvements []Function `json:"improvements"`
+	// AllocChanges lists the functions whose alloc_objects count changed
+	// the most, largest absolute change first, when both profiles are heap
+	// profiles. Heap profiles are sampled, so small changes are noise.
+	AllocChanges []Allocs `json:"alloc_changes,omitempty"`
+}
+
+// Load reads a profile file, gzipped or not.
+func Load(path string) (*profile.Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	p, err := profile.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("profdiff: %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Diff compares before and after.
+func Diff(before, after *profile.Profile, opts Options) (*Report, error) {
+	opts = opts.withDefaults()
+
+	sampleType := opts.SampleType
+	if sampleType == "" {
+		sampleType = defaultSampleType(before)
+	}
+	bi, unit, err := sampleIndex(before, sampleType)
+	if err != nil {
+		return nil, fmt.Errorf("profdiff: before: %w", err)
+	}
+	ai, _, err := sampleIndex(after, sampleType)
+	if err != nil {
+		return nil, fmt.Errorf("profdiff: after: %w", err)
+	}
+
+	b, bTotal := aggregate(before, bi)
+	a, aTotal := aggregate(after, ai)
+	if opts.Normalize && aTotal != 0 {
+		scale := float64(bTotal) / float64(aTotal)
+		for _, v := range a {
+			v.flat = int64(float64(v.flat) * scale)
+			v.cum = int64(float64(v.cum) * scale)
+		}
+		aTotal = bTotal
+	}
+
+	r := &Report{
+		SampleType:  sampleType,
+		Unit:        unit,
+		TotalBefore: bTotal,
+		TotalAfter:  aTotal,
+		// Empty rather than nil, so JSON readers always get a list
+		Regressions:  []Function{},
+		Improvements: []Function{},
+	}
+	for _, name := range names(b, a) {
+		fb, fa := value(b, name), value(a, name)
+		f := Function{Name: name, FlatBefore: fb.flat, FlatAfter: fa.flat, CumBefore: fb.cum, CumAfter: fa.cum}
+		f.File = fa.file
+		if f.File == "" {
+			f.File = fb.file
+		}
+		f.Delta = f.FlatAfter - f.FlatBefore
+		if bTotal != 0 {
+			f.Share = float64(f.Delta) / float64(bTotal)
+		}
+
+		switch {
+		case f.Delta > 0:
+			r.Regressions = append(r.Regressions, f)
+		case f.Delta < 0:
+			r.Improvements = append(r.Improvements, f)
+		}
+	}
+	// Largest change first, by name for equal changes so output is stable
+	slices.SortStableFunc(r.Regressions, func(x, y Function) int { return cmp.Compare(y.Delta, x.Delta) })
+	slices.SortStableFunc(r.Improvements, func(x, y Function) int { return cmp.Compare(x.Delta, y.Delta) })
+	r.Regressions = r.Regressions[:min(opts.Top, len(r.Regressions))]
+	r.Improvements = r.Improvements[:min(opts.Top, len(r.Improvements))]
+
+	r.AllocChanges = allocChanges(before, after, opts.Top)
+	return r, nil
+}
+
+// allocChanges returns the top largest changes of alloc_objects per
+// function when both profiles have it.
+func allocChanges(before, after *profile.Profile, top int) []Allocs {
+	bi, _, err := sampleIndex(before, "alloc_objects")
+	if err != nil {
+		return nil
+	}
+	ai, _, err := sampleIndex(after, "alloc_objects")
+	if err != nil {
+		return nil
+	}
+
+	b, _ := aggregate(before, bi)
+	a, _ := aggregate(after, ai)
+	var out []Allocs
+	for _, name := range names(b, a) {
+		if before, after := value(b, name).flat, value(a, name).flat; before != after {
+			out = append(out, Allocs{Name: name, Before: before, After: after})
+		}
+	}
+	abs := func(a Allocs) int64 {
+		if d := a.After - a.Before; d < 0 {
+			return -d
+		}
+		return a.After - a.Before
+	}
+	slices.SortStableFunc(out, func(x, y Allocs) int { return cmp.Compare(abs(y), abs(x)) })
+	return out[:min(top, len(out))]
+}
+
+// defaultSampleType mirrors go tool pprof: the profile's DefaultSampleType,
+// else the last sample type.
+func defaultSampleType(p *profile.Profile) string {
+	if p.DefaultSampleType != "" {
+		return p.DefaultSampleType
+	}
+	if len(p.SampleType) == 0 {
+		return ""
+	}
+	return p.SampleType[len(p.SampleType)-1].Type
+}
+
+func sampleIndex(p *profile.Profile, sampleType string) (int, string, error) {
+	for i, st := range p.SampleType {
+		if st.Type == sampleType {
+			return i, st.Unit, nil
+		}
+	}
+	return 0, "", fmt.Errorf("no sample type %q", sampleType)
+}
+
+type totals struct {
+	flat, cum int64
+	file      string
+}
+
+// aggregate sums one sample value per function: flat for the leaf frame,
+// cum once for every function on the stack.
+func aggregate(p *profile.Profile, index int) (map[string]*totals, int64) {
+	out := make(map[string]*totals)
+	get := func(line profile.Line) *totals {
+		name, file := "?", ""
+		if line.Function != nil {
+			name, file = line.Function.Name, line.Function.Filename
+		}
+		t, ok := out[name]
+		if !ok {
+			t = &totals{file: file}
+			out[name] = t
+		}
+		return t
+	}
+
+	var total int64
+	for _, s := range p.Sample {
+		v := s.Value[index]
+		total += v
+
+		seen := make(map[*totals]bool)
+		leaf := true
+		for _, loc := range s.Location {
+			// Inlined frames come first within a location
+			for _, line := range loc.Line {
+				t := get(line)
+				if leaf {
+					t.flat += v
+					leaf = false
+				}
+				if !seen[t] {
+					t.cum += v
+					seen[t] = true
+				}
+			}
+		}
+	}
+	return out, total
+}
+
+// names returns the function names of both maps, sorted.
+func names(a, b map[string]*totals) []string {
+	var out []string
+	for name := range a {
+		out = append(out, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			out = append(out, name)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// value returns the totals of name, zero if the function is not in m.
+func value(m map[string]*totals, name string) totals {
+	if t, ok := m[name]; ok {
+		return *t
+	}
+	return totals{}
+}
@@ -0,0 +1,200 @@
+package profdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+// heapProfile builds a heap profile from stacks, leaf first, with values
+// for alloc_objects and alloc_space.
+func heapProfile(stacks map[string][2]int64) *profile.Profile {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "alloc_objects", Unit: "count"},
+			{Type: "alloc_space", Unit: "bytes"},
+		},
+		DefaultSampleType: "alloc_space",
+	}
+	funcs := make(map[string]*profile.Function)
+	for stack, values := range stacks {
+		var locs []*profile.Location
+		for _, name := range strings.Split(stack, ";") {
+			fn, ok := funcs[name]
+			if !ok {
+				fn = &profile.Function{ID: uint64(len(funcs) + 1), Name: name, Filename: name + ".go"}
+				funcs[name] = fn
+				p.Function = append(p.Function, fn)
+			}
+			loc := &profile.Location{ID: uint64(len(p.Location) + 1), Line: []profile.Line{{Function: fn}}}
+			p.Location = append(p.Location, loc)
+			locs = append(locs, loc)
+		}
+		p.Sample = append(p.Sample, &profile.Sample{Location: locs, Value: []int64{values[0], values[1]}})
+	}
+	return p
+}
+
+func TestDiff(t *testing.T) {
+	before := heapProfile(map[string][2]int64{
+		"concat;main": {1000, 64 << 20},
+		"parse;main":  {10, 1 << 20},
+		"log;main":    {5, 1 << 10},
+	})
+	after := heapProfile(map[string][2]int64{
+		"build;main": {1, 16 << 20},
+		"parse;main": {20, 2 << 20},
+		"log;main":   {5, 1 << 10},
+	})
+
+	r, err := Diff(before, after, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.SampleType != "alloc_space" || r.Unit != "bytes" {
+		t.Errorf("sample type = %s (%s), want alloc_space (bytes)", r.SampleType, r.Unit)
+	}
+	if got := functionNames(r.Regressions); got != "build,parse" {
+		t.Errorf("regressions = %s, want build,parse", got)
+	}
+	if got := functionNames(r.Improvements); got != "concat" {
+		t.Errorf("improvements = %s, want concat", got)
+	}
+	if main := r.Improvements[0]; main.CumBefore != 64<<20 {
+		t.Errorf("concat cum before = %d, want %d", main.CumBefore, 64<<20)
+	}
+
+	want := []Allocs{{"concat", 1000, 0}, {"parse", 10, 20}, {"build", 0, 1}}
+	if len(r.AllocChanges) != len(want) {
+		t.Fatalf("alloc changes = %+v, want %+v", r.AllocChanges, want)
+	}
+	for i := range want {
+		if r.AllocChanges[i] != want[i] {
+			t.Errorf("alloc change %d = %+v, want %+v", i, r.AllocChanges[i], want[i])
+		}
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	before := heapProfile(map[string][2]int64{"a;main": {1, 100}, "b;main": {1, 100}})
+	after := heapProfile(map[string][2]int64{"a;main": {1, 400}, "b;main": {1, 400}})
+
+	tests := []struct {
+		name        string
+		opts        Options
+		regressions string
+		err         bool
+	}{
+		{"absolute", Options{}, "a,b", false},
+		{"top", Options{Top: 1}, "a", false},
+		{"normalized", Options{Normalize: true}, "", false},
+		{"counts", Options{SampleType: "alloc_objects"}, "", false},
+		{"unknown type", Options{SampleType: "cpu"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Diff(before, after, tt.opts)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := functionNames(r.Regressions); got != tt.regressions {
+				t.Errorf("regressions = %q, want %q", got, tt.regressions)
+			}
+		})
+	}
+}
+
+func TestAllocChangesTop(t *testing.T) {
+	before := heapProfile(map[string][2]int64{"a;main": {100, 1}, "b;main": {100, 1}, "c;main": {100, 1}, "d;main": {100, 1}})
+	after := heapProfile(map[string][2]int64{"a;main": {101, 1}, "b;main": {50, 1}, "c;main": {300, 1}, "d;main": {100, 1}})
+
+	r, err := Diff(before, after, Options{Top: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Allocs{{"c", 100, 300}, {"b", 100, 50}}
+	if len(r.AllocChanges) != len(want) || r.AllocChanges[0] != want[0] || r.AllocChanges[1] != want[1] {
+		t.Errorf("alloc changes = %+v, want %+v", r.AllocChanges, want)
+	}
+}
+
+func TestJSONEmptyLists(t *testing.T) {
+	p := heapProfile(map[string][2]int64{"a;main": {1, 100}})
+	r, err := Diff(p, p, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"regressions": []`, `"improvements": []`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("JSON lacks %s:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	before := heapProfile(map[string][2]int64{"concat;main": {1000, 64 << 20}})
+	after := heapProfile(map[string][2]int64{"build;main": {1, 16 << 20}})
+	r, err := Diff(before, after, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text bytes.Buffer
+	if err := WriteText(&text, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"64.00MB -> 16.00MB (-75.00%)", "Top regressions", "build", "Largest allocation count changes"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text output lacks %q:\n%s", want, text.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.TotalBefore != 64<<20 || len(decoded.AllocChanges) != 2 {
+		t.Errorf("decoded report = %+v", decoded)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	// The profiles kept in the repository from the string concatenation demo
+	for _, name := range []string{"cpu_profile.prof", "cpu_optimized.prof"} {
+		p, err := Load(filepath.Join("..", "pprof", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := sampleIndex(p, "cpu"); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func functionNames(fns []Function) string {
+	var out []string
+	for _, f := range fns {
+		out = append(out, f.Name)
+	}
+	return strings.Join(out, ",")
+}
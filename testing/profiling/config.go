@@ -0,0 +1,192 @@
+package profiling
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is a profile type.
+type Kind string
+
+const (
+	CPU       Kind = "cpu"
+	Heap      Kind = "heap"
+	Goroutine Kind = "goroutine"
+	Block     Kind = "block"
+	Mutex     Kind = "mutex"
+	Trace     Kind = "trace"
+)
+
+// AllKinds lists every supported Kind.
+var AllKinds = []Kind{CPU, Heap, Goroutine, Block, Mutex, Trace}
+
+// Environment variables read by ApplyEnv and RegisterFlags.
+const (
+	EnvProfile   = "PROFILE"            // Kinds, e.g. "cpu,heap" or "all"
+	EnvDir       = "PROFILE_DIR"        // Output directory
+	EnvInterval  = "PROFILE_INTERVAL"   // Snapshot period, e.g. "30s"
+	EnvHeapMB    = "PROFILE_HEAP_MB"    // Heap threshold in MB
+	EnvGoroutine = "PROFILE_GOROUTINES" // Goroutine count threshold
+)
+
+// Config selects what to profile and when. Zero values are replaced by
+// defaults in Start; an empty Kinds disables profiling.
+type Config struct {
+	Kinds []Kind
+	// Dir receives the profiles. It is created if needed. Default: "profiles".
+	Dir string
+
+	// Interval is how often heap, goroutine, block and mutex snapshots are
+	// taken and the CPU profile is rotated. Zero: only at Stop.
+	Interval time.Duration
+
+	// HeapThresholdMB takes a heap and goroutine snapshot when the live heap
+	// grows above it. Zero disables the check.
+	HeapThresholdMB int
+	// GoroutineThreshold takes a goroutine snapshot when more goroutines are
+	// running. Zero disables the check.
+	GoroutineThreshold int
+	// CheckInterval is how often thresholds are checked. Default: 1s.
+	CheckInterval time.Duration
+	// Cooldown is the least time between two threshold snapshots of the
+	// same kind, so a program stuck above a threshold does not fill the
+	// disk. Default: 1m.
+	Cooldown time.Duration
+
+	// BlockRate is passed to runtime.SetBlockProfileRate when Block is
+	// enabled. Default: 10000 (one sample per 10µs blocked).
+	BlockRate int
+	// MutexFraction is passed to runtime.SetMutexProfileFraction when Mutex
+	// is enabled. Default: 5.
+	MutexFraction int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Dir == "" {
+		c.Dir = "profiles"
+	}
+	if c.CheckInterval <= 0 {
+		c.CheckInterval = time.Second
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = time.Minute
+	}
+	if c.BlockRate <= 0 {
+		c.BlockRate = 10000
+	}
+	if c.MutexFraction <= 0 {
+		c.MutexFraction = 5
+	}
+	return c
+}
+
+// Enabled reports whether kind is selected.
+func (c Config) Enabled(kind Kind) bool {
+	for _, k := range c.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKinds parses a comma-separated list of kinds. "all" selects every
+// kind; "" and "off" select none.
+func ParseKinds(s string) ([]Kind, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return nil, nil
+	}
+	if s == "all" {
+		return append([]Kind(nil), AllKinds...), nil
+	}
+
+	var kinds []Kind
+	for _, part := range strings.Split(s, ",") {
+		kind := Kind(strings.ToLower(strings.TrimSpace(part)))
+		known := false
+		for _, k := range AllKinds {
+			known = known || k == kind
+		}
+		if !known {
+			return nil, fmt.Errorf("profiling: unknown profile %q", part)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// ApplyEnv overrides c with the PROFILE* environment variables that are set.
+func (c *Config) ApplyEnv() error {
+	if v, ok := os.LookupEnv(EnvProfile); ok {
+		kinds, err := ParseKinds(v)
+		if err != nil {
+			return err
+		}
+		c.Kinds = kinds
+	}
+	if v := os.Getenv(EnvDir); v != "" {
+		c.Dir = v
+	}
+	if v := os.Getenv(EnvInterval); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("profiling: %s: %w", EnvInterval, err)
+		}
+		c.Interval = d
+	}
+	for name, dst := range map[string]*int{EnvHeapMB: &c.HeapThresholdMB, EnvGoroutine: &c.GoroutineThreshold} {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("profiling: %s: %w", name, err)
+			}
+			*dst = n
+		}
+	}
+	return nil
+}
+
+// kindsFlag is a flag.Value for a list of kinds.
+type kindsFlag struct{ kinds *[]Kind }
+
+func (f kindsFlag) String() string {
+	if f.kinds == nil {
+		return ""
+	}
+	parts := make([]string, len(*f.kinds))
+	for i, k := range *f.kinds {
+		parts[i] = string(k)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f kindsFlag) Set(s string) error {
+	kinds, err := ParseKinds(s)
+	if err != nil {
+		return err
+	}
+	*f.kinds = kinds
+	return nil
+}
+
+// RegisterFlags applies the environment to c and registers flags that
+// override it: -profile, -profile-dir, -profile-interval, -profile-heap-mb
+// and -profile-goroutines. Precedence is flag, then environment, then the
+// values already in c. Call it before fs.Parse.
+func RegisterFlags(fs *flag.FlagSet, c *Config) error {
+	if err := c.ApplyEnv(); err != nil {
+		return err
+	}
+	fs.Var(kindsFlag{&c.Kinds}, "profile", `profiles to capture: "all" or a list of `+
+		"cpu,heap,goroutine,block,mutex,trace (env "+EnvProfile+")")
+	fs.StringVar(&c.Dir, "profile-dir", c.Dir, "directory for profiles (env "+EnvDir+")")
+	fs.DurationVar(&c.Interval, "profile-interval", c.Interval, "snapshot period, 0 for only at exit (env "+EnvInterval+")")
+	fs.IntVar(&c.HeapThresholdMB, "profile-heap-mb", c.HeapThresholdMB, "snapshot when the heap grows above this many MB (env "+EnvHeapMB+")")
+	fs.IntVar(&c.GoroutineThreshold, "profile-goroutines", c.GoroutineThreshold, "snapshot when more goroutines are running (env "+EnvGoroutine+")")
+	return nil
+}
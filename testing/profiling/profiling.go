@@ -0,0 +1,333 @@
+// Package profiling captures pprof profiles and execution traces without
+// hand-written StartCPUProfile/WriteHeapProfile calls in every program.
+//
+// A program registers the flags (or relies on the PROFILE environment
+// variable), calls Start and defers Stop:
+//
+//	cfg := profiling.Config{}
+//	_ = profiling.RegisterFlags(flag.CommandLine, &cfg)
+//	flag.Parse()
+//	prof, err := profiling.Start(cfg)
+//	...
+//	defer prof.Stop()
+//
+// Running it with -profile=cpu,heap or PROFILE=all writes timestamped files
+// such as profiles/heap-20250101-120000.000-final.pprof. Besides the final
+// snapshot at Stop, snapshots are taken every Config.Interval and whenever
+// the heap or goroutine count crosses a threshold.
+package profiling
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync"
+	"time"
+
+	"github.com/cooler-SAI/go-Tools/zerolog"
+)
+
+// Profiler owns the running profiles of one program. A Profiler started with
+// no kinds does nothing.
+type Profiler struct {
+	cfg Config
+
+	mu        sync.Mutex
+	cpuFile   *os.File
+	traceFile *os.File
+	files     []string
+	lastHit   map[Kind]time.Time // Last threshold snapshot per kind
+	stopped   bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Start begins profiling according to cfg.
+func Start(cfg Config) (*Profiler, error) {
+	cfg = cfg.withDefaults()
+	p := &Profiler{cfg: cfg, lastHit: make(map[Kind]time.Time), stop: make(chan struct{})}
+	if len(cfg.Kinds) == 0 && cfg.HeapThresholdMB <= 0 && cfg.GoroutineThreshold <= 0 {
+		p.stopped = true
+		return p, nil
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("profiling: %w", err)
+	}
+	if cfg.Enabled(Block) {
+		runtime.SetBlockProfileRate(cfg.BlockRate)
+	}
+	if cfg.Enabled(Mutex) {
+		runtime.SetMutexProfileFraction(cfg.MutexFraction)
+	}
+	if cfg.Enabled(CPU) {
+		if err := p.startCPU(); err != nil {
+			p.resetRates()
+			return nil, err
+		}
+	}
+	if cfg.Enabled(Trace) {
+		if err := p.startTrace(); err != nil {
+			p.stopCPU()
+			p.resetRates()
+			return nil, err
+		}
+	}
+
+	if cfg.Interval > 0 {
+		p.wg.Add(1)
+		go p.periodic()
+	}
+	if cfg.HeapThresholdMB > 0 || cfg.GoroutineThreshold > 0 {
+		p.wg.Add(1)
+		go p.watchThresholds()
+	}
+
+	zerolog.Log.Info().Str("dir", cfg.Dir).Interface("profiles", cfg.Kinds).Msg("Profiling started")
+	return p, nil
+}
+
+// Snapshot writes the enabled heap, goroutine, block and mutex profiles now
+// and returns their paths. reason becomes part of the file names.
+func (p *Profiler) Snapshot(reason string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		return nil, nil
+	}
+	return p.snapshotLocked(reason, p.lookupKinds()...)
+}
+
+// lookupKinds returns the enabled kinds that are written as snapshots.
+func (p *Profiler) lookupKinds() []Kind {
+	var kinds []Kind
+	for _, k := range []Kind{Heap, Goroutine, Block, Mutex} {
+		if p.cfg.Enabled(k) {
+			kinds = append(kinds, k)
+		}
+	}
+	return kinds
+}
+
+// Files returns the paths of all profiles written so far.
+func (p *Profiler) Files() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.files...)
+}
+
+// Stop takes a final snapshot, finishes the CPU profile and trace, and
+// restores the block and mutex profile rates. It is safe to call twice.
+func (p *Profiler) Stop() error {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return nil
+	}
+	p.stopped = true
+	p.mu.Unlock()
+
+	close(p.stop)
+	p.wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err := p.snapshotLocked("final", p.lookupKinds()...)
+	err = errors.Join(err, p.stopCPU(), p.stopTrace())
+	p.resetRates()
+
+	zerolog.Log.Info().Int("files", len(p.files)).Str("dir", p.cfg.Dir).Msg("Profiling stopped")
+	return err
+}
+
+// path returns a new file name such as heap-20250101-120000.000-final.pprof.
+func (p *Profiler) path(kind Kind, reason string) string {
+	name := string(kind) + "-" + time.Now().Format("20060102-150405.000")
+	if reason != "" {
+		name += "-" + reason
+	}
+	if kind == Trace {
+		return filepath.Join(p.cfg.Dir, name+".out")
+	}
+	return filepath.Join(p.cfg.Dir, name+".pprof")
+}
+
+func (p *Profiler) snapshotLocked(reason string, kinds ...Kind) ([]string, error) {
+	var written []string
+	var errs []error
+	for _, kind := range kinds {
+		path := p.path(kind, reason)
+		if err := writeLookup(kind, path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		written = append(written, path)
+	}
+	p.files = append(p.files, written...)
+	return written, errors.Join(errs...)
+}
+
+func writeLookup(kind Kind, path string) error {
+	if kind == Heap {
+		runtime.GC() // Up-to-date live heap, as in the old pprof4
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("profiling: %w", err)
+	}
+	err = pprof.Lookup(string(kind)).WriteTo(f, 0)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("profiling: write %s: %w", path, err)
+	}
+	return nil
+}
+
+func (p *Profiler) startCPU() error {
+	path := p.path(CPU, "")
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("profiling: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return fmt.Errorf("profiling: start CPU profile: %w", err)
+	}
+	p.cpuFile = f
+	return nil
+}
+
+func (p *Profiler) stopCPU() error {
+	if p.cpuFile == nil {
+		return nil
+	}
+	pprof.StopCPUProfile()
+	err := p.cpuFile.Close()
+	p.files = append(p.files, p.cpuFile.Name())
+	p.cpuFile = nil
+	return err
+}
+
+func (p *Profiler) startTrace() error {
+	f, err := os.Create(p.path(Trace, ""))
+	if err != nil {
+		return fmt.Errorf("profiling: %w", err)
+	}
+	if err := trace.Start(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("profiling: start trace: %w", err)
+	}
+	p.traceFile = f
+	return nil
+}
+
+func (p *Profiler) stopTrace() error {
+	if p.traceFile == nil {
+		return nil
+	}
+	trace.Stop()
+	err := p.traceFile.Close()
+	p.files = append(p.files, p.traceFile.Name())
+	p.traceFile = nil
+	return err
+}
+
+func (p *Profiler) resetRates() {
+	if p.cfg.Enabled(Block) {
+		runtime.SetBlockProfileRate(0)
+	}
+	if p.cfg.Enabled(Mutex) {
+		runtime.SetMutexProfileFraction(0)
+	}
+}
+
+// periodic takes snapshots and rotates the CPU profile every Interval.
+func (p *Profiler) periodic() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.Lock()
+		_, err := p.snapshotLocked("periodic", p.lookupKinds()...)
+		if err == nil && p.cpuFile != nil {
+			err = p.stopCPU()
+			if startErr := p.startCPU(); startErr != nil {
+				err = errors.Join(err, startErr)
+			}
+		}
+		p.mu.Unlock()
+		if err != nil {
+			zerolog.Log.Error().Err(err).Msg("Periodic profile failed")
+		}
+	}
+}
+
+// heapMetric is the live heap as reported by runtime/metrics, which unlike
+// runtime.ReadMemStats does not stop the world.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// watchThresholds takes snapshots when the heap or goroutine thresholds are
+// crossed, at most once per Cooldown for each.
+func (p *Profiler) watchThresholds() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	sample := []metrics.Sample{{Name: heapMetric}}
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if limit := uint64(p.cfg.HeapThresholdMB) << 20; limit > 0 {
+			metrics.Read(sample)
+			if heap := sample[0].Value.Uint64(); heap > limit {
+				p.thresholdHit(Heap, "heap-threshold", "heap_mb", int(heap>>20), Heap, Goroutine)
+			}
+		}
+		if limit := p.cfg.GoroutineThreshold; limit > 0 {
+			if n := runtime.NumGoroutine(); n > limit {
+				p.thresholdHit(Goroutine, "goroutine-threshold", "goroutines", n, Goroutine)
+			}
+		}
+	}
+}
+
+// thresholdHit snapshots kinds, enabled or not, unless the trigger is still
+// in its cooldown. key and value describe the crossing in the log.
+func (p *Profiler) thresholdHit(trigger Kind, reason, key string, value int, kinds ...Kind) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if last, ok := p.lastHit[trigger]; ok && time.Since(last) < p.cfg.Cooldown {
+		return
+	}
+	p.lastHit[trigger] = time.Now()
+
+	written, err := p.snapshotLocked(reason, kinds...)
+	if err != nil {
+		zerolog.Log.Error().Err(err).Str("reason", reason).Msg("Threshold profile failed")
+		return
+	}
+	zerolog.Log.Warn().Int(key, value).Strs("files", written).Str("reason", reason).Msg("Threshold crossed, profile captured")
+}
@@ -0,0 +1,163 @@
+package profiling
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKinds(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []Kind
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"off", nil, false},
+		{"cpu", []Kind{CPU}, false},
+		{"cpu, Heap,trace", []Kind{CPU, Heap, Trace}, false},
+		{"all", AllKinds, false},
+		{"cpu,disk", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseKinds(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKinds(%q): err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseKinds(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterFlagsPrecedence(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		args      []string
+		wantKinds []Kind
+		wantDir   string
+	}{
+		{"code default", "", nil, []Kind{CPU}, "default-dir"},
+		{"env overrides default", "heap,mutex", nil, []Kind{Heap, Mutex}, "default-dir"},
+		{"flag overrides env", "heap", []string{"-profile=trace", "-profile-dir=flag-dir"}, []Kind{Trace}, "flag-dir"},
+		{"env turns profiling off", "off", nil, nil, "default-dir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv(EnvProfile, tt.env)
+			} else {
+				os.Unsetenv(EnvProfile)
+			}
+
+			cfg := Config{Kinds: []Kind{CPU}, Dir: "default-dir"}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			if err := RegisterFlags(fs, &cfg); err != nil {
+				t.Fatalf("RegisterFlags: %v", err)
+			}
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !slices.Equal(cfg.Kinds, tt.wantKinds) || cfg.Dir != tt.wantDir {
+				t.Errorf("got kinds %v dir %q, want %v %q", cfg.Kinds, cfg.Dir, tt.wantKinds, tt.wantDir)
+			}
+		})
+	}
+}
+
+func TestRegisterFlagsThresholds(t *testing.T) {
+	t.Setenv(EnvHeapMB, "100")
+	t.Setenv(EnvGoroutine, "500")
+
+	var cfg Config
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := RegisterFlags(fs, &cfg); err != nil {
+		t.Fatalf("RegisterFlags: %v", err)
+	}
+	if err := fs.Parse([]string{"-profile-goroutines=1000"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.HeapThresholdMB != 100 || cfg.GoroutineThreshold != 1000 {
+		t.Errorf("got heap %d MB, %d goroutines; want 100 from env and 1000 from the flag",
+			cfg.HeapThresholdMB, cfg.GoroutineThreshold)
+	}
+}
+
+// kindsOf returns the kinds and reasons of the written files, e.g. "heap-final".
+func kindsOf(files []string) []string {
+	var out []string
+	for _, f := range files {
+		base := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(f), ".pprof"), ".out")
+		parts := strings.Split(base, "-")
+		name := parts[0]
+		if len(parts) > 3 {
+			name += "-" + strings.Join(parts[3:], "-")
+		}
+		out = append(out, name)
+	}
+	slices.Sort(out)
+	return out
+}
+
+var ballast []byte
+
+func TestStartStop(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{"disabled", Config{}, nil},
+		{"cpu and heap", Config{Kinds: []Kind{CPU, Heap}}, []string{"cpu", "heap-final"}},
+		{"all", Config{Kinds: AllKinds}, []string{"block-final", "cpu", "goroutine-final", "heap-final", "mutex-final", "trace"}},
+		{
+			"periodic",
+			Config{Kinds: []Kind{Goroutine}, Interval: 30 * time.Millisecond},
+			[]string{"goroutine-final", "goroutine-periodic", "goroutine-periodic"},
+		},
+		{
+			"heap threshold",
+			Config{HeapThresholdMB: 1, CheckInterval: 10 * time.Millisecond},
+			[]string{"goroutine-heap-threshold", "heap-heap-threshold"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Dir = t.TempDir()
+			p, err := Start(tt.cfg)
+			if err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+
+			ballast = make([]byte, 4<<20) // Keeps the heap above 1 MB
+			time.Sleep(80 * time.Millisecond)
+			if err := p.Stop(); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+			ballast = nil
+
+			got := kindsOf(p.Files())
+			if tt.name == "periodic" && len(got) > 3 {
+				got = got[:3] // Timing decides how many periodic snapshots fit
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("files %v, want %v", got, tt.want)
+			}
+			for _, f := range p.Files() {
+				if info, err := os.Stat(f); err != nil || info.Size() == 0 {
+					t.Errorf("%s is missing or empty: %v", f, err)
+				}
+			}
+		})
+	}
+}